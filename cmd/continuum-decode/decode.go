@@ -0,0 +1,70 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// continuum-decode reads a stream of binary marker records, as
+// written by a continuum.BinarySink, and prints one human-readable
+// event per record.  It reads the file named as its argument, or
+// standard input when run without arguments.
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+)
+
+func main() {
+	in := os.Stdin
+	if len(os.Args) == 2 {
+		file, err := os.Open(os.Args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+			os.Exit(1)
+		}
+		defer file.Close()
+		in = file
+	} else if len(os.Args) > 2 {
+		fmt.Fprintf(os.Stderr, "usage: %s [marker file]\n", os.Args[0])
+		os.Exit(1)
+	}
+	if err := decode(bufio.NewReader(in), os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
+
+// decode prints the records of in to out until a clean end of stream.
+func decode(in io.Reader, out io.Writer) error {
+	for record := 0; ; record++ {
+		m, err := continuum.ReadBinaryMarker(in)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", record, err)
+		}
+		line := fmt.Sprintf("%s marker=%04d category=%s",
+			time.Unix(0, m.Time).UTC().Format(time.RFC3339Nano), m.ID, m.Category())
+		if m.TraceID != "" {
+			line += fmt.Sprintf(" traceID=%q", m.TraceID)
+		}
+		fmt.Fprintln(out, line)
+	}
+}
@@ -20,14 +20,25 @@ import (
 	"k8s.io/component-base/cli"
 	"k8s.io/kubectl/pkg/cmd"
 	"k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/cmd/util/continuum"
 
 	// Import to initialize client auth plugins.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 )
 
 func main() {
+	// CONTINUUM markers are no-ops unless KUBECTL_CONTINUUM is set.
+	continuum.EmitMarker(continuum.MarkerInvocationStart)
 	command := cmd.NewDefaultKubectlCommand()
-	if err := cli.RunNoErrOutput(command); err != nil {
+	continuum.EmitMarker(continuum.MarkerCommandConstructed)
+	err := cli.RunNoErrOutput(command)
+	continuum.EmitMarker(continuum.MarkerInvocationFinish)
+	exitCode := 0
+	if err != nil {
+		exitCode = util.DefaultErrorExitCode
+	}
+	continuum.PrintSummary(exitCode)
+	if err != nil {
 		// Pretty-print the error and exit with an error.
 		util.CheckErr(err)
 	}
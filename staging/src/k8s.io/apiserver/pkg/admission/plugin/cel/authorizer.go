@@ -0,0 +1,55 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"context"
+
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+// NoopAuthorizer answers every authorization check with the same
+// configured decision, without consulting any external machinery.  It
+// exists so tests and benchmarks of authorizer-referencing expressions
+// can satisfy OptionalVariableBindings.Authorizer without building
+// SubjectAccessReview plumbing.  The zero value returns
+// DecisionNoOpinion; it is stateless and safe for concurrent use.
+type NoopAuthorizer struct {
+	// Decision is returned for every check.
+	Decision authorizer.Decision
+
+	// Reason is returned alongside Decision.
+	Reason string
+}
+
+var _ authorizer.Authorizer = &NoopAuthorizer{}
+
+// NewAlwaysAllowAuthorizer returns a NoopAuthorizer that allows
+// everything.
+func NewAlwaysAllowAuthorizer() *NoopAuthorizer {
+	return &NoopAuthorizer{Decision: authorizer.DecisionAllow}
+}
+
+// NewAlwaysDenyAuthorizer returns a NoopAuthorizer that denies
+// everything.
+func NewAlwaysDenyAuthorizer() *NoopAuthorizer {
+	return &NoopAuthorizer{Decision: authorizer.DecisionDeny}
+}
+
+func (a *NoopAuthorizer) Authorize(ctx context.Context, attrs authorizer.Attributes) (authorizer.Decision, string, error) {
+	return a.Decision, a.Reason, nil
+}
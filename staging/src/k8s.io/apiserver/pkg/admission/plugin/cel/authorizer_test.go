@@ -0,0 +1,78 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/admission"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+func TestNoopAuthorizer(t *testing.T) {
+	expression := "authorizer.group('').resource('endpoints').check('create').allowed()"
+	cases := []struct {
+		name       string
+		authorizer *NoopAuthorizer
+		want       bool
+	}{
+		{
+			name:       "always allow",
+			authorizer: NewAlwaysAllowAuthorizer(),
+			want:       true,
+		},
+		{
+			name:       "always deny",
+			authorizer: NewAlwaysDenyAuthorizer(),
+			want:       false,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+			f := compiler.Compile([]ExpressionAccessor{
+				&condition{Expression: expression},
+			}, OptionalVariableDeclarations{HasAuthorizer: true, StrictCost: true}, environment.NewExpressions)
+			if errs := f.CompilationErrors(); len(errs) > 0 {
+				t.Fatalf("unexpected compilation errors: %v", errs)
+			}
+
+			attr := endpointCreateAttributes()
+			versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+			if err != nil {
+				t.Fatalf("unexpected error on conversion: %v", err)
+			}
+			optionalVars := OptionalVariableBindings{Authorizer: tc.authorizer}
+			evalResults, _, err := f.ForInput(context.TODO(), versionedAttr, CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind)), optionalVars, nil, celconfig.RuntimeCELCostBudget)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(evalResults) != 1 {
+				t.Fatalf("expected one evaluation result, got %d", len(evalResults))
+			}
+			if evalResults[0].Error != nil {
+				t.Fatalf("unexpected evaluation error: %v", evalResults[0].Error)
+			}
+			if got := evalResults[0].EvalResult.Value(); got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"fmt"
+	"strings"
+
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/cel/environment"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/utils/lru"
+)
+
+// DefaultFilterCacheSize is the eviction bound used by
+// NewCachingFilterCompiler when callers have no better-informed size.
+const DefaultFilterCacheSize = 1024
+
+var filterCacheRequests = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Namespace:      "apiserver",
+		Subsystem:      "cel",
+		Name:           "filter_compilation_cache_requests_total",
+		Help:           "Number of filter compilation cache lookups, labeled by whether a previously compiled filter was found.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+func init() {
+	legacyregistry.MustRegister(filterCacheRequests)
+}
+
+// cachingFilterCompiler wraps a FilterCompiler with an LRU cache of
+// compiled Filters.  Admission policies and webhook match conditions
+// compile the same stable expressions repeatedly; compilation dominates
+// that cost, and compilation results are immutable and safe for
+// concurrent use.  Filters themselves are not shareable, because Close
+// is permanent: the cache therefore holds a pristine instance per key
+// and hands every caller its own Filter over the shared compilation,
+// so one caller closing its Filter does not poison the others'.
+type cachingFilterCompiler struct {
+	compiler FilterCompiler
+	cache    *lru.Cache
+}
+
+// NewCachingFilterCompiler wraps the given compiler with an LRU cache
+// holding at most size compiled Filters.  Compile results are cached by
+// expression text, return types, the optional variable declarations,
+// the environment type, and the per-call cost limit; any difference in
+// those compiles afresh.
+func NewCachingFilterCompiler(compiler FilterCompiler, size int) FilterCompiler {
+	return &cachingFilterCompiler{
+		compiler: compiler,
+		cache:    lru.New(size),
+	}
+}
+
+// EstimateCost delegates to the wrapped compiler; estimation is cheap
+// relative to program instantiation and is not cached.
+func (c *cachingFilterCompiler) EstimateCost(expressions []ExpressionAccessor, options OptionalVariableDeclarations) ([]uint64, error) {
+	return c.compiler.EstimateCost(expressions, options)
+}
+
+// Validate is cheap relative to program instantiation and is not cached.
+func (c *cachingFilterCompiler) Validate(expression ExpressionAccessor, options OptionalVariableDeclarations) []error {
+	return c.compiler.Validate(expression, options)
+}
+
+func (c *cachingFilterCompiler) Compile(expressions []ExpressionAccessor, options OptionalVariableDeclarations, mode environment.Type) Filter {
+	// The Compile path always compiles under the default per-call
+	// cost limit; only the uncached CompileWithOptions varies it.
+	// The key records the limit anyway, so that applying the cache
+	// to a limit-varying path cannot silently collide filters
+	// compiled under different limits.
+	key := filterCacheKey(expressions, options, mode, celconfig.PerCallLimit)
+	if cached, ok := c.cache.Get(key); ok {
+		filterCacheRequests.WithLabelValues("hit").Inc()
+		return cached.(*filter).shareCompiled()
+	}
+	filterCacheRequests.WithLabelValues("miss").Inc()
+	compiled := c.compiler.Compile(expressions, options, mode)
+	inner, ok := compiled.(*filter)
+	if !ok {
+		// An unfamiliar Filter implementation cannot be copied per
+		// caller, and sharing one closable instance would let one
+		// caller's Close poison the others; skip the cache.
+		return compiled
+	}
+	c.cache.Add(key, inner)
+	return inner.shareCompiled()
+}
+
+// filterCacheKey renders everything the compilation result depends on
+// into a string key, including the per-call cost limit the expressions
+// are compiled under.  Expression text may contain anything, so entries
+// are delimited by NUL bytes, which cannot appear in CEL source.
+func filterCacheKey(expressions []ExpressionAccessor, options OptionalVariableDeclarations, mode environment.Type, perCallLimit uint64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%t\x00%t\x00%t\x00%t\x00%d\x00%s", options.HasParams, options.HasAuthorizer, options.StrictCost, options.HasChangedFields, perCallLimit, mode)
+	for _, expression := range expressions {
+		b.WriteString("\x00")
+		if expression == nil {
+			continue
+		}
+		b.WriteString(expression.GetExpression())
+		for _, returnType := range expression.ReturnTypes() {
+			b.WriteString("\x00")
+			b.WriteString(returnType.String())
+		}
+	}
+	return b.String()
+}
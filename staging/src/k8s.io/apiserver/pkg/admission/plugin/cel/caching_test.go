@@ -0,0 +1,127 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"sync"
+	"testing"
+
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+// sharesCompilation reports whether the two filters were built over
+// the same compilation, i.e. both came from one cache entry.
+func sharesCompilation(a, b Filter) bool {
+	af, aok := a.(*filter)
+	bf, bok := b.(*filter)
+	return aok && bok &&
+		len(af.compilationResults) > 0 && len(bf.compilationResults) > 0 &&
+		&af.compilationResults[0] == &bf.compilationResults[0]
+}
+
+func TestCachingFilterCompiler(t *testing.T) {
+	compiler := NewCachingFilterCompiler(
+		NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true)),
+		DefaultFilterCacheSize)
+
+	expressions := []ExpressionAccessor{&condition{Expression: "object.spec.replicas < 100"}}
+	decls := OptionalVariableDeclarations{HasParams: false, HasAuthorizer: false, StrictCost: true}
+
+	first := compiler.Compile(expressions, decls, environment.NewExpressions)
+	second := compiler.Compile(expressions, decls, environment.NewExpressions)
+	if !sharesCompilation(first, second) {
+		t.Errorf("expected identical expressions to reuse the cached compilation")
+	}
+	if first == second {
+		t.Errorf("expected each caller to get its own filter instance")
+	}
+
+	otherDecls := compiler.Compile(expressions, OptionalVariableDeclarations{HasParams: true, HasAuthorizer: false, StrictCost: true}, environment.NewExpressions)
+	if sharesCompilation(otherDecls, first) {
+		t.Errorf("expected differing variable declarations to compile afresh")
+	}
+
+	otherMode := compiler.Compile(expressions, decls, environment.StoredExpressions)
+	if sharesCompilation(otherMode, first) {
+		t.Errorf("expected differing environment type to compile afresh")
+	}
+
+	otherExpression := compiler.Compile([]ExpressionAccessor{&condition{Expression: "object.spec.replicas < 101"}}, decls, environment.NewExpressions)
+	if sharesCompilation(otherExpression, first) {
+		t.Errorf("expected differing expression to compile afresh")
+	}
+}
+
+func TestCachingFilterCompilerCloseIsolation(t *testing.T) {
+	compiler := NewCachingFilterCompiler(
+		NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true)),
+		DefaultFilterCacheSize)
+
+	expressions := []ExpressionAccessor{&condition{Expression: "object.spec.replicas < 100"}}
+	decls := OptionalVariableDeclarations{StrictCost: true}
+
+	first := compiler.Compile(expressions, decls, environment.NewExpressions)
+	second := compiler.Compile(expressions, decls, environment.NewExpressions)
+	first.Close()
+	if err := second.(*filter).closedErr(); err != nil {
+		t.Errorf("closing one caller's filter must not close another caller's: %v", err)
+	}
+	third := compiler.Compile(expressions, decls, environment.NewExpressions)
+	if !sharesCompilation(third, first) {
+		t.Errorf("expected the cache entry to survive a caller's Close")
+	}
+	if err := third.(*filter).closedErr(); err != nil {
+		t.Errorf("expected a freshly handed-out filter to be open: %v", err)
+	}
+}
+
+func TestCachingFilterCompilerEviction(t *testing.T) {
+	compiler := NewCachingFilterCompiler(
+		NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true)),
+		1)
+	decls := OptionalVariableDeclarations{StrictCost: true}
+
+	first := compiler.Compile([]ExpressionAccessor{&condition{Expression: "1 < 2"}}, decls, environment.NewExpressions)
+	compiler.Compile([]ExpressionAccessor{&condition{Expression: "2 < 3"}}, decls, environment.NewExpressions)
+	recompiled := compiler.Compile([]ExpressionAccessor{&condition{Expression: "1 < 2"}}, decls, environment.NewExpressions)
+	if sharesCompilation(recompiled, first) {
+		t.Errorf("expected the first filter to have been evicted and recompiled")
+	}
+}
+
+func TestCachingFilterCompilerConcurrency(t *testing.T) {
+	compiler := NewCachingFilterCompiler(
+		NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true)),
+		2)
+	decls := OptionalVariableDeclarations{StrictCost: true}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			expression := "1 < 2"
+			if i%2 == 0 {
+				expression = "2 < 3"
+			}
+			filter := compiler.Compile([]ExpressionAccessor{&condition{Expression: expression}}, decls, environment.NewExpressions)
+			if filter == nil {
+				t.Error("unexpected nil filter")
+			}
+		}(i)
+	}
+	wg.Wait()
+}
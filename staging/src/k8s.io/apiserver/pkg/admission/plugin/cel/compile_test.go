@@ -268,6 +268,18 @@ func BenchmarkCompile(b *testing.B) {
 	}
 }
 
+// BenchmarkNewFilterCompiler measures constructing a compiler against
+// an already-seen base environment, the pattern of a cluster compiling
+// many ValidatingAdmissionPolicies at startup.
+func BenchmarkNewFilterCompiler(b *testing.B) {
+	base := environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true)
+	NewFilterCompiler(base) // prime the environment cache
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewFilterCompiler(base)
+	}
+}
+
 type fakeValidationCondition struct {
 	Expression string
 }
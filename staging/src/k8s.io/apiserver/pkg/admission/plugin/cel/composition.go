@@ -165,6 +165,16 @@ func (f *CompositedFilter) ForInput(ctx context.Context, versionedAttr *admissio
 	return f.Filter.ForInput(ctx, versionedAttr, request, optionalVars, namespace, runtimeCELCostBudget)
 }
 
+func (f *CompositedFilter) ForPreparedInput(ctx context.Context, prepared *PreparedInput, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error) {
+	ctx = f.compositionEnv.CreateContext(ctx)
+	return f.Filter.ForPreparedInput(ctx, prepared, optionalVars, namespace, runtimeCELCostBudget)
+}
+
+func (f *CompositedFilter) ForInputMatching(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *v1.AdmissionRequest, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error) {
+	ctx = f.compositionEnv.CreateContext(ctx)
+	return f.Filter.ForInputMatching(ctx, versionedAttr, request, optionalVars, namespace, runtimeCELCostBudget)
+}
+
 func (c *compositionContext) reportCost(cost int64) {
 	c.accumulatedCost += cost
 }
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"fmt"
+	"strings"
+
+	celgo "github.com/google/cel-go/cel"
+)
+
+// ExpressionError carries the diagnostics shared by the typed
+// compilation errors: which expression failed, where in its source,
+// and the compiler's message.  Callers distinguish the error classes
+// with errors.As against *SyntaxError, *TypeError, and
+// *UndeclaredReferenceError.
+type ExpressionError struct {
+	// Index is the position of the failing expression among the
+	// expressions the Filter was compiled from.
+	Index int
+
+	// Line and Column are the 1-based source position of the
+	// diagnostic within the expression, or zero when the diagnostic
+	// carries none.
+	Line, Column int
+
+	// Message is the compiler's diagnostic message.
+	Message string
+}
+
+func (e *ExpressionError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("expression %d: %d:%d: %s", e.Index, e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("expression %d: %s", e.Index, e.Message)
+}
+
+// SyntaxError reports an expression that could not be parsed.
+type SyntaxError struct{ ExpressionError }
+
+// TypeError reports an expression that parsed but failed type
+// checking, e.g. for lack of a matching overload or a wrong output
+// type.
+type TypeError struct{ ExpressionError }
+
+// UndeclaredReferenceError reports a reference to a variable or
+// function that is not declared in the compilation environment.
+type UndeclaredReferenceError struct{ ExpressionError }
+
+// structuredIssueErrors maps each of the given cel-go diagnostics to
+// the typed error matching its class.  cel-go does not expose an
+// error kind, so the classification goes by the message shapes its
+// parser and checker produce; anything unrecognized counts as a type
+// error, which is what the checker reports most.  The expression
+// index is stamped later, by CompilationErrors, since the compiler
+// handles one expression at a time and does not know its position.
+func structuredIssueErrors(issues *celgo.Issues) []error {
+	celErrs := issues.Errors()
+	if len(celErrs) == 0 {
+		return nil
+	}
+	errs := make([]error, 0, len(celErrs))
+	for _, celErr := range celErrs {
+		base := ExpressionError{Message: celErr.Message}
+		if loc := celErr.Location; loc != nil && loc.Line() > 0 {
+			base.Line, base.Column = loc.Line(), loc.Column()
+		}
+		switch {
+		case strings.HasPrefix(celErr.Message, "Syntax error"):
+			errs = append(errs, &SyntaxError{base})
+		case strings.Contains(celErr.Message, "undeclared reference"):
+			errs = append(errs, &UndeclaredReferenceError{base})
+		default:
+			errs = append(errs, &TypeError{base})
+		}
+	}
+	return errs
+}
+
+// withExpressionIndex returns a copy of the given structured error
+// with the expression index set, leaving the stored original intact
+// for results shared between filters.
+func withExpressionIndex(err error, index int) error {
+	switch e := err.(type) {
+	case *SyntaxError:
+		indexed := *e
+		indexed.Index = index
+		return &indexed
+	case *TypeError:
+		indexed := *e
+		indexed.Index = index
+		return &indexed
+	case *UndeclaredReferenceError:
+		indexed := *e
+		indexed.Index = index
+		return &indexed
+	default:
+		return err
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apiserver/pkg/cel/environment"
+)
+
+func TestStructuredCompilationErrors(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	f := compiler.Compile([]ExpressionAccessor{
+		&condition{Expression: "object.subsets.size() == 1"},
+		&condition{Expression: "object.subsets.size( == 1"},
+		&condition{Expression: "nosuchvar == 1"},
+		&condition{Expression: "object.subsets.size() + 'x' == 'y'"},
+	}, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions)
+
+	indexesByClass := map[string][]int{}
+	for _, err := range f.CompilationErrors() {
+		var syntaxErr *SyntaxError
+		var undeclaredErr *UndeclaredReferenceError
+		var typeErr *TypeError
+		switch {
+		case errors.As(err, &syntaxErr):
+			indexesByClass["syntax"] = append(indexesByClass["syntax"], syntaxErr.Index)
+			if syntaxErr.Line <= 0 || syntaxErr.Message == "" {
+				t.Errorf("syntax error lacks position or message: %#v", syntaxErr)
+			}
+		case errors.As(err, &undeclaredErr):
+			indexesByClass["undeclared"] = append(indexesByClass["undeclared"], undeclaredErr.Index)
+		case errors.As(err, &typeErr):
+			indexesByClass["type"] = append(indexesByClass["type"], typeErr.Index)
+		default:
+			t.Errorf("expected a typed compilation error, got %T: %v", err, err)
+		}
+	}
+	for class, wantIndex := range map[string]int{"syntax": 1, "undeclared": 2, "type": 3} {
+		indexes := indexesByClass[class]
+		if len(indexes) == 0 {
+			t.Errorf("no %s error reported, got %v", class, indexesByClass)
+			continue
+		}
+		for _, index := range indexes {
+			if index != wantIndex {
+				t.Errorf("%s error stamped with index %d, expected %d", class, index, wantIndex)
+			}
+		}
+	}
+}
@@ -18,11 +18,16 @@ package cel
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
+	"sync"
 	"time"
 
+	celgo "github.com/google/cel-go/cel"
+	celtypes "github.com/google/cel-go/common/types"
 	"github.com/google/cel-go/interpreter"
 
 	admissionv1 "k8s.io/api/admission/v1"
@@ -31,7 +36,11 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/version"
 	"k8s.io/apiserver/pkg/admission"
+	celconfig "k8s.io/apiserver/pkg/apis/cel"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/cel"
 	"k8s.io/apiserver/pkg/cel/environment"
 	"k8s.io/apiserver/pkg/cel/library"
@@ -40,14 +49,91 @@ import (
 // filterCompiler implement the interface FilterCompiler.
 type filterCompiler struct {
 	compiler Compiler
+	// varEnvs is retained for static cost estimation, which needs the
+	// declared-variable environments but not instantiated programs.
+	varEnvs variableDeclEnvs
 }
 
+// NewFilterCompiler returns a FilterCompiler for the given base
+// environment.  The variable-declaration environments it compiles
+// against are cached per base EnvSet, so repeated construction against
+// the same base environment is cheap; see cachedVariableDeclEnvs.
 func NewFilterCompiler(env *environment.EnvSet) FilterCompiler {
-	return &filterCompiler{compiler: NewCompiler(env)}
+	return &filterCompiler{compiler: NewCompiler(env), varEnvs: cachedVariableDeclEnvs(env)}
+}
+
+// EstimateCost statically estimates the worst-case cost of each expression,
+// in CEL cost units, using the same variable declarations as Compile.
+// Estimation uses the StoredExpressions environment so that expressions valid
+// under any supported environment version can be estimated.
+func (c *filterCompiler) EstimateCost(expressions []ExpressionAccessor, options OptionalVariableDeclarations) ([]uint64, error) {
+	env, err := c.varEnvs[options].Env(environment.StoredExpressions)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error loading CEL environment: %w", err)
+	}
+	estimator := &library.CostEstimator{}
+	costs := make([]uint64, len(expressions))
+	for i, expression := range expressions {
+		if expression == nil {
+			continue
+		}
+		ast, issues := env.Compile(expression.GetExpression())
+		if issues != nil {
+			return nil, fmt.Errorf("compilation failed for expression %d: %s", i, issues.String())
+		}
+		costEstimate, err := env.EstimateCost(ast, estimator)
+		if err != nil {
+			return nil, fmt.Errorf("cost estimation failed for expression %d: %w", i, err)
+		}
+		costs[i] = costEstimate.Max
+	}
+	return costs, nil
+}
+
+// Validate compiles the expression far enough to report syntax errors and
+// return-type mismatches, one per element of the returned slice, without
+// instantiating a program.  New expressions are validated against the
+// NewExpressions environment since linting targets expressions that have not
+// yet been persisted.
+func (c *filterCompiler) Validate(expression ExpressionAccessor, options OptionalVariableDeclarations) []error {
+	env, err := c.varEnvs[options].Env(environment.NewExpressions)
+	if err != nil {
+		return []error{fmt.Errorf("unexpected error loading CEL environment: %w", err)}
+	}
+	ast, issues := env.Compile(expression.GetExpression())
+	if issues != nil {
+		var errs []error
+		for _, issue := range issues.Errors() {
+			errs = append(errs, errors.New(issue.Message))
+		}
+		return errs
+	}
+	returnTypes := expression.ReturnTypes()
+	for _, returnType := range returnTypes {
+		if ast.OutputType() == returnType || celgo.AnyType == returnType {
+			return nil
+		}
+	}
+	if len(returnTypes) == 1 {
+		return []error{fmt.Errorf("must evaluate to %v", returnTypes[0].String())}
+	}
+	return []error{fmt.Errorf("must evaluate to one of %v", returnTypes)}
 }
 
 type evaluationActivation struct {
 	object, oldObject, params, request, namespace, authorizer, requestResourceAuthorizer, variables interface{}
+	// changedFields memoizes the value of the "changed" variable; it is
+	// computed on first resolution so requests that never reference the
+	// variable do not pay for the comparison.
+	changedFields interface{}
+	// lazyAuthorizer, if non-nil, is invoked on the first reference to
+	// an authorizer variable to supply the authorizer; expressions
+	// that never reference one never pay for its construction.  It is
+	// combined with lazyUserInfo and lazyVersionedAttr into the
+	// authorizer and requestResourceAuthorizer values above.
+	lazyAuthorizer    func() authorizer.Authorizer
+	lazyUserInfo      user.Info
+	lazyVersionedAttr *admission.VersionedAttributes
 }
 
 // ResolveName returns a value from the activation by qualified name, or false if the name
@@ -65,16 +151,60 @@ func (a *evaluationActivation) ResolveName(name string) (interface{}, bool) {
 	case NamespaceVarName:
 		return a.namespace, true
 	case AuthorizerVarName:
+		a.resolveAuthorizerValues()
 		return a.authorizer, a.authorizer != nil
 	case RequestResourceAuthorizerVarName:
+		a.resolveAuthorizerValues()
 		return a.requestResourceAuthorizer, a.requestResourceAuthorizer != nil
 	case VariableVarName: // variables always present
 		return a.variables, true
+	case ChangedVarName:
+		if a.changedFields == nil {
+			a.changedFields = changedTopLevelFields(a.oldObject, a.object)
+		}
+		return a.changedFields, true
 	default:
 		return nil, false
 	}
 }
 
+// changedTopLevelFields returns the sorted names of the top-level fields that
+// differ between the old and new object, including fields present in only one
+// of the two.  Both arguments are expected to be unstructured object contents
+// (map[string]interface{}) or nil.
+func changedTopLevelFields(oldObject, object interface{}) []string {
+	oldMap, _ := oldObject.(map[string]interface{})
+	newMap, _ := object.(map[string]interface{})
+	changed := []string{}
+	for field, oldVal := range oldMap {
+		if newVal, ok := newMap[field]; !ok || !reflect.DeepEqual(oldVal, newVal) {
+			changed = append(changed, field)
+		}
+	}
+	for field := range newMap {
+		if _, ok := oldMap[field]; !ok {
+			changed = append(changed, field)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// resolveAuthorizerValues constructs the authorizer variable values
+// from the lazy supplier on first reference.  The supplier is invoked
+// at most once, even if it returns nil.
+func (a *evaluationActivation) resolveAuthorizerValues() {
+	if a.lazyAuthorizer == nil {
+		return
+	}
+	supplier := a.lazyAuthorizer
+	a.lazyAuthorizer = nil
+	if authz := supplier(); authz != nil {
+		a.authorizer = library.NewAuthorizerVal(a.lazyUserInfo, authz)
+		a.requestResourceAuthorizer = library.NewResourceAuthorizerVal(a.lazyUserInfo, authz, a.lazyVersionedAttr)
+	}
+}
+
 // Parent returns the parent of the current activation, may be nil.
 // If non-nil, the parent will be searched during resolve calls.
 func (a *evaluationActivation) Parent() interpreter.Activation {
@@ -83,6 +213,7 @@ func (a *evaluationActivation) Parent() interpreter.Activation {
 
 // Compile compiles the cel expressions defined in the ExpressionAccessors into a Filter
 func (c *filterCompiler) Compile(expressionAccessors []ExpressionAccessor, options OptionalVariableDeclarations, mode environment.Type) Filter {
+	defer observeCompilation(time.Now(), len(expressionAccessors), options)
 	compilationResults := make([]CompilationResult, len(expressionAccessors))
 	for i, expressionAccessor := range expressionAccessors {
 		if expressionAccessor == nil {
@@ -90,17 +221,213 @@ func (c *filterCompiler) Compile(expressionAccessors []ExpressionAccessor, optio
 		}
 		compilationResults[i] = c.compiler.CompileCELExpression(expressionAccessor, options, mode)
 	}
-	return NewFilter(compilationResults)
+	f := NewFilter(compilationResults).(*filter)
+	f.declaredVariables = declaredVariables(options)
+	return f
+}
+
+// CompileOptions selects what is available to a compilation beyond
+// the defaults that Compile uses.
+type CompileOptions struct {
+	// Libraries names the optional CEL function libraries to enable;
+	// see environment.OptionalLibraryNames for the accepted names.
+	// Expressions can only call functions of the libraries listed
+	// here, so policies opt into heavier function sets only when they
+	// need them.
+	Libraries []string
+}
+
+// CompileWithOptions compiles the given expressions the way
+// FilterCompiler.Compile does, but against a base environment
+// containing only the optional function libraries named in opts, and
+// with perCallLimit as the per-call cost limit.  An unknown library
+// name is reported as a compilation error on every expression of the
+// returned filter.  Expressions are compiled as NewExpressions since
+// the library selection applies to expressions being authored, not to
+// ones already persisted.
+func CompileWithOptions(expressionAccessors []ExpressionAccessor, options OptionalVariableDeclarations, perCallLimit uint64, opts CompileOptions) Filter {
+	baseEnv, err := environment.BaseEnvSetWithLibraries(environment.DefaultCompatibilityVersion(), options.StrictCost, opts.Libraries)
+	if err == nil && perCallLimit != celconfig.PerCallLimit {
+		baseEnv, err = baseEnv.Extend(environment.VersionedOptions{
+			IntroducedVersion: version.MajorMinor(1, 0),
+			ProgramOptions:    []celgo.ProgramOption{celgo.CostLimit(perCallLimit)},
+		})
+	}
+	if err != nil {
+		compilationResults := make([]CompilationResult, len(expressionAccessors))
+		for i, expressionAccessor := range expressionAccessors {
+			if expressionAccessor == nil {
+				continue
+			}
+			compilationResults[i] = CompilationResult{
+				ExpressionAccessor: expressionAccessor,
+				Error:              &cel.Error{Type: cel.ErrorTypeInvalid, Detail: err.Error()},
+			}
+		}
+		return NewFilterWithPerCallLimit(compilationResults, perCallLimit)
+	}
+	// Built directly rather than through cachedVariableDeclEnvs: the
+	// base environment here is freshly constructed per call, so caching
+	// it would only grow the cache.
+	varEnvs := mustBuildEnvs(baseEnv)
+	c := &filterCompiler{compiler: &compiler{varEnvs: varEnvs}, varEnvs: varEnvs}
+	f := c.Compile(expressionAccessors, options, environment.NewExpressions).(*filter)
+	f.perCallLimit = perCallLimit
+	return f
+}
+
+// declaredVariables lists the variables declared for expressions
+// compiled with the given options, mirroring the environments that
+// mustBuildEnvs constructs.
+func declaredVariables(options OptionalVariableDeclarations) []VariableInfo {
+	vars := []VariableInfo{
+		{Name: ObjectVarName},
+		{Name: OldObjectVarName},
+		{Name: NamespaceVarName},
+		{Name: RequestVarName},
+	}
+	if options.HasParams {
+		vars = append(vars, VariableInfo{Name: ParamsVarName, Optional: true})
+	}
+	if options.HasAuthorizer {
+		vars = append(vars,
+			VariableInfo{Name: AuthorizerVarName, Optional: true},
+			VariableInfo{Name: RequestResourceAuthorizerVarName, Optional: true})
+	}
+	if options.HasChangedFields {
+		vars = append(vars, VariableInfo{Name: ChangedVarName, Optional: true})
+	}
+	return vars
 }
 
+// BudgetExceededBehavior selects how a filter reacts when the runtime
+// CEL cost budget is exhausted during evaluation.
+type BudgetExceededBehavior int
+
+const (
+	// BudgetFailClosed makes budget exhaustion a top-level evaluation
+	// error, for the caller to deny on.  This is the default, for
+	// safety.
+	BudgetFailClosed BudgetExceededBehavior = iota
+
+	// BudgetFailOpen records budget exhaustion on the result of the
+	// expression that exhausted it and skips the remaining
+	// expressions, without a top-level error; the caller may treat
+	// the unevaluated expressions as allowed.
+	BudgetFailOpen
+)
+
+// BudgetExceededError reports exhaustion of the runtime CEL cost
+// budget, annotated with the behavior the filter was configured with
+// so that callers can deny or skip without inspecting the message.
+type BudgetExceededError struct {
+	// Behavior is the configured reaction to exhaustion.
+	Behavior BudgetExceededBehavior
+
+	err *cel.Error
+}
+
+func (e *BudgetExceededError) Error() string { return e.err.Error() }
+
+// Unwrap exposes the underlying *cel.Error.
+func (e *BudgetExceededError) Unwrap() error { return e.err }
+
+// ErrFilterClosed is returned by ForInput and ForInputs when the
+// filter was closed before or during evaluation.  Callers treat
+// evaluations aborted this way as skipped rather than failed.
+var ErrFilterClosed = errors.New("cel filter closed, evaluation aborted")
+
 // filter implements the Filter interface
 type filter struct {
 	compilationResults []CompilationResult
+
+	// done is closed by Close to signal in-flight evaluations to
+	// abort; a nil done (as on a zero-value filter) means Close is a
+	// no-op and evaluations never abort.
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// perExpressionTimeout bounds the wall-clock time of each individual
+	// expression evaluation, independently of the cost budget.  Zero
+	// means no bound.  The cost budget alone does not bound wall time on
+	// a slow machine; this does.
+	perExpressionTimeout time.Duration
+
+	// budgetBehavior selects the reaction to cost-budget exhaustion;
+	// the zero value fails closed.
+	budgetBehavior BudgetExceededBehavior
+
+	// declaredVariables records the variables declared for the
+	// expressions of this filter; nil when the filter was built
+	// directly from compilation results rather than compiled.
+	declaredVariables []VariableInfo
+
+	// perCallLimit records the per-call CEL cost limit the filter's
+	// expressions were compiled under, for reporting only; the limit
+	// itself is enforced by the program options baked into the
+	// compilation environment.
+	perCallLimit uint64
 }
 
 func NewFilter(compilationResults []CompilationResult) Filter {
 	return &filter{
-		compilationResults,
+		compilationResults: compilationResults,
+		perCallLimit:       celconfig.PerCallLimit,
+		done:               make(chan struct{}),
+	}
+}
+
+// NewFilterWithPerCallLimit is like NewFilter but records the given
+// per-call cost limit as the one the compilation environment enforced,
+// for PerCallLimit to report.  Use it when the compilation results
+// were produced under an environment with a non-default cel.CostLimit,
+// as tests do.
+func NewFilterWithPerCallLimit(compilationResults []CompilationResult, perCallLimit uint64) Filter {
+	return &filter{
+		compilationResults: compilationResults,
+		perCallLimit:       perCallLimit,
+		done:               make(chan struct{}),
+	}
+}
+
+// NewFilterWithPerExpressionTimeout is like NewFilter but cancels any single
+// expression evaluation that runs longer than the given timeout.  A timed-out
+// expression records the timeout as its EvaluationResult.Error, with Elapsed
+// reflecting the truncated time, and evaluation continues with the remaining
+// expressions.
+func NewFilterWithPerExpressionTimeout(compilationResults []CompilationResult, perExpressionTimeout time.Duration) Filter {
+	return &filter{
+		compilationResults:   compilationResults,
+		perExpressionTimeout: perExpressionTimeout,
+		perCallLimit:         celconfig.PerCallLimit,
+		done:                 make(chan struct{}),
+	}
+}
+
+// NewFilterWithBudgetBehavior is like NewFilter but reacts to runtime
+// cost-budget exhaustion according to the given behavior instead of
+// the default of failing closed.
+func NewFilterWithBudgetBehavior(compilationResults []CompilationResult, budgetBehavior BudgetExceededBehavior) Filter {
+	return &filter{
+		compilationResults: compilationResults,
+		budgetBehavior:     budgetBehavior,
+		perCallLimit:       celconfig.PerCallLimit,
+		done:               make(chan struct{}),
+	}
+}
+
+// shareCompiled returns a new filter sharing e's immutable compilation
+// results and settings but with its own Close state, so that a cache
+// can hand every caller an independently closable instance over one
+// compilation; see cachingFilterCompiler.
+func (e *filter) shareCompiled() *filter {
+	return &filter{
+		compilationResults:   e.compilationResults,
+		perExpressionTimeout: e.perExpressionTimeout,
+		budgetBehavior:       e.budgetBehavior,
+		declaredVariables:    e.declaredVariables,
+		perCallLimit:         e.perCallLimit,
+		done:                 make(chan struct{}),
 	}
 }
 
@@ -126,51 +453,192 @@ func objectToResolveVal(r runtime.Object) (interface{}, error) {
 	return v.Object, nil
 }
 
+// PreparedInput is the CEL-activation-ready representation of one
+// request's objects, converted once by PrepareInput.  Reusing one
+// PreparedInput across several filters — the policies matching one
+// admission request — pays for the object-to-unstructured conversion
+// only once instead of once per policy.  Reuse is safe, including
+// concurrently, as long as the underlying objects are not mutated
+// after preparation: evaluation only reads the converted maps.
+type PreparedInput struct {
+	// versionedAttr is retained for the bindings that are not
+	// conversions: user info and the authorizer values.
+	versionedAttr *admission.VersionedAttributes
+
+	// objectVal and oldObjectVal are the unstructured contents of the
+	// versioned new and old objects; nil stands for CEL null.
+	objectVal    any
+	oldObjectVal any
+
+	// requestVal is the unstructured content of the AdmissionRequest.
+	requestVal map[string]interface{}
+}
+
+// PrepareInput converts the given versioned attributes and admission
+// request into the representation that ForPreparedInput consumes.
+func PrepareInput(versionedAttr *admission.VersionedAttributes, request *admissionv1.AdmissionRequest) (*PreparedInput, error) {
+	oldObjectVal, err := objectToResolveVal(versionedAttr.VersionedOldObject)
+	if err != nil {
+		return nil, err
+	}
+	objectVal, err := objectToResolveVal(versionedAttr.VersionedObject)
+	if err != nil {
+		return nil, err
+	}
+	requestVal, err := convertObjectToUnstructured(request)
+	if err != nil {
+		return nil, err
+	}
+	return &PreparedInput{
+		versionedAttr: versionedAttr,
+		objectVal:     objectVal,
+		oldObjectVal:  oldObjectVal,
+		requestVal:    requestVal.Object,
+	}, nil
+}
+
 // ForInput evaluates the compiled CEL expressions converting them into CELEvaluations
 // errors per evaluation are returned on the Evaluation object
 // runtimeCELCostBudget was added for testing purpose only. Callers should always use const RuntimeCELCostBudget from k8s.io/apiserver/pkg/apis/cel/config.go as input.
+// The "namespaceObject" variable is bound to null when namespace is nil, as is
+// the case for cluster-scoped requests; expressions referencing it must guard
+// against that, e.g. with `namespaceObject != null && ...`.
+// Likewise "object" is bound to null when the request carries no new object,
+// as on DELETE (request.operation == 'DELETE'), where only "oldObject" holds
+// data; expressions meant to run on deletes should reference "oldObject" or
+// guard with `object != null`.
+// The returned results correspond index-for-index to the compiled
+// expressions; see Filter.ForInput.
 func (f *filter) ForInput(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *admissionv1.AdmissionRequest, inputs OptionalVariableBindings, namespace *v1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error) {
-	// TODO: replace unstructured with ref.Val for CEL variables when native type support is available
-	evaluations := make([]EvaluationResult, len(f.compilationResults))
+	var paramsVal any
 	var err error
-
-	oldObjectVal, err := objectToResolveVal(versionedAttr.VersionedOldObject)
+	if inputs.VersionedParams != nil {
+		paramsVal, err = objectToResolveVal(inputs.VersionedParams)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
+	namespaceVal, err := objectToResolveVal(namespace)
 	if err != nil {
 		return nil, -1, err
 	}
-	objectVal, err := objectToResolveVal(versionedAttr.VersionedObject)
+	prepared, err := PrepareInput(versionedAttr, request)
 	if err != nil {
 		return nil, -1, err
 	}
-	var paramsVal, authorizerVal, requestResourceAuthorizerVal any
+	return f.forInput(ctx, prepared, inputs, paramsVal, namespaceVal, runtimeCELCostBudget, false)
+}
+
+// ForPreparedInput is like ForInput but takes the activation-ready
+// representation produced by PrepareInput instead of converting the
+// versioned objects itself; see Filter.ForPreparedInput.
+func (f *filter) ForPreparedInput(ctx context.Context, prepared *PreparedInput, inputs OptionalVariableBindings, namespace *v1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error) {
+	var paramsVal any
+	var err error
 	if inputs.VersionedParams != nil {
 		paramsVal, err = objectToResolveVal(inputs.VersionedParams)
 		if err != nil {
 			return nil, -1, err
 		}
 	}
-
-	if inputs.Authorizer != nil {
-		authorizerVal = library.NewAuthorizerVal(versionedAttr.GetUserInfo(), inputs.Authorizer)
-		requestResourceAuthorizerVal = library.NewResourceAuthorizerVal(versionedAttr.GetUserInfo(), inputs.Authorizer, versionedAttr)
+	namespaceVal, err := objectToResolveVal(namespace)
+	if err != nil {
+		return nil, -1, err
 	}
+	return f.forInput(ctx, prepared, inputs, paramsVal, namespaceVal, runtimeCELCostBudget, false)
+}
 
-	requestVal, err := convertObjectToUnstructured(request)
+// ForInputMatching is like ForInput but stops evaluating at the first
+// expression that evaluates to false; see Filter.ForInputMatching.
+func (f *filter) ForInputMatching(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *admissionv1.AdmissionRequest, inputs OptionalVariableBindings, namespace *v1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error) {
+	var paramsVal any
+	var err error
+	if inputs.VersionedParams != nil {
+		paramsVal, err = objectToResolveVal(inputs.VersionedParams)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
+	namespaceVal, err := objectToResolveVal(namespace)
+	if err != nil {
+		return nil, -1, err
+	}
+	prepared, err := PrepareInput(versionedAttr, request)
 	if err != nil {
 		return nil, -1, err
 	}
+	return f.forInput(ctx, prepared, inputs, paramsVal, namespaceVal, runtimeCELCostBudget, true)
+}
+
+// ForInputs evaluates the compiled CEL expressions for each of the given
+// inputs in turn, producing one result slice per input.  The params and
+// namespace bindings are converted once and shared across all inputs, which
+// amortizes the per-evaluation setup when the same policy is applied across
+// many objects.  The cost budget is shared by all inputs; evaluation stops
+// with an error as soon as it is exhausted.
+func (f *filter) ForInputs(ctx context.Context, versionedAttrs []*admission.VersionedAttributes, requests []*admissionv1.AdmissionRequest, inputs OptionalVariableBindings, namespace *v1.Namespace, runtimeCELCostBudget int64) ([][]EvaluationResult, int64, error) {
+	if len(versionedAttrs) != len(requests) {
+		return nil, -1, fmt.Errorf("number of attributes (%d) does not match number of requests (%d)", len(versionedAttrs), len(requests))
+	}
+	var paramsVal any
+	var err error
+	if inputs.VersionedParams != nil {
+		paramsVal, err = objectToResolveVal(inputs.VersionedParams)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
 	namespaceVal, err := objectToResolveVal(namespace)
 	if err != nil {
 		return nil, -1, err
 	}
+	results := make([][]EvaluationResult, len(versionedAttrs))
+	remainingBudget := runtimeCELCostBudget
+	for i := range versionedAttrs {
+		prepared, err := PrepareInput(versionedAttrs[i], requests[i])
+		if err != nil {
+			return nil, -1, err
+		}
+		results[i], remainingBudget, err = f.forInput(ctx, prepared, inputs, paramsVal, namespaceVal, remainingBudget, false)
+		if err != nil {
+			return nil, -1, err
+		}
+	}
+	return results, remainingBudget, nil
+}
+
+// forInput evaluates the compiled expressions for one prepared input,
+// given already-converted params and namespace bindings.  With
+// stopOnFirstFalse, evaluation ends at the first expression that
+// evaluates to false; see Filter.ForInputMatching.
+func (f *filter) forInput(ctx context.Context, prepared *PreparedInput, inputs OptionalVariableBindings, paramsVal, namespaceVal any, runtimeCELCostBudget int64, stopOnFirstFalse bool) ([]EvaluationResult, int64, error) {
+	if err := f.closedErr(); err != nil {
+		return nil, -1, err
+	}
+	// TODO: replace unstructured with ref.Val for CEL variables when native type support is available
+	evaluations := make([]EvaluationResult, len(f.compilationResults))
+	for i := range evaluations {
+		evaluations[i].Index = i
+	}
+
+	versionedAttr := prepared.versionedAttr
+	var authorizerVal, requestResourceAuthorizerVal any
+	if inputs.AuthorizerFunc == nil && inputs.Authorizer != nil {
+		authorizerVal = library.NewAuthorizerVal(versionedAttr.GetUserInfo(), inputs.Authorizer)
+		requestResourceAuthorizerVal = library.NewResourceAuthorizerVal(versionedAttr.GetUserInfo(), inputs.Authorizer, versionedAttr)
+	}
+
 	va := &evaluationActivation{
-		object:                    objectVal,
-		oldObject:                 oldObjectVal,
+		object:                    prepared.objectVal,
+		oldObject:                 prepared.oldObjectVal,
 		params:                    paramsVal,
-		request:                   requestVal.Object,
+		request:                   prepared.requestVal,
 		namespace:                 namespaceVal,
 		authorizer:                authorizerVal,
 		requestResourceAuthorizer: requestResourceAuthorizerVal,
+		lazyAuthorizer:            inputs.AuthorizerFunc,
+		lazyUserInfo:              versionedAttr.GetUserInfo(),
+		lazyVersionedAttr:         versionedAttr,
 	}
 
 	// composition is an optional feature that only applies for ValidatingAdmissionPolicy.
@@ -181,6 +649,20 @@ func (f *filter) ForInput(ctx context.Context, versionedAttr *admission.Versione
 		va.variables = compositionCtx.Variables(va)
 	}
 
+	// Cancel the evaluation context when the filter is closed so that
+	// in-flight program evaluations are interrupted; see Close.  This
+	// must happen after the CompositionContext assertion above, which
+	// would not see through the wrapping.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-f.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
 	remainingBudget := runtimeCELCostBudget
 	for i, compilationResult := range f.compilationResults {
 		var evaluation = &evaluations[i]
@@ -203,20 +685,44 @@ func (f *filter) ForInput(ctx context.Context, versionedAttr *admission.Versione
 			continue
 		}
 		t1 := time.Now()
-		evalResult, evalDetails, err := compilationResult.Program.ContextEval(ctx, va)
+		evalCtx := ctx
+		var evalCancel context.CancelFunc
+		if f.perExpressionTimeout > 0 {
+			evalCtx, evalCancel = context.WithTimeout(ctx, f.perExpressionTimeout)
+		}
+		evalResult, evalDetails, err := compilationResult.Program.ContextEval(evalCtx, va)
+		timedOut := evalCancel != nil && evalCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil
+		if evalCancel != nil {
+			evalCancel()
+		}
+		// An evaluation interrupted by Close surfaces as a context
+		// error from ContextEval; report the closure instead.
+		if closedErr := f.closedErr(); closedErr != nil {
+			return nil, -1, closedErr
+		}
 		// budget may be spent due to lazy evaluation of composited variables
 		if compositionCtx != nil {
 			compositionCost := compositionCtx.GetAndResetCost()
+			evaluation.Cost += uint64(compositionCost)
 			if compositionCost > remainingBudget {
-				return nil, -1, &cel.Error{
-					Type:   cel.ErrorTypeInvalid,
-					Detail: fmt.Sprintf("validation failed due to running out of cost budget, no further validation rules will be run"),
-				}
+				return f.budgetExceeded(evaluation, evaluations)
 			}
 			remainingBudget -= compositionCost
 		}
 		elapsed := time.Since(t1)
 		evaluation.Elapsed = elapsed
+		if timedOut {
+			// The expression was cancelled by the per-expression timeout,
+			// not by the caller; record the timeout on this result and
+			// continue with the remaining expressions.  No runtime cost is
+			// charged since evalDetails is unavailable for an interrupted
+			// evaluation.
+			evaluation.Error = &cel.Error{
+				Type:   cel.ErrorTypeInvalid,
+				Detail: fmt.Sprintf("expression '%v' evaluation timed out after %v", compilationResult.ExpressionAccessor.GetExpression(), f.perExpressionTimeout),
+			}
+			continue
+		}
 		if evalDetails == nil {
 			return nil, -1, &cel.Error{
 				Type:   cel.ErrorTypeInternal,
@@ -230,11 +736,9 @@ func (f *filter) ForInput(ctx context.Context, versionedAttr *admission.Versione
 					Detail: fmt.Sprintf("runtime cost could not be calculated for expression: %v, no further expression will be run", compilationResult.ExpressionAccessor.GetExpression()),
 				}
 			} else {
+				evaluation.Cost += *rtCost
 				if *rtCost > math.MaxInt64 || int64(*rtCost) > remainingBudget {
-					return nil, -1, &cel.Error{
-						Type:   cel.ErrorTypeInvalid,
-						Detail: fmt.Sprintf("validation failed due to running out of cost budget, no further validation rules will be run"),
-					}
+					return f.budgetExceeded(evaluation, evaluations)
 				}
 				remainingBudget -= int64(*rtCost)
 			}
@@ -246,12 +750,38 @@ func (f *filter) ForInput(ctx context.Context, versionedAttr *admission.Versione
 			}
 		} else {
 			evaluation.EvalResult = evalResult
+			if stopOnFirstFalse && evalResult == celtypes.False {
+				// One false decides an ANDed condition list; the
+				// remaining expressions are left unevaluated.
+				break
+			}
 		}
 	}
 
 	return evaluations, remainingBudget, nil
 }
 
+// budgetExceeded reports exhaustion of the runtime cost budget
+// according to the filter's configured behavior.  Failing closed
+// returns a top-level *BudgetExceededError for the caller to deny on;
+// failing open records the exhaustion on the result of the expression
+// that exhausted the budget and reports success with zero budget
+// remaining, leaving any later expressions unevaluated.
+func (f *filter) budgetExceeded(evaluation *EvaluationResult, evaluations []EvaluationResult) ([]EvaluationResult, int64, error) {
+	budgetErr := &BudgetExceededError{
+		Behavior: f.budgetBehavior,
+		err: &cel.Error{
+			Type:   cel.ErrorTypeInvalid,
+			Detail: "validation failed due to running out of cost budget, no further validation rules will be run",
+		},
+	}
+	if f.budgetBehavior == BudgetFailOpen {
+		evaluation.Error = budgetErr
+		return evaluations, 0, nil
+	}
+	return nil, -1, budgetErr
+}
+
 // TODO: to reuse https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apiserver/pkg/admission/plugin/webhook/request/admissionreview.go#L154
 func CreateAdmissionRequest(attr admission.Attributes, equivalentGVR metav1.GroupVersionResource, equivalentKind metav1.GroupVersionKind) *admissionv1.AdmissionRequest {
 	// Attempting to use same logic as webhook for constructing resource
@@ -345,13 +875,64 @@ func CreateNamespaceObject(namespace *v1.Namespace) *v1.Namespace {
 	}
 }
 
-// CompilationErrors returns a list of all the errors from the compilation of the evaluator
+// CompilationErrors returns a list of all the errors from the compilation of
+// the evaluator.  Where the compiler produced typed diagnostics —
+// SyntaxError, TypeError, UndeclaredReferenceError — those are returned, one
+// per diagnostic and stamped with the expression's index; otherwise the
+// result's opaque error is returned as before.
 func (e *filter) CompilationErrors() []error {
 	compilationErrors := []error{}
-	for _, result := range e.compilationResults {
+	for i, result := range e.compilationResults {
+		if len(result.StructuredErrors) > 0 {
+			for _, structuredErr := range result.StructuredErrors {
+				compilationErrors = append(compilationErrors, withExpressionIndex(structuredErr, i))
+			}
+			continue
+		}
 		if result.Error != nil {
 			compilationErrors = append(compilationErrors, result.Error)
 		}
 	}
 	return compilationErrors
 }
+
+// CompilationWarnings returns a list of all the non-fatal diagnostics from the compilation of the evaluator
+func (e *filter) CompilationWarnings() []error {
+	compilationWarnings := []error{}
+	for _, result := range e.compilationResults {
+		compilationWarnings = append(compilationWarnings, result.Warnings...)
+	}
+	return compilationWarnings
+}
+
+// DeclaredVariables returns the variables declared for the
+// expressions of this filter, in declaration order.
+func (e *filter) DeclaredVariables() []VariableInfo {
+	return append([]VariableInfo(nil), e.declaredVariables...)
+}
+
+// PerCallLimit returns the per-call CEL cost limit the filter's
+// expressions were compiled under.
+func (e *filter) PerCallLimit() uint64 {
+	return e.perCallLimit
+}
+
+// Close aborts in-flight and future evaluations; see Filter.Close.
+func (e *filter) Close() {
+	e.closeOnce.Do(func() {
+		if e.done != nil {
+			close(e.done)
+		}
+	})
+}
+
+// closedErr returns an error wrapping ErrFilterClosed if the filter
+// has been closed, and nil otherwise.
+func (e *filter) closedErr() error {
+	select {
+	case <-e.done:
+		return fmt.Errorf("evaluation skipped: %w", ErrFilterClosed)
+	default:
+		return nil
+	}
+}
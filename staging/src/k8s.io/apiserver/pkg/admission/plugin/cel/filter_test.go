@@ -22,14 +22,18 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	celgo "github.com/google/cel-go/cel"
 	celtypes "github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
 	"github.com/stretchr/testify/require"
 
 	"k8s.io/utils/pointer"
 
+	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -124,6 +128,118 @@ func TestCompile(t *testing.T) {
 	}
 }
 
+func TestCompileWithOptions(t *testing.T) {
+	cases := []struct {
+		name             string
+		validation       []ExpressionAccessor
+		opts             CompileOptions
+		errorExpressions map[string]string
+	}{
+		{
+			name: "core functions need no libraries",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "object.spec.string.matches('[0-9]+')",
+				},
+			},
+		},
+		{
+			name: "url library enabled",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "isURL('http://example.com')",
+				},
+			},
+			opts: CompileOptions{Libraries: []string{"urls"}},
+		},
+		{
+			name: "url library not enabled",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "isURL('http://example.com')",
+				},
+			},
+			errorExpressions: map[string]string{
+				"isURL('http://example.com')": "undeclared reference to 'isURL'",
+			},
+		},
+		{
+			name: "regex library enabled",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "'abc 123'.find('[0-9]+') == '123'",
+				},
+			},
+			opts: CompileOptions{Libraries: []string{"regex"}},
+		},
+		{
+			name: "regex library not enabled",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "'abc 123'.find('[0-9]+') == '123'",
+				},
+			},
+			errorExpressions: map[string]string{
+				"'abc 123'.find('[0-9]+') == '123'": "undeclared reference to 'find'",
+			},
+		},
+		{
+			name: "unknown library",
+			validation: []ExpressionAccessor{
+				&condition{
+					Expression: "1 < 2",
+				},
+				&condition{
+					Expression: "2 < 3",
+				},
+			},
+			opts: CompileOptions{Libraries: []string{"frobnicate"}},
+			errorExpressions: map[string]string{
+				"1 < 2": `unknown optional CEL library "frobnicate"`,
+				"2 < 3": `unknown optional CEL library "frobnicate"`,
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := CompileWithOptions(tc.validation, OptionalVariableDeclarations{StrictCost: true}, celconfig.PerCallLimit, tc.opts)
+			if e == nil {
+				t.Fatalf("unexpected nil validator")
+			}
+			validations := tc.validation
+			compilationResults := e.(*filter).compilationResults
+			require.Equal(t, len(validations), len(compilationResults))
+
+			meets := make([]bool, len(validations))
+			for expr, expectErr := range tc.errorExpressions {
+				for i, result := range compilationResults {
+					if validations[i].GetExpression() == expr {
+						if result.Error == nil {
+							t.Errorf("Expect expression '%s' to contain error '%v' but got no error", expr, expectErr)
+						} else if !strings.Contains(result.Error.Error(), expectErr) {
+							t.Errorf("Expected validations '%s' error to contain '%v' but got: %v", expr, expectErr, result.Error)
+						}
+						meets[i] = true
+					}
+				}
+			}
+			for i, meet := range meets {
+				if !meet && compilationResults[i].Error != nil {
+					t.Errorf("Unexpected err '%v' for expression '%s'", compilationResults[i].Error, validations[i].GetExpression())
+				}
+			}
+		})
+	}
+
+	t.Run("per-call limit is recorded", func(t *testing.T) {
+		e := CompileWithOptions(nil, OptionalVariableDeclarations{StrictCost: true}, 10, CompileOptions{})
+		if limit := e.PerCallLimit(); limit != 10 {
+			t.Errorf("expected per-call limit 10, got %d", limit)
+		}
+	})
+}
+
 func TestFilter(t *testing.T) {
 	configMapParams := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -1436,3 +1552,629 @@ func endpointStatusUpdateAttributes() admission.Attributes {
 		attrs.GetObject(), attrs.GetObject(), attrs.GetKind(), attrs.GetNamespace(), attrs.GetName(),
 		attrs.GetResource(), "status", admission.Update, &metav1.UpdateOptions{}, false, nil)
 }
+
+func TestEstimateCost(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	decls := OptionalVariableDeclarations{HasParams: false, HasAuthorizer: false, StrictCost: true}
+
+	costs, err := compiler.EstimateCost([]ExpressionAccessor{
+		&condition{Expression: "true"},
+		&condition{Expression: "object.subsets.all(s, s.addresses.size() < 100)"},
+	}, decls)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(costs) != 2 {
+		t.Fatalf("expected one cost per expression, got %d", len(costs))
+	}
+	if costs[1] <= costs[0] {
+		t.Errorf("expected iterating expression to cost more than a constant, got %d <= %d", costs[1], costs[0])
+	}
+
+	if _, err := compiler.EstimateCost([]ExpressionAccessor{&condition{Expression: "1 < 'asdf'"}}, decls); err == nil {
+		t.Errorf("expected error for uncompilable expression")
+	}
+}
+
+func TestForInputs(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	f := compiler.Compile([]ExpressionAccessor{
+		&condition{Expression: "object.subsets.size() == 1"},
+	}, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions)
+
+	attrs := []admission.Attributes{endpointCreateAttributes(), endpointCreateAttributes()}
+	versionedAttrs := make([]*admission.VersionedAttributes, len(attrs))
+	requests := make([]*admissionv1.AdmissionRequest, len(attrs))
+	for i, attr := range attrs {
+		va, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+		if err != nil {
+			t.Fatal(err)
+		}
+		versionedAttrs[i] = va
+		requests[i] = CreateAdmissionRequest(va.Attributes, metav1.GroupVersionResource(va.GetResource()), metav1.GroupVersionKind(va.VersionedKind))
+	}
+
+	results, remaining, err := f.(*filter).ForInputs(context.TODO(), versionedAttrs, requests, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(attrs) {
+		t.Fatalf("expected one result slice per input, got %d", len(results))
+	}
+	if remaining >= celconfig.RuntimeCELCostBudget {
+		t.Errorf("expected some cost budget to be consumed")
+	}
+	for i, evaluations := range results {
+		if len(evaluations) != 1 {
+			t.Fatalf("input %d: expected 1 evaluation, got %d", i, len(evaluations))
+		}
+		if evaluations[0].Error != nil {
+			t.Fatalf("input %d: unexpected error: %v", i, evaluations[0].Error)
+		}
+		if evaluations[0].EvalResult != celtypes.True {
+			t.Errorf("input %d: expected true, got %v", i, evaluations[0].EvalResult)
+		}
+	}
+
+	if _, _, err := f.(*filter).ForInputs(context.TODO(), versionedAttrs, requests[:1], OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget); err == nil {
+		t.Errorf("expected error for mismatched input lengths")
+	}
+}
+
+func TestForPreparedInput(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	filters := []Filter{
+		compiler.Compile([]ExpressionAccessor{
+			&condition{Expression: "object.subsets.size() == 1"},
+		}, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions),
+		compiler.Compile([]ExpressionAccessor{
+			&condition{Expression: "request.operation == 'CREATE'"},
+		}, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions),
+	}
+
+	attr := endpointCreateAttributes()
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	// One preparation serves every filter evaluating the same input.
+	prepared, err := PrepareInput(versionedAttr, request)
+	if err != nil {
+		t.Fatalf("PrepareInput failed: %v", err)
+	}
+	for i, f := range filters {
+		results, _, err := f.ForPreparedInput(context.TODO(), prepared, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+		if err != nil {
+			t.Fatalf("filter %d: unexpected error: %v", i, err)
+		}
+		if len(results) != 1 || results[0].Error != nil {
+			t.Fatalf("filter %d: unexpected results: %#v", i, results)
+		}
+		if results[0].EvalResult != celtypes.True {
+			t.Errorf("filter %d: expected true, got %v", i, results[0].EvalResult)
+		}
+	}
+
+	// The prepared path must agree with the converting path.
+	direct, _, err := filters[0].ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	viaPrepared, _, err := filters[0].ForPreparedInput(context.TODO(), prepared, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if direct[0].EvalResult != viaPrepared[0].EvalResult {
+		t.Errorf("prepared evaluation disagrees with direct evaluation: %v vs %v", viaPrepared[0].EvalResult, direct[0].EvalResult)
+	}
+}
+
+// TestEvaluationResultOrdering asserts that ForInput returns one
+// result per compiled expression, index-for-index, including the
+// placeholder for a nil accessor, even when many evaluations run
+// concurrently.
+func TestEvaluationResultOrdering(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	expressions := []ExpressionAccessor{
+		&condition{Expression: "object.subsets.size() == 1"},
+		nil, // placeholder, as left by a policy with an uncompiled entry
+		&condition{Expression: "object.subsets.size() == 2"},
+		&condition{Expression: "object.metadata.missing == 'x'"},
+	}
+	f := compiler.Compile(expressions, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions)
+
+	attr := endpointCreateAttributes()
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	check := func() error {
+		results, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+		if err != nil {
+			return err
+		}
+		if len(results) != len(expressions) {
+			return fmt.Errorf("expected %d results, got %d", len(expressions), len(results))
+		}
+		for i, result := range results {
+			if result.Index != i {
+				return fmt.Errorf("result %d reports index %d", i, result.Index)
+			}
+			if expressions[i] == nil {
+				if result.ExpressionAccessor != nil {
+					return fmt.Errorf("result %d should be a placeholder but carries an accessor", i)
+				}
+				continue
+			}
+			if result.ExpressionAccessor != expressions[i] {
+				return fmt.Errorf("result %d carries the accessor of a different expression", i)
+			}
+		}
+		if results[0].EvalResult != celtypes.True {
+			return fmt.Errorf("expected expression 0 to evaluate to true, got %v", results[0].EvalResult)
+		}
+		if results[2].EvalResult != celtypes.False {
+			return fmt.Errorf("expected expression 2 to evaluate to false, got %v", results[2].EvalResult)
+		}
+		if results[3].Error == nil {
+			return fmt.Errorf("expected expression 3 to record an evaluation error")
+		}
+		return nil
+	}
+
+	const workers = 8
+	errs := make([]error, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for iter := 0; iter < 20; iter++ {
+				if errs[w] = check(); errs[w] != nil {
+					return
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestForInputMatching(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	expressions := []ExpressionAccessor{
+		&condition{Expression: "object.metadata.missing == 'x'"}, // errors, must not short-circuit
+		&condition{Expression: "object.subsets.size() == 1"},     // true
+		&condition{Expression: "object.subsets.size() == 2"},     // false, short-circuits
+		&condition{Expression: "object.subsets.size() == 1"},     // never evaluated
+	}
+	f := compiler.Compile(expressions, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions)
+
+	attr := endpointCreateAttributes()
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	results, _, err := f.ForInputMatching(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != len(expressions) {
+		t.Fatalf("expected one result per expression, got %d", len(results))
+	}
+	if results[0].Error == nil {
+		t.Errorf("expected expression 0 to record an evaluation error")
+	}
+	if results[1].EvalResult != celtypes.True {
+		t.Errorf("expected expression 1 to evaluate to true, got %v", results[1].EvalResult)
+	}
+	if results[2].EvalResult != celtypes.False {
+		t.Errorf("expected expression 2 to evaluate to false, got %v", results[2].EvalResult)
+	}
+	if results[3].ExpressionAccessor != nil || results[3].EvalResult != nil || results[3].Error != nil {
+		t.Errorf("expected expression 3 to be left unevaluated, got %+v", results[3])
+	}
+	if results[3].Index != 3 {
+		t.Errorf("expected the unevaluated entry to keep its index, got %d", results[3].Index)
+	}
+
+	// Without short-circuiting, the expression after the false one is
+	// evaluated as usual.
+	results, _, err = f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if results[3].EvalResult != celtypes.True {
+		t.Errorf("expected expression 3 to evaluate to true, got %v", results[3].EvalResult)
+	}
+}
+
+func TestFilterClose(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	f := compiler.Compile([]ExpressionAccessor{
+		&condition{Expression: "object.subsets.size() == 1"},
+	}, OptionalVariableDeclarations{StrictCost: true}, environment.NewExpressions)
+
+	attr := endpointCreateAttributes()
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	if _, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget); err != nil {
+		t.Fatalf("unexpected error before close: %v", err)
+	}
+
+	f.Close()
+	f.Close() // closing again must be safe
+
+	if _, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget); !errors.Is(err, ErrFilterClosed) {
+		t.Errorf("expected ErrFilterClosed after close, got: %v", err)
+	}
+	if _, _, err := f.(*filter).ForInputs(context.TODO(), []*admission.VersionedAttributes{versionedAttr}, []*admissionv1.AdmissionRequest{request}, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget); !errors.Is(err, ErrFilterClosed) {
+		t.Errorf("expected ErrFilterClosed after close, got: %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	decls := OptionalVariableDeclarations{StrictCost: true}
+
+	if errs := compiler.Validate(&condition{Expression: "object.subsets.size() < 100"}, decls); len(errs) != 0 {
+		t.Errorf("unexpected errors for valid expression: %v", errs)
+	}
+	if errs := compiler.Validate(&condition{Expression: "object.subsets.size("}, decls); len(errs) == 0 {
+		t.Errorf("expected syntax errors")
+	}
+	if errs := compiler.Validate(&condition{Expression: "'not a bool'"}, decls); len(errs) != 1 || !strings.Contains(errs[0].Error(), "must evaluate to bool") {
+		t.Errorf("expected return type error, got %v", errs)
+	}
+	// params is not declared unless HasParams is set
+	if errs := compiler.Validate(&condition{Expression: "params != null"}, decls); len(errs) == 0 {
+		t.Errorf("expected undeclared variable error")
+	}
+	if errs := compiler.Validate(&condition{Expression: "params != null"}, OptionalVariableDeclarations{HasParams: true, StrictCost: true}); len(errs) != 0 {
+		t.Errorf("unexpected errors with params declared: %v", errs)
+	}
+}
+
+func TestChangedVariable(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	decls := OptionalVariableDeclarations{HasChangedFields: true, StrictCost: true}
+
+	oldObject := endpointCreateAttributes().GetObject().(*corev1.Endpoints)
+	object := oldObject.DeepCopy()
+	object.Subsets[0].Addresses[0].IP = "127.0.0.1"
+	gvk := schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Endpoints"}
+	gvr := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "endpoints"}
+
+	cases := []struct {
+		name       string
+		attributes admission.Attributes
+		expression string
+		expected   ref.Val
+	}{
+		{
+			name:       "changed field listed",
+			attributes: admission.NewAttributesRecord(object, oldObject, gvk, "default", "endpoints1", gvr, "", admission.Update, &metav1.UpdateOptions{}, false, nil),
+			expression: "changed == ['subsets']",
+			expected:   celtypes.True,
+		},
+		{
+			name:       "unchanged update is empty",
+			attributes: endpointStatusUpdateAttributes(),
+			expression: "changed.size() == 0",
+			expected:   celtypes.True,
+		},
+		{
+			name:       "create lists all new fields",
+			attributes: endpointCreateAttributes(),
+			expression: "'metadata' in changed && 'subsets' in changed && !('oops' in changed)",
+			expected:   celtypes.True,
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := compiler.Compile([]ExpressionAccessor{&condition{Expression: tc.expression}}, decls, environment.NewExpressions)
+			versionedAttr, err := admission.NewVersionedAttributes(tc.attributes, tc.attributes.GetKind(), newObjectInterfacesForTest())
+			if err != nil {
+				t.Fatal(err)
+			}
+			request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+			evaluations, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if evaluations[0].Error != nil {
+				t.Fatalf("unexpected error: %v", evaluations[0].Error)
+			}
+			if evaluations[0].EvalResult != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, evaluations[0].EvalResult)
+			}
+		})
+	}
+}
+
+func TestPerExpressionTimeout(t *testing.T) {
+	compiler := NewCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	decls := OptionalVariableDeclarations{StrictCost: true}
+	// The first expression iterates enough to hit the interrupt check;
+	// the second is a constant that completes without one.
+	exprs := []ExpressionAccessor{
+		&condition{Expression: "[1,2,3,4,5,6,7,8,9,10].all(x, [1,2,3,4,5,6,7,8,9,10].all(y, [1,2,3,4,5,6,7,8,9,10].all(z, x+y+z > 0)))"},
+		&condition{Expression: "true"},
+	}
+	compilationResults := make([]CompilationResult, len(exprs))
+	for i, expr := range exprs {
+		compilationResults[i] = compiler.CompileCELExpression(expr, decls, environment.NewExpressions)
+		if compilationResults[i].Error != nil {
+			t.Fatalf("unexpected compilation error: %v", compilationResults[i].Error)
+		}
+	}
+	attr := endpointCreateAttributes()
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	// With an already-expired timeout, each expression records the timeout
+	// as its own error and evaluation continues; ForInput itself does not
+	// fail.
+	f := NewFilterWithPerExpressionTimeout(compilationResults, time.Nanosecond)
+	evaluations, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(evaluations) != 2 {
+		t.Fatalf("expected 2 evaluations, got %d", len(evaluations))
+	}
+	for i, evaluation := range evaluations {
+		if evaluation.Error == nil || !strings.Contains(evaluation.Error.Error(), "timed out") {
+			t.Errorf("expression %d: expected timeout error, got %v", i, evaluation.Error)
+		}
+	}
+
+	// A generous timeout does not interfere with evaluation.
+	f = NewFilterWithPerExpressionTimeout(compilationResults, time.Minute)
+	evaluations, _, err = f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, evaluation := range evaluations {
+		if evaluation.Error != nil {
+			t.Errorf("expression %d: unexpected error: %v", i, evaluation.Error)
+		}
+		if evaluation.EvalResult != celtypes.True {
+			t.Errorf("expression %d: expected true, got %v", i, evaluation.EvalResult)
+		}
+		if evaluation.Index != i {
+			t.Errorf("expression %d: expected Index %d, got %d", i, i, evaluation.Index)
+		}
+	}
+}
+
+func TestBudgetExceededBehavior(t *testing.T) {
+	validations := []ExpressionAccessor{
+		&condition{
+			Expression: "has(object.subsets) && object.subsets.size() < 2",
+		},
+		&condition{
+			Expression: "has(object.subsets)",
+		},
+	}
+	c := filterCompiler{compiler: NewCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), false))}
+	compilationResults := c.Compile(validations, OptionalVariableDeclarations{}, environment.NewExpressions).(*filter).compilationResults
+	versionedAttr, err := admission.NewVersionedAttributes(newValidAttribute(nil, false), newValidAttribute(nil, false).GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatalf("unexpected error on conversion: %v", err)
+	}
+	ctx := context.TODO()
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	t.Run("fail-closed is the default", func(t *testing.T) {
+		f := NewFilter(compilationResults)
+		_, remaining, err := f.ForInput(ctx, versionedAttr, request, OptionalVariableBindings{}, nil, 1)
+		var budgetErr *BudgetExceededError
+		if !errors.As(err, &budgetErr) {
+			t.Fatalf("expected a *BudgetExceededError, got %v", err)
+		}
+		if budgetErr.Behavior != BudgetFailClosed {
+			t.Errorf("expected fail-closed behavior on the error, got %v", budgetErr.Behavior)
+		}
+		var celErr *apiservercel.Error
+		if !errors.As(err, &celErr) || celErr.Type != apiservercel.ErrorTypeInvalid {
+			t.Errorf("expected the wrapped *cel.Error of type invalid, got %v", err)
+		}
+		if remaining != -1 {
+			t.Errorf("expected -1 remaining budget, got %d", remaining)
+		}
+	})
+
+	t.Run("fail-open annotates the result instead of erroring", func(t *testing.T) {
+		f := NewFilterWithBudgetBehavior(compilationResults, BudgetFailOpen)
+		evalResults, remaining, err := f.ForInput(ctx, versionedAttr, request, OptionalVariableBindings{}, nil, 1)
+		if err != nil {
+			t.Fatalf("expected no top-level error when failing open, got %v", err)
+		}
+		if remaining != 0 {
+			t.Errorf("expected zero remaining budget, got %d", remaining)
+		}
+		if len(evalResults) != len(validations) {
+			t.Fatalf("expected %d results, got %d", len(validations), len(evalResults))
+		}
+		var budgetErr *BudgetExceededError
+		if !errors.As(evalResults[0].Error, &budgetErr) || budgetErr.Behavior != BudgetFailOpen {
+			t.Errorf("expected the exhausting expression's result to carry a fail-open *BudgetExceededError, got %v", evalResults[0].Error)
+		}
+		if evalResults[1].EvalResult != nil || evalResults[1].Error != nil {
+			t.Errorf("expected the remaining expression to be left unevaluated, got %#v", evalResults[1])
+		}
+	})
+}
+
+func TestDeclaredVariables(t *testing.T) {
+	c := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), false))
+	for _, tc := range []struct {
+		name     string
+		options  OptionalVariableDeclarations
+		expected []VariableInfo
+	}{
+		{
+			name:    "base variables only",
+			options: OptionalVariableDeclarations{},
+			expected: []VariableInfo{
+				{Name: ObjectVarName},
+				{Name: OldObjectVarName},
+				{Name: NamespaceVarName},
+				{Name: RequestVarName},
+			},
+		},
+		{
+			name:    "all optional variables",
+			options: OptionalVariableDeclarations{HasParams: true, HasAuthorizer: true, HasChangedFields: true},
+			expected: []VariableInfo{
+				{Name: ObjectVarName},
+				{Name: OldObjectVarName},
+				{Name: NamespaceVarName},
+				{Name: RequestVarName},
+				{Name: ParamsVarName, Optional: true},
+				{Name: AuthorizerVarName, Optional: true},
+				{Name: RequestResourceAuthorizerVarName, Optional: true},
+				{Name: ChangedVarName, Optional: true},
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := c.Compile([]ExpressionAccessor{&condition{Expression: "object != null"}}, tc.options, environment.NewExpressions)
+			if got := f.DeclaredVariables(); !reflect.DeepEqual(got, tc.expected) {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+
+	if got := NewFilter(nil).DeclaredVariables(); got != nil {
+		t.Errorf("expected no declared variables for a directly built filter, got %v", got)
+	}
+}
+
+func TestLazyAuthorizerConstruction(t *testing.T) {
+	c := filterCompiler{compiler: NewCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), false))}
+	versionedAttr, err := admission.NewVersionedAttributes(newValidAttribute(nil, false), newValidAttribute(nil, false).GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatalf("unexpected error on conversion: %v", err)
+	}
+	ctx := context.TODO()
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+
+	for _, tc := range []struct {
+		name            string
+		expression      string
+		expectConstruct bool
+	}{
+		{
+			name:            "authorizer referenced",
+			expression:      "authorizer.group('').resource('endpoints').check('create').allowed() == false",
+			expectConstruct: true,
+		},
+		{
+			name:            "authorizer not referenced",
+			expression:      "object != null",
+			expectConstruct: false,
+		},
+		{
+			name:            "authorizer reference short-circuited away",
+			expression:      "true || authorizer.group('').resource('endpoints').check('create').allowed()",
+			expectConstruct: false,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			f := c.Compile([]ExpressionAccessor{&condition{Expression: tc.expression}}, OptionalVariableDeclarations{HasAuthorizer: true}, environment.NewExpressions)
+			constructed := 0
+			bindings := OptionalVariableBindings{AuthorizerFunc: func() authorizer.Authorizer {
+				constructed++
+				return denyAll
+			}}
+			results, _, err := f.ForInput(ctx, versionedAttr, request, bindings, nil, celconfig.RuntimeCELCostBudget)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if results[0].Error != nil {
+				t.Fatalf("unexpected evaluation error: %v", results[0].Error)
+			}
+			if results[0].EvalResult != celtypes.True {
+				t.Errorf("expected the expression to evaluate to true, got %v", results[0].EvalResult)
+			}
+			wantConstructed := 0
+			if tc.expectConstruct {
+				wantConstructed = 1
+			}
+			if constructed != wantConstructed {
+				t.Errorf("expected the authorizer to be constructed %d times, got %d", wantConstructed, constructed)
+			}
+		})
+	}
+}
+
+func TestPerCallLimit(t *testing.T) {
+	c := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), false))
+	f := c.Compile([]ExpressionAccessor{&condition{Expression: "object != null"}}, OptionalVariableDeclarations{}, environment.NewExpressions)
+	if got := f.PerCallLimit(); got != uint64(celconfig.PerCallLimit) {
+		t.Errorf("expected a compiled filter to report the default per-call limit %d, got %d", celconfig.PerCallLimit, got)
+	}
+	if got := NewFilter(nil).PerCallLimit(); got != uint64(celconfig.PerCallLimit) {
+		t.Errorf("expected a directly built filter to report the default per-call limit %d, got %d", celconfig.PerCallLimit, got)
+	}
+	if got := NewFilterWithPerCallLimit(nil, 42).PerCallLimit(); got != 42 {
+		t.Errorf("expected the recorded per-call limit 42, got %d", got)
+	}
+}
+
+func TestDeleteOldObjectOnly(t *testing.T) {
+	oldObject := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "endpoints1",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "test"},
+		},
+	}
+	attr := admission.NewAttributesRecord(nil, oldObject, schema.GroupVersionKind{}, "default", "endpoints1", schema.GroupVersionResource{}, "", admission.Delete, &metav1.DeleteOptions{}, false, nil)
+	versionedAttr, err := admission.NewVersionedAttributes(attr, attr.GetKind(), newObjectInterfacesForTest())
+	if err != nil {
+		t.Fatalf("unexpected error on conversion: %v", err)
+	}
+	c := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), false))
+	f := c.Compile([]ExpressionAccessor{
+		&condition{Expression: "oldObject.metadata.labels['app'] == 'test'"},
+		&condition{Expression: "object == null"},
+		&condition{Expression: "request.operation == 'DELETE'"},
+	}, OptionalVariableDeclarations{}, environment.NewExpressions)
+	if errs := f.CompilationErrors(); len(errs) > 0 {
+		t.Fatalf("expected expressions referencing only oldObject to compile, got %v", errs)
+	}
+	request := CreateAdmissionRequest(versionedAttr.Attributes, metav1.GroupVersionResource(versionedAttr.GetResource()), metav1.GroupVersionKind(versionedAttr.VersionedKind))
+	results, _, err := f.ForInput(context.TODO(), versionedAttr, request, OptionalVariableBindings{}, nil, celconfig.RuntimeCELCostBudget)
+	if err != nil {
+		t.Fatalf("unexpected error on evaluation: %v", err)
+	}
+	for _, result := range results {
+		if result.Error != nil {
+			t.Errorf("unexpected error evaluating %q: %v", result.ExpressionAccessor.GetExpression(), result.Error)
+			continue
+		}
+		if result.EvalResult != celtypes.True {
+			t.Errorf("expected %q to be true on DELETE, got %v", result.ExpressionAccessor.GetExpression(), result.EvalResult)
+		}
+	}
+}
@@ -31,6 +31,12 @@ import (
 	"k8s.io/apiserver/pkg/cel/environment"
 )
 
+// ExpressionAccessor provides the CEL expression source and the set of
+// types the expression is allowed to evaluate to.  Return types are not
+// limited to booleans: string- or int-returning accessors (e.g. the
+// validating policy's AuditAnnotationCondition) are validated against
+// their declared types at compile time, and the typed result is
+// preserved in EvaluationResult.EvalResult for the caller to convert.
 type ExpressionAccessor interface {
 	GetExpression() string
 	ReturnTypes() []*cel.Type
@@ -48,7 +54,16 @@ type EvaluationResult struct {
 	EvalResult         ref.Val
 	ExpressionAccessor ExpressionAccessor
 	Elapsed            time.Duration
-	Error              error
+	// Index is the position of the evaluated expression in the slice the
+	// Filter was compiled from, so that a failing result can be reported
+	// by position without identity comparison on ExpressionAccessor.
+	Index int
+	// Cost is the number of CEL cost units consumed evaluating this
+	// expression (including any lazily evaluated composited variables),
+	// as charged against the runtime cost budget.  Unlike Elapsed it is
+	// deterministic, so it identifies expensive expressions reliably.
+	Cost  uint64
+	Error error
 }
 
 // OptionalVariableDeclarations declares which optional CEL variables
@@ -63,12 +78,31 @@ type OptionalVariableDeclarations struct {
 	HasAuthorizer bool
 	// StrictCost specifies if the CEL cost limitation is strict for extended libraries as well as native libraries.
 	StrictCost bool
+	// HasChangedFields specifies if the "changed" variable is declared.
+	// When declared, "changed" is bound to the sorted list of top-level
+	// field names that differ between "oldObject" and "object", sparing
+	// policies a manual field-by-field comparison on update requests.
+	HasChangedFields bool
 }
 
 // FilterCompiler contains a function to assist with converting types and values to/from CEL-typed values.
 type FilterCompiler interface {
 	// Compile is used for the cel expression compilation
 	Compile(expressions []ExpressionAccessor, optionalDecls OptionalVariableDeclarations, envType environment.Type) Filter
+
+	// EstimateCost statically estimates the worst-case runtime cost of
+	// each expression, in CEL cost units, without retaining a runnable
+	// program.  The returned slice corresponds index-for-index to the
+	// input expressions.  This lets operators verify that a policy
+	// stays within the per-call cost limit before serving live traffic.
+	EstimateCost(expressions []ExpressionAccessor, optionalDecls OptionalVariableDeclarations) ([]uint64, error)
+
+	// Validate checks the expression's syntax and its output type against
+	// ReturnTypes without instantiating a runnable program, making it
+	// cheap enough for linting large numbers of expressions that are
+	// immediately discarded.  A nil return means the expression would
+	// compile cleanly under the same environment Compile uses.
+	Validate(expression ExpressionAccessor, optionalDecls OptionalVariableDeclarations) []error
 }
 
 // OptionalVariableBindings provides expression bindings for optional CEL variables.
@@ -78,8 +112,28 @@ type OptionalVariableBindings struct {
 	VersionedParams runtime.Object
 	// Authorizer provides the authorizer used for the "authorizer" and
 	// "authorizer.requestResource" variable bindings. If the expression was compiled with
-	// OptionalVariableDeclarations.HasAuthorizer set to true this must be non-nil.
+	// OptionalVariableDeclarations.HasAuthorizer set to true, this or
+	// AuthorizerFunc must be non-nil.
 	Authorizer authorizer.Authorizer
+
+	// AuthorizerFunc, if non-nil, supplies the authorizer instead of
+	// Authorizer and is invoked lazily, at most once per input, and
+	// only when an expression actually references the "authorizer" or
+	// "authorizer.requestResource" variable during evaluation.  Use
+	// this when constructing the authorizer is expensive and
+	// expressions may not reference it.
+	AuthorizerFunc func() authorizer.Authorizer
+}
+
+// VariableInfo describes one CEL variable declared for the
+// expressions of a Filter.
+type VariableInfo struct {
+	// Name is the variable's name as referenced in expressions.
+	Name string
+	// Optional is true when the variable is declared only because of
+	// the OptionalVariableDeclarations the Filter was compiled with,
+	// rather than being available to every expression.
+	Optional bool
 }
 
 // Filter contains a function to evaluate compiled CEL-typed values
@@ -90,8 +144,72 @@ type Filter interface {
 	// ForInput converts compiled CEL-typed values into evaluated CEL-typed value.
 	// runtimeCELCostBudget was added for testing purpose only. Callers should always use const RuntimeCELCostBudget from k8s.io/apiserver/pkg/apis/cel/config.go as input.
 	// If cost budget is calculated, the filter should return the remaining budget.
+	// On DELETE requests "object" is bound to null and only "oldObject"
+	// carries data; on CREATE it is the reverse.  Expressions reference
+	// the side that exists for their operation or guard against null.
+	// The returned results correspond index-for-index to the
+	// expressions the Filter was compiled from: result i is always the
+	// evaluation of expression i (a placeholder for a nil accessor),
+	// regardless of any internal parallelism, so callers may rely on
+	// positional mapping instead of matching on ExpressionAccessor.
 	ForInput(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *v1.AdmissionRequest, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error)
 
+	// ForPreparedInput is like ForInput but takes the activation-ready
+	// representation produced by PrepareInput instead of converting
+	// the versioned objects itself.  Preparing once and evaluating the
+	// same input against several Filters — the policies matching one
+	// admission request — skips the redundant conversions.
+	ForPreparedInput(ctx context.Context, prepared *PreparedInput, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error)
+
+	// ForInputs evaluates the compiled CEL expressions for each of the given
+	// inputs in turn, producing one result slice per input.  The params and
+	// namespace bindings and the cost budget are shared across all inputs,
+	// amortizing per-evaluation setup when a policy is applied to many
+	// objects at once.  The outer result slice corresponds index-for-index
+	// to versionedAttrs, which must be the same length as requests.
+	ForInputs(ctx context.Context, versionedAttrs []*admission.VersionedAttributes, requests []*v1.AdmissionRequest, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([][]EvaluationResult, int64, error)
+
+	// ForInputMatching is like ForInput but stops evaluating at the
+	// first expression that evaluates to false, skipping the
+	// remaining expressions.  It is meant for ANDed boolean match
+	// conditions, where one false decides the outcome and evaluating
+	// the rest is wasted cost.  The returned slice still has one entry
+	// per compiled expression; entries after the first false are
+	// zero-valued apart from their index.  Expressions that error do
+	// not short-circuit, so callers can still apply their failure
+	// policy when no expression is definitively false.
+	ForInputMatching(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *v1.AdmissionRequest, optionalVars OptionalVariableBindings, namespace *corev1.Namespace, runtimeCELCostBudget int64) ([]EvaluationResult, int64, error)
+
 	// CompilationErrors returns a list of errors from the compilation of the evaluator
 	CompilationErrors() []error
+
+	// CompilationWarnings returns non-fatal diagnostics from the
+	// compilation of the evaluator.  A Filter with warnings but no
+	// errors remains usable for evaluation.
+	CompilationWarnings() []error
+
+	// DeclaredVariables reports the variables declared for the
+	// expressions of this Filter, in declaration order, given the
+	// OptionalVariableDeclarations it was compiled with.  Tooling can
+	// use this for accurate completion and to reject references to
+	// undeclared optional variables with a clearer error than the
+	// evaluator produces.  A Filter built directly from compilation
+	// results reports none.
+	DeclaredVariables() []VariableInfo
+
+	// PerCallLimit reports the per-call CEL cost limit the Filter's
+	// expressions were compiled under, in CEL cost units, so tooling
+	// and logs can display the effective limit.  A Filter built
+	// without an explicit limit reports the default from
+	// k8s.io/apiserver/pkg/apis/cel/config.go.
+	PerCallLimit() uint64
+
+	// Close signals all in-progress ForInput and ForInputs calls to
+	// abort promptly with an error wrapping ErrFilterClosed, and makes
+	// future calls fail the same way.  Callers tearing down a policy
+	// during reconfiguration use it to stop paying for evaluations
+	// whose results will be discarded, treating the aborted
+	// evaluations as skipped.  It is safe to call more than once and
+	// concurrently with evaluation.
+	Close()
 }
@@ -0,0 +1,50 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cel
+
+import (
+	"testing"
+
+	"k8s.io/apiserver/pkg/cel/environment"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+func TestFilterCompilationDurationMetric(t *testing.T) {
+	compiler := NewFilterCompiler(environment.MustBaseEnvSet(environment.DefaultCompatibilityVersion(), true))
+	labels := map[string]string{"expressions": "2", "has_params": "true", "has_authorizer": "false"}
+
+	before := compilationSampleCount(t, labels)
+	compiler.Compile([]ExpressionAccessor{
+		&condition{Expression: "true"},
+		&condition{Expression: "false"},
+	}, OptionalVariableDeclarations{HasParams: true, StrictCost: true}, environment.NewExpressions)
+	after := compilationSampleCount(t, labels)
+
+	if after != before+1 {
+		t.Errorf("expected one observation for labels %v, sample count went from %d to %d", labels, before, after)
+	}
+}
+
+func compilationSampleCount(t *testing.T, labels map[string]string) uint64 {
+	t.Helper()
+	vec, err := testutil.GetHistogramVecFromGatherer(legacyregistry.DefaultGatherer, "apiserver_cel_filter_compilation_duration_seconds", labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return vec.GetAggregatedSampleCount()
+}
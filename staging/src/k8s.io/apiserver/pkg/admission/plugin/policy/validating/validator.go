@@ -18,6 +18,7 @@ package validating
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 
@@ -136,15 +137,18 @@ func (v *validator) Validate(ctx context.Context, matchedResource schema.GroupVe
 		if len(messageResults) > i {
 			messageResult = &messageResults[i]
 		}
-		messageError, _ = err.(*apiservercel.Error)
+		messageError = nil
+		errors.As(err, &messageError)
+		var messageBudgetError *cel.BudgetExceededError
 		if evalResult.Error != nil {
 			decision.Action = policyDecisionActionForError(f)
 			decision.Evaluation = EvalError
 			decision.Message = evalResult.Error.Error()
-		} else if messageError != nil &&
-			(messageError.Type == apiservercel.ErrorTypeInternal ||
-				(messageError.Type == apiservercel.ErrorTypeInvalid &&
-					strings.HasPrefix(messageError.Detail, "validation failed due to running out of cost budget"))) {
+		} else if errors.As(err, &messageBudgetError) ||
+			(messageError != nil &&
+				(messageError.Type == apiservercel.ErrorTypeInternal ||
+					(messageError.Type == apiservercel.ErrorTypeInvalid &&
+						strings.HasPrefix(messageError.Detail, "validation failed due to running out of cost budget")))) {
 			decision.Action = policyDecisionActionForError(f)
 			decision.Evaluation = EvalError
 			decision.Message = fmt.Sprintf("failed messageExpression: %s", err)
@@ -61,10 +61,46 @@ func (f *fakeCelFilter) ForInput(ctx context.Context, versionedAttr *admission.V
 	return f.evaluations, costBudget - 1, nil
 }
 
+func (f *fakeCelFilter) ForPreparedInput(ctx context.Context, prepared *cel.PreparedInput, optionalVars cel.OptionalVariableBindings, namespace *corev1.Namespace, costBudget int64) ([]cel.EvaluationResult, int64, error) {
+	return f.ForInput(ctx, nil, nil, optionalVars, namespace, costBudget)
+}
+
+func (f *fakeCelFilter) ForInputMatching(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *admissionv1.AdmissionRequest, optionalVars cel.OptionalVariableBindings, namespace *corev1.Namespace, costBudget int64) ([]cel.EvaluationResult, int64, error) {
+	return f.ForInput(ctx, versionedAttr, request, optionalVars, namespace, costBudget)
+}
+
+func (f *fakeCelFilter) ForInputs(ctx context.Context, versionedAttrs []*admission.VersionedAttributes, requests []*admissionv1.AdmissionRequest, optionalVars cel.OptionalVariableBindings, namespace *corev1.Namespace, costBudget int64) ([][]cel.EvaluationResult, int64, error) {
+	results := make([][]cel.EvaluationResult, len(versionedAttrs))
+	for i, versionedAttr := range versionedAttrs {
+		evaluations, remaining, err := f.ForInput(ctx, versionedAttr, requests[i], optionalVars, namespace, costBudget)
+		if err != nil {
+			return nil, -1, err
+		}
+		results[i] = evaluations
+		costBudget = remaining
+	}
+	return results, costBudget, nil
+}
+
 func (f *fakeCelFilter) CompilationErrors() []error {
 	return []error{}
 }
 
+func (f *fakeCelFilter) CompilationWarnings() []error {
+	return []error{}
+}
+
+func (f *fakeCelFilter) DeclaredVariables() []cel.VariableInfo {
+	return nil
+}
+
+func (f *fakeCelFilter) PerCallLimit() uint64 {
+	return 0
+}
+
+func (f *fakeCelFilter) Close() {
+}
+
 var _ matchconditions.Matcher = &fakeCELMatcher{}
 
 type fakeCELMatcher struct {
@@ -49,10 +49,47 @@ func (f *fakeCelFilter) ForInput(context.Context, *admission.VersionedAttributes
 	return f.evaluations, 0, nil
 }
 
+func (f *fakeCelFilter) ForPreparedInput(context.Context, *cel.PreparedInput, cel.OptionalVariableBindings, *api.Namespace, int64) ([]cel.EvaluationResult, int64, error) {
+	if f.throwError {
+		return nil, 0, errors.New("test error")
+	}
+	return f.evaluations, 0, nil
+}
+
+func (f *fakeCelFilter) ForInputMatching(ctx context.Context, versionedAttr *admission.VersionedAttributes, request *admissionv1.AdmissionRequest, optionalVars cel.OptionalVariableBindings, namespace *api.Namespace, costBudget int64) ([]cel.EvaluationResult, int64, error) {
+	return f.ForInput(ctx, versionedAttr, request, optionalVars, namespace, costBudget)
+}
+
+func (f *fakeCelFilter) ForInputs(ctx context.Context, versionedAttrs []*admission.VersionedAttributes, requests []*admissionv1.AdmissionRequest, optionalVars cel.OptionalVariableBindings, namespace *api.Namespace, costBudget int64) ([][]cel.EvaluationResult, int64, error) {
+	if f.throwError {
+		return nil, 0, errors.New("test error")
+	}
+	results := make([][]cel.EvaluationResult, len(versionedAttrs))
+	for i := range results {
+		results[i] = f.evaluations
+	}
+	return results, 0, nil
+}
+
 func (f *fakeCelFilter) CompilationErrors() []error {
 	return []error{}
 }
 
+func (f *fakeCelFilter) CompilationWarnings() []error {
+	return []error{}
+}
+
+func (f *fakeCelFilter) DeclaredVariables() []cel.VariableInfo {
+	return nil
+}
+
+func (f *fakeCelFilter) PerCallLimit() uint64 {
+	return 0
+}
+
+func (f *fakeCelFilter) Close() {
+}
+
 func TestMatch(t *testing.T) {
 	fakeAttr := admission.NewAttributesRecord(nil, nil, schema.GroupVersionKind{}, "default", "foo", schema.GroupVersionResource{}, "", admission.Create, nil, false, nil)
 	fakeVersionedAttr, _ := admission.NewVersionedAttributes(fakeAttr, schema.GroupVersionKind{}, nil)
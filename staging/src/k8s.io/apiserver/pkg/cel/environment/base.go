@@ -18,6 +18,7 @@ package environment
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"sync"
 
@@ -48,7 +49,12 @@ func DefaultCompatibilityVersion() *version.Version {
 
 var baseOpts = append(baseOptsWithoutStrictCost, StrictCostOpt)
 
-var baseOptsWithoutStrictCost = []VersionedOptions{
+var baseOptsWithoutStrictCost = append(append([]VersionedOptions{}, coreOpts...), allOptionalLibraryOpts()...)
+
+// coreOpts are the base options other than the optional function
+// libraries, which live in optionalLibraryOpts so that environments
+// can be built with a subset of them; see BaseEnvSetWithLibraries.
+var coreOpts = []VersionedOptions{
 	{
 		// CEL epoch was actually 1.23, but we artificially set it to 1.0 because these
 		// options should always be present.
@@ -61,10 +67,6 @@ var baseOptsWithoutStrictCost = []VersionedOptions{
 			cel.EagerlyValidateDeclarations(true),
 			cel.DefaultUTCTimeZone(true),
 
-			library.URLs(),
-			library.Regex(),
-			library.Lists(),
-
 			// cel-go v0.17.7 change the cost of has() from 0 to 1, but also provided the CostEstimatorOptions option to preserve the old behavior, so we enabled it at the same time we bumped our cel version to v0.17.7.
 			// Since it is a regression fix, we apply it uniformly to all code use v0.17.7.
 			cel.CostEstimatorOptions(checker.PresenceTestHasCost(false)),
@@ -78,18 +80,11 @@ var baseOptsWithoutStrictCost = []VersionedOptions{
 			cel.CostTrackerOptions(interpreter.PresenceTestHasCost(false)),
 		},
 	},
-	{
-		IntroducedVersion: version.MajorMinor(1, 27),
-		EnvOptions: []cel.EnvOption{
-			library.Authz(),
-		},
-	},
 	{
 		IntroducedVersion: version.MajorMinor(1, 28),
 		EnvOptions: []cel.EnvOption{
 			cel.CrossTypeNumericComparisons(true),
 			cel.OptionalTypes(),
-			library.Quantity(),
 		},
 	},
 	// add the new validator in 1.29
@@ -125,22 +120,66 @@ var baseOptsWithoutStrictCost = []VersionedOptions{
 			ext.Sets(),
 		},
 	},
-	{
+}
+
+// optionalLibraryOpts maps each optional function library to the
+// option introducing it, keyed by the names BaseEnvSetWithLibraries
+// accepts.  All of them are part of the default base environment.
+var optionalLibraryOpts = map[string]VersionedOptions{
+	"urls": {
+		IntroducedVersion: version.MajorMinor(1, 0),
+		EnvOptions:        []cel.EnvOption{library.URLs()},
+	},
+	"regex": {
+		IntroducedVersion: version.MajorMinor(1, 0),
+		EnvOptions:        []cel.EnvOption{library.Regex()},
+	},
+	"lists": {
+		IntroducedVersion: version.MajorMinor(1, 0),
+		EnvOptions:        []cel.EnvOption{library.Lists()},
+	},
+	"authz": {
+		IntroducedVersion: version.MajorMinor(1, 27),
+		EnvOptions:        []cel.EnvOption{library.Authz()},
+	},
+	"quantity": {
+		IntroducedVersion: version.MajorMinor(1, 28),
+		EnvOptions:        []cel.EnvOption{library.Quantity()},
+	},
+	"ip": {
 		IntroducedVersion: version.MajorMinor(1, 30),
-		EnvOptions: []cel.EnvOption{
-			library.IP(),
-			library.CIDR(),
-		},
+		EnvOptions:        []cel.EnvOption{library.IP()},
+	},
+	"cidr": {
+		IntroducedVersion: version.MajorMinor(1, 30),
+		EnvOptions:        []cel.EnvOption{library.CIDR()},
 	},
 	// Format Library
-	{
+	"format": {
 		IntroducedVersion: version.MajorMinor(1, 31),
-		EnvOptions: []cel.EnvOption{
-			library.Format(),
-		},
+		EnvOptions:        []cel.EnvOption{library.Format()},
 	},
 }
 
+// OptionalLibraryNames returns the names of the optional function
+// libraries accepted by BaseEnvSetWithLibraries, sorted.
+func OptionalLibraryNames() []string {
+	names := make([]string, 0, len(optionalLibraryOpts))
+	for name := range optionalLibraryOpts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func allOptionalLibraryOpts() []VersionedOptions {
+	opts := make([]VersionedOptions, 0, len(optionalLibraryOpts))
+	for _, name := range OptionalLibraryNames() {
+		opts = append(opts, optionalLibraryOpts[name])
+	}
+	return opts
+}
+
 var StrictCostOpt = VersionedOptions{
 	// This is to configure the cost calculation for extended libraries
 	IntroducedVersion: version.MajorMinor(1, 0),
@@ -191,6 +230,36 @@ func MustBaseEnvSet(ver *version.Version, strictCost bool) *EnvSet {
 	return entry.(*EnvSet)
 }
 
+// BaseEnvSetWithLibraries is like MustBaseEnvSet, but the returned
+// environment contains only the optional function libraries named in
+// libraries; see OptionalLibraryNames for the accepted names.  An
+// unknown name, a nil version or a version without major and minor
+// components is reported as an error rather than a panic since the
+// library selection typically originates in configuration.
+//
+// Unlike MustBaseEnvSet the result is not cached; callers compiling
+// many expressions against the same selection should reuse it.
+func BaseEnvSetWithLibraries(ver *version.Version, strictCost bool, libraries []string) (*EnvSet, error) {
+	if ver == nil {
+		return nil, fmt.Errorf("version must be non-nil")
+	}
+	if len(ver.Components()) < 2 {
+		return nil, fmt.Errorf("version must contain an major and minor component, but got: %s", ver.String())
+	}
+	opts := append([]VersionedOptions{}, coreOpts...)
+	for _, name := range libraries {
+		opt, ok := optionalLibraryOpts[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown optional CEL library %q, known libraries are %v", name, OptionalLibraryNames())
+		}
+		opts = append(opts, opt)
+	}
+	if strictCost {
+		opts = append(opts, StrictCostOpt)
+	}
+	return newEnvSet(ver, opts)
+}
+
 var (
 	baseEnvs                       = sync.Map{}
 	baseEnvsWithOption             = sync.Map{}
@@ -35,6 +35,7 @@ import (
 	"k8s.io/apiserver/pkg/endpoints/handlers/responsewriters"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/server/httplog"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 )
 
 // WithImpersonation is a filter that will inspect and check requests that attempt to change the user.Info for their requests
@@ -161,6 +162,10 @@ func WithImpersonation(handler http.Handler, a authorizer.Authorizer, s runtime.
 			Extra:  userExtra,
 			UID:    uid,
 		}
+		// Preserve the authenticated identity for the flow-control
+		// trace matcher, which otherwise only sees the impersonated
+		// user; see continuum.AuthenticatedUserFrom.
+		ctx = continuum.WithAuthenticatedUser(ctx, requestor.GetName())
 		req = req.WithContext(request.WithUser(ctx, newUser))
 
 		oldUser, _ := request.UserFrom(ctx)
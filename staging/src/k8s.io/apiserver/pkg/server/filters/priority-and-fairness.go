@@ -32,6 +32,7 @@ import (
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/server/httplog"
 	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 	fcmetrics "k8s.io/apiserver/pkg/util/flowcontrol/metrics"
 	flowcontrolrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
 	"k8s.io/klog/v2"
@@ -98,6 +99,23 @@ func (h *priorityAndFairnessHandler) Handle(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// A benchmark run id supplied by the client tags this request's
+	// CONTINUUM markers, separating interleaved runs in the output.
+	if runID := r.Header.Get(continuum.RunIDHeader); runID != "" {
+		ctx = continuum.WithRunID(ctx, runID)
+	}
+
+	// Operator-configured header labels tag this request's lifecycle
+	// event; see continuum.SetHeaderLabels.
+	if labels := continuum.ExtractHeaderLabels(r.Header); labels != nil {
+		ctx = continuum.WithHeaderLabels(ctx, labels)
+	}
+
+	// Let the handler discount time blocked on non-APF work from the
+	// reported execution duration; see utilflowcontrol.MarkBlocked.
+	ctx = utilflowcontrol.WithBlockedTracking(ctx, utilsclock.RealClock{})
+	r = r.WithContext(ctx)
+
 	isWatchRequest := watchVerbs.Has(requestInfo.Verb)
 
 	// Skip tracking long running non-watch requests.
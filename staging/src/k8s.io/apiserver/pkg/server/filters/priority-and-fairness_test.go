@@ -122,6 +122,10 @@ func (t fakeApfFilter) Run(stopCh <-chan struct{}) error {
 func (t fakeApfFilter) Install(c *mux.PathRecorderMux) {
 }
 
+func (t fakeApfFilter) IsExempt(requestDigest utilflowcontrol.RequestDigest) bool {
+	return false
+}
+
 func newApfServerWithSingleRequest(t *testing.T, decision mockDecision) *httptest.Server {
 	onExecuteFunc := func() {
 		if decision == decisionCancelWait {
@@ -405,6 +409,10 @@ func (f *fakeWatchApfFilter) Run(stopCh <-chan struct{}) error {
 func (t *fakeWatchApfFilter) Install(c *mux.PathRecorderMux) {
 }
 
+func (f *fakeWatchApfFilter) IsExempt(requestDigest utilflowcontrol.RequestDigest) bool {
+	return false
+}
+
 func (f *fakeWatchApfFilter) wait() error {
 	return wait.Poll(100*time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
 		f.lock.Lock()
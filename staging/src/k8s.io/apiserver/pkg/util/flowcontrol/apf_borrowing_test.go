@@ -0,0 +1,45 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestBorrowingStatus(t *testing.T) {
+	cfgCtlr := &configController{
+		priorityLevelStates: map[string]*priorityLevelState{
+			"borrower": {nominalCL: 10, currentCL: 14},
+			"lender-b": {nominalCL: 20, currentCL: 18},
+			"lender-a": {nominalCL: 8, currentCL: 6},
+			"balanced": {nominalCL: 5, currentCL: 5},
+		},
+	}
+	borrowed, lenders := cfgCtlr.borrowingStatus("borrower")
+	if !borrowed {
+		t.Error("expected the level above its nominal limit to count as borrowing")
+	}
+	if got := fmt.Sprint(lenders); got != "[lender-a lender-b]" {
+		t.Errorf("expected the lending levels sorted by name, got %v", lenders)
+	}
+	for _, plName := range []string{"lender-a", "balanced", "absent"} {
+		if borrowed, lenders := cfgCtlr.borrowingStatus(plName); borrowed || lenders != nil {
+			t.Errorf("expected no borrowing for %q, got %v %v", plName, borrowed, lenders)
+		}
+	}
+}
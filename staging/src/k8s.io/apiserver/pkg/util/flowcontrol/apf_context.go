@@ -19,6 +19,9 @@ package flowcontrol
 import (
 	"context"
 	"sync"
+	"time"
+
+	"k8s.io/utils/clock"
 )
 
 type priorityAndFairnessKeyType int
@@ -28,6 +31,10 @@ const (
 	// initialization signal function for watch requests is stored
 	// in the context.
 	priorityAndFairnessInitializationSignalKey priorityAndFairnessKeyType = iota
+
+	// priorityAndFairnessBlockedTrackerKey is a key under which the
+	// blocked-time tracker for a request is stored in the context.
+	priorityAndFairnessBlockedTrackerKey
 )
 
 // WithInitializationSignal creates a copy of parent context with
@@ -91,3 +98,75 @@ func (i *initializationSignal) Signal() {
 func (i *initializationSignal) Wait() {
 	<-i.done
 }
+
+// blockedTracker accumulates intervals during which the handler of a
+// request reported being blocked on work outside the dispatcher's
+// concern, such as storage or other apiserver locks.
+type blockedTracker struct {
+	clock clock.PassiveClock
+
+	lock         sync.Mutex
+	blockedSince time.Time
+	total        time.Duration
+}
+
+// WithBlockedTracking creates a copy of parent context carrying a
+// blocked-time tracker reading the given clock, enabling MarkBlocked
+// and MarkUnblocked for the request and the subtraction of the
+// reported intervals from its recorded execution duration.
+func WithBlockedTracking(ctx context.Context, clk clock.PassiveClock) context.Context {
+	return context.WithValue(ctx, priorityAndFairnessBlockedTrackerKey, &blockedTracker{clock: clk})
+}
+
+// blockedTrackerFrom returns the blocked-time tracker of the request,
+// if any.
+func blockedTrackerFrom(ctx context.Context) (*blockedTracker, bool) {
+	tracker, ok := ctx.Value(priorityAndFairnessBlockedTrackerKey).(*blockedTracker)
+	return tracker, ok && tracker != nil
+}
+
+// MarkBlocked tells the priority and fairness dispatcher that the
+// handler of the given request is blocked on work that is not the
+// dispatcher's concern, e.g. a storage or apiserver-internal lock.
+// The interval until the matching MarkUnblocked is excluded from the
+// request's recorded execution duration.  No-op when the context
+// carries no tracker or the handler is already marked blocked.
+func MarkBlocked(ctx context.Context) {
+	tracker, ok := blockedTrackerFrom(ctx)
+	if !ok {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	if tracker.blockedSince.IsZero() {
+		tracker.blockedSince = tracker.clock.Now()
+	}
+}
+
+// MarkUnblocked tells the priority and fairness dispatcher that the
+// handler of the given request has resumed active work.  No-op when
+// the context carries no tracker or MarkBlocked was not called.
+func MarkUnblocked(ctx context.Context) {
+	tracker, ok := blockedTrackerFrom(ctx)
+	if !ok {
+		return
+	}
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	if !tracker.blockedSince.IsZero() {
+		tracker.total += tracker.clock.Since(tracker.blockedSince)
+		tracker.blockedSince = time.Time{}
+	}
+}
+
+// blockedDuration returns the total time reported blocked so far,
+// including any interval still open at the time of the call.
+func (t *blockedTracker) blockedDuration() time.Duration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	total := t.total
+	if !t.blockedSince.IsZero() {
+		total += t.clock.Since(t.blockedSince)
+	}
+	return total
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	testingclock "k8s.io/utils/clock/testing"
+)
+
+func TestBlockedTracking(t *testing.T) {
+	clk := testingclock.NewFakeClock(time.Now())
+	ctx := WithBlockedTracking(context.Background(), clk)
+	tracker, ok := blockedTrackerFrom(ctx)
+	if !ok {
+		t.Fatal("expected a tracker in the context")
+	}
+	if got := tracker.blockedDuration(); got != 0 {
+		t.Errorf("expected no blocked time initially, got %v", got)
+	}
+
+	MarkBlocked(ctx)
+	clk.Step(3 * time.Second)
+	// A second MarkBlocked while already blocked must not restart the
+	// interval.
+	MarkBlocked(ctx)
+	clk.Step(2 * time.Second)
+	MarkUnblocked(ctx)
+	if got, want := tracker.blockedDuration(), 5*time.Second; got != want {
+		t.Errorf("expected %v blocked, got %v", want, got)
+	}
+
+	// MarkUnblocked without a matching MarkBlocked accumulates nothing.
+	MarkUnblocked(ctx)
+	clk.Step(time.Second)
+	if got, want := tracker.blockedDuration(), 5*time.Second; got != want {
+		t.Errorf("expected %v blocked after spurious unblock, got %v", want, got)
+	}
+
+	// An interval still open counts up to the time of the reading.
+	MarkBlocked(ctx)
+	clk.Step(4 * time.Second)
+	if got, want := tracker.blockedDuration(), 9*time.Second; got != want {
+		t.Errorf("expected %v blocked with an open interval, got %v", want, got)
+	}
+}
+
+func TestMarkBlockedWithoutTracker(t *testing.T) {
+	// Both helpers must tolerate a context without a tracker.
+	MarkBlocked(context.Background())
+	MarkUnblocked(context.Background())
+}
@@ -26,6 +26,7 @@ import (
 	"math/rand"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
@@ -36,12 +37,16 @@ import (
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/uuid"
 	"k8s.io/apimachinery/pkg/util/wait"
 	fcboot "k8s.io/apiserver/pkg/apis/flowcontrol/bootstrap"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/util/apihelpers"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	"k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/eventclock"
+	fqs "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/queueset"
 	fcfmt "k8s.io/apiserver/pkg/util/flowcontrol/format"
 	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
 	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
@@ -137,6 +142,36 @@ type configController struct {
 	// objects need to be reprocessed.
 	configQueue workqueue.TypedRateLimitingInterface[int]
 
+	// configWorkerCount is the number of config worker goroutines
+	// that Run starts; see TestableConfig.ConfigWorkerCount.
+	configWorkerCount int
+
+	// digestionLagThreshold, if positive, is the pending time beyond
+	// which a config change's digestion counts as lagging; see
+	// TestableConfig.DigestionLagThreshold.
+	digestionLagThreshold time.Duration
+
+	// queueSelectionLogging selects logging of each request's queue
+	// assignment and maintenance of the per-queue request histogram;
+	// see TestableConfig.QueueSelectionLogging.
+	queueSelectionLogging bool
+
+	// configDebounceWindow, if positive, is how long a noticed config
+	// change is held back so that a burst of changes coalesces into
+	// one digestion; see TestableConfig.ConfigDebounceWindow.
+	configDebounceWindow time.Duration
+
+	// traceIDFunc generates trace ids for traced requests that did
+	// not supply one; see TestableConfig.TraceIDFunc.  Never nil.
+	traceIDFunc func() string
+
+	// oldestPendingConfigChange is the time, in nanoseconds since
+	// the Unix epoch, at which the oldest not-yet-digested config
+	// change was noticed, or zero when none is pending.  Armed by
+	// the informer event handlers and cleared by the worker that
+	// picks the change up.
+	oldestPendingConfigChange atomic.Int64
+
 	plLister         flowcontrollister.PriorityLevelConfigurationLister
 	plInformerSynced cache.InformerSynced
 
@@ -150,6 +185,144 @@ type configController struct {
 	// from server configuration.
 	serverConcurrencyLimit int
 
+	// requestWaitLimitByPL optionally bounds queue wait per priority
+	// level; see TestableConfig.RequestWaitLimitByPriorityLevel.
+	requestWaitLimitByPL map[string]time.Duration
+
+	// onReject, if non-nil, is called for every rejected request; see
+	// TestableConfig.OnReject.
+	onReject func(rd RequestDigest, reason string)
+
+	// onConfigApplied, if non-nil, is called after each config
+	// digestion with the resulting per-priority-level nominal seat
+	// allocation; see TestableConfig.OnConfigApplied.
+	onConfigApplied func(allocation map[string]int)
+
+	// maxExecutingByFS optionally caps concurrent executions per
+	// FlowSchema; see TestableConfig.MaxExecutingByFlowSchema.
+	// Immutable after construction.
+	maxExecutingByFS map[string]int
+
+	// absoluteConcurrency optionally pins the nominal concurrency of
+	// named priority levels to absolute seat counts; see
+	// TestableConfig.AbsoluteConcurrency.  Immutable after
+	// construction.
+	absoluteConcurrency map[string]int
+
+	// maxQueueLength optionally overrides the per-queue length limit
+	// of named priority levels; see TestableConfig.MaxQueueLength.
+	// Immutable after construction.
+	maxQueueLength map[string]int
+
+	// fsExecSemaphores holds the lazily created semaphores that
+	// enforce maxExecutingByFS, guarded by fsExecLock.
+	fsExecLock       sync.Mutex
+	fsExecSemaphores map[string]chan struct{}
+
+	// strictFIFOByFS selects FlowSchemas whose requests must begin
+	// execution in arrival order; see
+	// TestableConfig.StrictFIFOByFlowSchema.  Immutable after
+	// construction.
+	strictFIFOByFS map[string]bool
+
+	// fsFIFOGates holds the lazily created gates that enforce
+	// strictFIFOByFS, guarded by fifoLock.
+	fifoLock    sync.Mutex
+	fsFIFOGates map[string]*fsFIFOGate
+
+	// syntheticInjection selects whether the synthetic-request debug
+	// endpoint is served; see
+	// TestableConfig.EnableSyntheticInjection.
+	syntheticInjection bool
+
+	// syntheticSeq numbers synthetic requests so that successive ones
+	// hash to different flows.
+	syntheticSeq atomic.Uint64
+
+	// immediateDispatchEpsilon is the wait below which a queued
+	// request counts as immediately dispatched; see
+	// TestableConfig.ImmediateDispatchEpsilon.
+	immediateDispatchEpsilon time.Duration
+
+	// pprofLabeling selects whether execFn runs under pprof labels
+	// naming the priority level and flow schema; see
+	// TestableConfig.ExecutionPprofLabels.
+	pprofLabeling bool
+
+	// maxRequestDuration, if positive, bounds each request's total
+	// queue-plus-execution time in the filter; see
+	// TestableConfig.MaxRequestDuration.
+	maxRequestDuration time.Duration
+
+	// cpuAccounting selects measurement of CPU time consumed during
+	// execution; see TestableConfig.ExecutionCPUAccounting.
+	cpuAccounting bool
+
+	// suppressMetrics disables the per-request Prometheus metric
+	// observations in Handle; see TestableConfig.SuppressMetrics.
+	suppressMetrics bool
+
+	// runtimeStats, if non-nil, holds the periodically sampled Go
+	// runtime state attached to lifecycle events; see
+	// TestableConfig.RuntimeStatsAccounting.  Run starts the sampler.
+	runtimeStats *runtimeStats
+
+	// digestionPaused, while true, causes the worker to skip config
+	// digestion so that the last-applied configuration stays in
+	// effect; see the pause/resume debug endpoints.  Guarded by lock.
+	digestionPaused bool
+
+	// digestionDeferred records that at least one sync was skipped
+	// while digestion was paused, so one must be scheduled upon
+	// resuming.  Guarded by lock.
+	digestionDeferred bool
+
+	// forceRejectPLs names the priority levels whose requests are
+	// currently rejected on arrival through the reject_all debug
+	// endpoint, simulating those levels being completely unavailable.
+	// Guarded by lock.
+	forceRejectPLs map[string]bool
+
+	// counters accumulates per-flow counts that the counters debug
+	// endpoint can snapshot and reset atomically.  It has its own lock.
+	counters apfCounters
+
+	// queueDepths tracks the current and high-water number of
+	// requests waiting per priority level.  It has its own lock.
+	queueDepths queueDepthTracker
+
+	// queueSelections counts, per priority level, how many requests
+	// were assigned to each queue, for the queue_selections debug
+	// endpoint.  It has its own lock.
+	queueSelections queueSelectionTracker
+
+	// snapshots holds the named captures of the snapshot debug
+	// endpoint.  It has its own lock.
+	snapshots snapshotStore
+
+	// inflight tracks the currently executing requests for the
+	// inflight debug endpoint.  It has its own lock.
+	inflight inflightRegistry
+
+	// latencies retains a window of recent per-FlowSchema wait and
+	// execution durations for the latencies debug endpoint.  It has
+	// its own lock.
+	latencies apfLatencies
+
+	// events fans markers out to the clients of the events debug
+	// endpoint.  It has its own lock.
+	events eventBroadcaster
+
+	// eventsTracer is the catch-all tracer feeding events, registered
+	// by the first Install call and unregistered when Run returns, so
+	// that a stopped controller does not linger in the global tracer
+	// list.  Nil until Install runs.
+	eventsTracer *continuum.RequestTracer
+
+	// history retains the most recent lifecycle events for the
+	// trace_events debug endpoint.  It has its own lock.
+	history lifecycleHistory
+
 	// watchTracker implements the necessary WatchTracker interface.
 	WatchTracker
 
@@ -163,7 +336,8 @@ type configController struct {
 	// Consumer trims to keep only the last minute's worth of entries.
 	// The controller uses this to limit itself to at most six updates
 	// to a given FlowSchema in any minute.
-	// This may only be accessed from the one and only worker goroutine.
+	// This may only be accessed by the worker holding the config work
+	// item, which the work queue hands to at most one worker at a time.
 	mostRecentUpdates []updateAttempt
 
 	// This must be locked while accessing the later fields.
@@ -276,18 +450,65 @@ func (stats *seatDemandStats) update(obs fq.IntegratorResults) {
 // NewTestableController is extra flexible to facilitate testing
 func newTestableController(config TestableConfig) *configController {
 	cfgCtlr := &configController{
-		name:                   config.Name,
-		clock:                  config.Clock,
-		queueSetFactory:        config.QueueSetFactory,
-		reqsGaugeVec:           config.ReqsGaugeVec,
-		execSeatsGaugeVec:      config.ExecSeatsGaugeVec,
-		asFieldManager:         config.AsFieldManager,
-		foundToDangling:        config.FoundToDangling,
-		serverConcurrencyLimit: config.ServerConcurrencyLimit,
-		flowcontrolClient:      config.FlowcontrolClient,
-		priorityLevelStates:    make(map[string]*priorityLevelState),
-		WatchTracker:           NewWatchTracker(),
-		MaxSeatsTracker:        NewMaxSeatsTracker(),
+		name:                     config.Name,
+		clock:                    config.Clock,
+		queueSetFactory:          config.QueueSetFactory,
+		reqsGaugeVec:             config.ReqsGaugeVec,
+		execSeatsGaugeVec:        config.ExecSeatsGaugeVec,
+		asFieldManager:           config.AsFieldManager,
+		foundToDangling:          config.FoundToDangling,
+		serverConcurrencyLimit:   config.ServerConcurrencyLimit,
+		requestWaitLimitByPL:     config.RequestWaitLimitByPriorityLevel,
+		onReject:                 config.OnReject,
+		onConfigApplied:          config.OnConfigApplied,
+		maxExecutingByFS:         config.MaxExecutingByFlowSchema,
+		strictFIFOByFS:           config.StrictFIFOByFlowSchema,
+		syntheticInjection:       config.EnableSyntheticInjection,
+		immediateDispatchEpsilon: config.ImmediateDispatchEpsilon,
+		maxRequestDuration:       config.MaxRequestDuration,
+		pprofLabeling:            config.ExecutionPprofLabels,
+		configWorkerCount:        config.ConfigWorkerCount,
+		digestionLagThreshold:    config.DigestionLagThreshold,
+		configDebounceWindow:     config.ConfigDebounceWindow,
+		queueSelectionLogging:    config.QueueSelectionLogging,
+		traceIDFunc:              config.TraceIDFunc,
+		absoluteConcurrency:      config.AbsoluteConcurrency,
+		maxQueueLength:           config.MaxQueueLength,
+		cpuAccounting:            config.ExecutionCPUAccounting,
+		suppressMetrics:          config.SuppressMetrics,
+		flowcontrolClient:        config.FlowcontrolClient,
+		priorityLevelStates:      make(map[string]*priorityLevelState),
+		forceRejectPLs:           make(map[string]bool),
+		WatchTracker:             NewWatchTracker(),
+		MaxSeatsTracker:          NewMaxSeatsTracker(),
+	}
+	if config.HighResolutionTimingMetrics {
+		metrics.EnableHighResolutionTiming()
+	}
+	if config.PriorityLevelClasses != nil {
+		metrics.SetPriorityLevelClasses(config.PriorityLevelClasses)
+	}
+	if cfgCtlr.queueSetFactory == nil {
+		if ec, ok := config.Clock.(eventclock.Interface); ok {
+			cfgCtlr.queueSetFactory = fqs.NewQueueSetFactory(ec)
+		} else {
+			cfgCtlr.queueSetFactory = fqs.NewQueueSetFactory(eventclock.Real{})
+		}
+	}
+	if config.RuntimeStatsAccounting {
+		cfgCtlr.runtimeStats = &runtimeStats{}
+	}
+	if cfgCtlr.traceIDFunc == nil {
+		cfgCtlr.traceIDFunc = func() string { return string(uuid.NewUUID()) }
+	}
+	if len(config.AbsoluteConcurrency) > 0 {
+		absoluteSum := 0
+		for _, seats := range config.AbsoluteConcurrency {
+			absoluteSum += seats
+		}
+		if absoluteSum > config.ServerConcurrencyLimit {
+			panic(fmt.Sprintf("sum of AbsoluteConcurrency allocations (%d) exceeds ServerConcurrencyLimit (%d)", absoluteSum, config.ServerConcurrencyLimit))
+		}
 	}
 	klog.V(2).Infof("NewTestableController %q with serverConcurrencyLimit=%d, name=%s, asFieldManager=%q", cfgCtlr.name, cfgCtlr.serverConcurrencyLimit, cfgCtlr.name, cfgCtlr.asFieldManager)
 	// Start with longish delay because conflicts will be between
@@ -309,14 +530,14 @@ func newTestableController(config TestableConfig) *configController {
 		AddFunc: func(obj interface{}) {
 			pl := obj.(*flowcontrol.PriorityLevelConfiguration)
 			klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to creation of PLC %s", cfgCtlr.name, pl.Name)
-			cfgCtlr.configQueue.Add(0)
+			cfgCtlr.noteConfigChange()
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			newPL := newObj.(*flowcontrol.PriorityLevelConfiguration)
 			oldPL := oldObj.(*flowcontrol.PriorityLevelConfiguration)
 			if !apiequality.Semantic.DeepEqual(oldPL.Spec, newPL.Spec) {
 				klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to spec update of PLC %s", cfgCtlr.name, newPL.Name)
-				cfgCtlr.configQueue.Add(0)
+				cfgCtlr.noteConfigChange()
 			} else {
 				klog.V(7).Infof("No trigger API priority and fairness config reloading in %s due to spec non-change of PLC %s", cfgCtlr.name, newPL.Name)
 			}
@@ -324,14 +545,14 @@ func newTestableController(config TestableConfig) *configController {
 		DeleteFunc: func(obj interface{}) {
 			name, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 			klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to deletion of PLC %s", cfgCtlr.name, name)
-			cfgCtlr.configQueue.Add(0)
+			cfgCtlr.noteConfigChange()
 
 		}})
 	fsi.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			fs := obj.(*flowcontrol.FlowSchema)
 			klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to creation of FS %s", cfgCtlr.name, fs.Name)
-			cfgCtlr.configQueue.Add(0)
+			cfgCtlr.noteConfigChange()
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			newFS := newObj.(*flowcontrol.FlowSchema)
@@ -354,7 +575,7 @@ func newTestableController(config TestableConfig) *configController {
 			if !(apiequality.Semantic.DeepEqual(oldFS.Spec, newFS.Spec) &&
 				apiequality.Semantic.DeepEqual(oldFS.Status, newFS.Status)) {
 				klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to spec and/or status update of FS %s", cfgCtlr.name, newFS.Name)
-				cfgCtlr.configQueue.Add(0)
+				cfgCtlr.noteConfigChange()
 			} else {
 				klog.V(7).Infof("No trigger of API priority and fairness config reloading in %s due to spec and status non-change of FS %s", cfgCtlr.name, newFS.Name)
 			}
@@ -362,12 +583,28 @@ func newTestableController(config TestableConfig) *configController {
 		DeleteFunc: func(obj interface{}) {
 			name, _ := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
 			klog.V(7).Infof("Triggered API priority and fairness config reloading in %s due to deletion of FS %s", cfgCtlr.name, name)
-			cfgCtlr.configQueue.Add(0)
+			cfgCtlr.noteConfigChange()
 
 		}})
 	return cfgCtlr
 }
 
+// noteConfigChange records when a config change was first noticed,
+// for lag detection, and queues the one work item that triggers
+// redigestion.
+func (cfgCtlr *configController) noteConfigChange() {
+	cfgCtlr.oldestPendingConfigChange.CompareAndSwap(0, cfgCtlr.clock.Now().UnixNano())
+	if window := cfgCtlr.configDebounceWindow; window > 0 {
+		// The queue holds at most one instance of the item, and the
+		// delaying logic keeps the earliest ready time, so changes
+		// arriving while one is held back merge into the digestion
+		// already scheduled.
+		cfgCtlr.configQueue.AddAfter(0, window)
+		return
+	}
+	cfgCtlr.configQueue.Add(0)
+}
+
 func (cfgCtlr *configController) Run(stopCh <-chan struct{}) error {
 	defer utilruntime.HandleCrash()
 
@@ -379,17 +616,44 @@ func (cfgCtlr *configController) Run(stopCh <-chan struct{}) error {
 		return fmt.Errorf("Never achieved initial sync")
 	}
 
-	klog.Info("Running API Priority and Fairness config worker")
-	go wait.Until(cfgCtlr.runWorker, time.Second, stopCh)
+	workerCount := cfgCtlr.configWorkerCount
+	if workerCount < 1 {
+		workerCount = 1
+	}
+	klog.Infof("Running %d API Priority and Fairness config worker(s)", workerCount)
+	for i := 0; i < workerCount; i++ {
+		go wait.Until(cfgCtlr.runWorker, time.Second, stopCh)
+	}
 
 	klog.Info("Running API Priority and Fairness periodic rebalancing process")
 	go wait.Until(cfgCtlr.updateBorrowing, borrowingAdjustmentPeriod, stopCh)
 
+	if cfgCtlr.runtimeStats != nil {
+		go wait.Until(cfgCtlr.runtimeStats.sample, runtimeStatsSamplePeriod, stopCh)
+	}
+
 	<-stopCh
 	klog.Info("Shutting down API Priority and Fairness config worker")
+	if cfgCtlr.eventsTracer != nil {
+		continuum.UnregisterTracer(cfgCtlr.eventsTracer)
+	}
+	cfgCtlr.logCounterSummary()
 	return nil
 }
 
+// logCounterSummary logs the per-flow request counts accumulated since
+// startup (or the last reset through the counters debug endpoint), as
+// a one-glance end-of-run report that does not depend on a final
+// metrics scrape.
+func (cfgCtlr *configController) logCounterSummary() {
+	for _, e := range cfgCtlr.counters.snapshot(false) {
+		klog.InfoS("API Priority and Fairness request totals",
+			"flowSchema", e.FlowSchema, "priorityLevel", e.PriorityLevel,
+			"classified", e.Classified, "queued", e.Queued,
+			"dispatched", e.Dispatched, "rejected", e.Rejected)
+	}
+}
+
 func (cfgCtlr *configController) updateBorrowing() {
 	cfgCtlr.lock.Lock()
 	defer cfgCtlr.lock.Unlock()
@@ -432,7 +696,7 @@ func (cfgCtlr *configController) updateBorrowingLocked(setCompleters bool, plSta
 		plState := plStates[plName]
 		if setCompleters {
 			qsCompleter, err := queueSetCompleterForPL(cfgCtlr.queueSetFactory, plState.queues,
-				plState.pl, plState.reqsGaugePair, plState.execSeatsObs,
+				plState.pl, cfgCtlr.maxQueueLength, plState.reqsGaugePair, plState.execSeatsObs,
 				metrics.NewUnionGauge(plState.seatDemandIntegrator, plState.seatDemandRatioedGauge))
 			if err != nil {
 				klog.ErrorS(err, "Inconceivable!  Configuration error in existing priority level", "pl", plState.pl)
@@ -444,6 +708,7 @@ func (cfgCtlr *configController) updateBorrowingLocked(setCompleters bool, plSta
 		relChange := relDiff(float64(currentCL), float64(plState.currentCL))
 		plState.currentCL = currentCL
 		metrics.NotePriorityLevelConcurrencyAdjustment(plState.pl.Name, plState.seatDemandStats.highWatermark, plState.seatDemandStats.avg, plState.seatDemandStats.stdDev, plState.seatDemandStats.smoothed, float64(items[idx].target), currentCL)
+		metrics.SetPriorityLevelSeatBorrowing(plState.pl.Name, lentSeats(plState), borrowedSeats(plState))
 		logLevel := klog.Level(4)
 		if relChange >= 0.05 {
 			logLevel = 2
@@ -461,16 +726,19 @@ func (cfgCtlr *configController) updateBorrowingLocked(setCompleters bool, plSta
 	metrics.SetFairFrac(float64(fairFrac))
 }
 
-// runWorker is the logic of the one and only worker goroutine.  We
-// limit the number to one in order to obviate explicit
-// synchronization around access to `cfgCtlr.mostRecentUpdates`.
+// runWorker is the logic of one config worker goroutine.  There may
+// be several (see TestableConfig.ConfigWorkerCount), but the work
+// queue holds at most the one item and never hands an item to two
+// workers at once, so digestions never overlap and state accessed
+// only while holding the item, such as `cfgCtlr.mostRecentUpdates`,
+// needs no further synchronization.
 func (cfgCtlr *configController) runWorker() {
 	for cfgCtlr.processNextWorkItem() {
 	}
 }
 
 // processNextWorkItem works on one entry from the work queue.
-// Only invoke this in the one and only worker goroutine.
+// Only invoke this in a config worker goroutine.
 func (cfgCtlr *configController) processNextWorkItem() bool {
 	obj, shutdown := cfgCtlr.configQueue.Get()
 	if shutdown {
@@ -479,10 +747,23 @@ func (cfgCtlr *configController) processNextWorkItem() bool {
 
 	func(obj int) {
 		defer cfgCtlr.configQueue.Done(obj)
+		if cfgCtlr.noteDigestionSkippedIfPaused() {
+			// The change stays buffered in the informers' stores; a
+			// sync will be queued when digestion is resumed.  The
+			// pending timestamp stays armed, so the lag check below
+			// counts the paused interval.
+			cfgCtlr.configQueue.Forget(obj)
+			return
+		}
+		pendingSince := cfgCtlr.oldestPendingConfigChange.Swap(0)
+		cfgCtlr.noteDigestionLag(pendingSince)
 		specificDelay, err := cfgCtlr.syncOne()
 		switch {
 		case err != nil:
 			klog.Error(err)
+			// The change is still undigested; keep measuring its lag
+			// from the original notice across the retry.
+			cfgCtlr.oldestPendingConfigChange.CompareAndSwap(0, pendingSince)
 			cfgCtlr.configQueue.AddRateLimited(obj)
 		case specificDelay > 0:
 			cfgCtlr.configQueue.AddAfter(obj, specificDelay)
@@ -494,10 +775,47 @@ func (cfgCtlr *configController) processNextWorkItem() bool {
 	return true
 }
 
+// noteDigestionLag checks how long the config change picked up by a
+// worker had been pending, given the notice time in nanoseconds since
+// the Unix epoch (zero means no change was pending, e.g. a sync
+// queued on resuming digestion), and emits a marker if that exceeds
+// the configured threshold.
+func (cfgCtlr *configController) noteDigestionLag(pendingSince int64) {
+	if cfgCtlr.digestionLagThreshold <= 0 || pendingSince == 0 {
+		return
+	}
+	now := cfgCtlr.clock.Now()
+	lag := now.Sub(time.Unix(0, pendingSince))
+	if lag <= cfgCtlr.digestionLagThreshold {
+		return
+	}
+	klog.V(3).Infof("Config digestion in %s lagging: change pending for %s exceeds threshold %s", cfgCtlr.name, lag, cfgCtlr.digestionLagThreshold)
+	continuum.EmitMarker(continuum.Marker{ID: continuum.MarkerConfigDigestionLagging, Time: continuum.TimestampFrom(now)})
+}
+
+// isForceRejected reports whether the named priority level currently
+// has the reject-all flag set; see the reject_all debug endpoint.
+func (cfgCtlr *configController) isForceRejected(plName string) bool {
+	cfgCtlr.lock.RLock()
+	defer cfgCtlr.lock.RUnlock()
+	return cfgCtlr.forceRejectPLs[plName]
+}
+
+// noteDigestionSkippedIfPaused reports whether config digestion is
+// currently paused, and if so records that a sync was skipped.
+func (cfgCtlr *configController) noteDigestionSkippedIfPaused() bool {
+	cfgCtlr.lock.Lock()
+	defer cfgCtlr.lock.Unlock()
+	if cfgCtlr.digestionPaused {
+		cfgCtlr.digestionDeferred = true
+	}
+	return cfgCtlr.digestionPaused
+}
+
 // syncOne does one full synchronization.  It reads all the API
 // objects that configure API Priority and Fairness and updates the
 // local configController accordingly.
-// Only invoke this in the one and only worker goroutine
+// Only invoke this in the config worker holding the work item
 func (cfgCtlr *configController) syncOne() (specificDelay time.Duration, err error) {
 	klog.V(5).Infof("%s syncOne at %s", cfgCtlr.name, cfgCtlr.clock.Now().Format(timeFmt))
 	all := labels.Everything()
@@ -551,7 +869,7 @@ type fsStatusUpdate struct {
 
 // digestConfigObjects is given all the API objects that configure
 // cfgCtlr and writes its consequent new configState.
-// Only invoke this in the one and only worker goroutine
+// Only invoke this in the config worker holding the work item
 func (cfgCtlr *configController) digestConfigObjects(newPLs []*flowcontrol.PriorityLevelConfiguration, newFSs []*flowcontrol.FlowSchema) (time.Duration, error) {
 	fsStatusUpdates := cfgCtlr.lockAndDigestConfigObjects(newPLs, newFSs)
 	var errs []error
@@ -615,7 +933,7 @@ func toFlowSchemaApplyConfiguration(fsUpdate fsStatusUpdate) *flowcontrolapplyco
 }
 
 // shouldDelayUpdate checks to see if a flowschema has been updated too often and returns true if a delay is needed.
-// Only invoke this in the one and only worker goroutine
+// Only invoke this in the config worker holding the work item
 func (cfgCtlr *configController) shouldDelayUpdate(flowSchemaName string) bool {
 	numUpdatesInPastMinute := 0
 	oneMinuteAgo := cfgCtlr.clock.Now().Add(-1 * time.Minute)
@@ -637,7 +955,7 @@ func (cfgCtlr *configController) shouldDelayUpdate(flowSchemaName string) bool {
 
 // addUpdateResult adds the result. It isn't a ring buffer because
 // this is small and rate limited.
-// Only invoke this in the one and only worker goroutine
+// Only invoke this in the config worker holding the work item
 func (cfgCtlr *configController) addUpdateResult(result updateAttempt) {
 	cfgCtlr.mostRecentUpdates = append([]updateAttempt{result}, cfgCtlr.mostRecentUpdates...)
 }
@@ -673,6 +991,14 @@ func (cfgCtlr *configController) lockAndDigestConfigObjects(newPLs []*flowcontro
 	metrics.GetExecutingReadonlyConcurrency().SetDenominator(float64(meal.maxExecutingRequests))
 	metrics.GetExecutingMutatingConcurrency().SetDenominator(float64(meal.maxExecutingRequests))
 
+	if cfgCtlr.onConfigApplied != nil {
+		allocation := make(map[string]int, len(meal.newPLStates))
+		for plName, plState := range meal.newPLStates {
+			allocation[plName] = plState.nominalCL
+		}
+		cfgCtlr.onConfigApplied(allocation)
+	}
+
 	return meal.fsStatusUpdates
 }
 
@@ -691,7 +1017,7 @@ func (meal *cfgMeal) digestNewPLsLocked(newPLs []*flowcontrol.PriorityLevelConfi
 			}
 		}
 		qsCompleter, err := queueSetCompleterForPL(meal.cfgCtlr.queueSetFactory, state.queues,
-			pl, state.reqsGaugePair, state.execSeatsObs,
+			pl, meal.cfgCtlr.maxQueueLength, state.reqsGaugePair, state.execSeatsObs,
 			metrics.NewUnionGauge(state.seatDemandIntegrator, state.seatDemandRatioedGauge))
 		if err != nil {
 			klog.Warningf("Ignoring PriorityLevelConfiguration object %s because its spec (%s) is broken: %s", pl.Name, fcfmt.Fmt(pl.Spec), err)
@@ -797,7 +1123,7 @@ func (meal *cfgMeal) processOldPLsLocked() {
 		}
 		var err error
 		plState.qsCompleter, err = queueSetCompleterForPL(meal.cfgCtlr.queueSetFactory, plState.queues,
-			plState.pl, plState.reqsGaugePair, plState.execSeatsObs,
+			plState.pl, meal.cfgCtlr.maxQueueLength, plState.reqsGaugePair, plState.execSeatsObs,
 			metrics.NewUnionGauge(plState.seatDemandIntegrator, plState.seatDemandRatioedGauge))
 		if err != nil {
 			// This can not happen because queueSetCompleterForPL already approved this config
@@ -821,12 +1147,29 @@ func (meal *cfgMeal) processOldPLsLocked() {
 // server's total concurrency limit among them and create/update their
 // QueueSets.
 func (meal *cfgMeal) finishQueueSetReconfigsLocked() {
+	// Priority levels pinned to an absolute seat count are taken off
+	// the top; the remaining levels divide what is left among
+	// themselves in proportion to their shares.
+	availableConcurrency := float64(meal.cfgCtlr.serverConcurrencyLimit)
+	shareSum := meal.shareSum
+	for plName, plState := range meal.newPLStates {
+		if seats, ok := meal.cfgCtlr.absoluteConcurrency[plName]; ok && seats > 0 {
+			availableConcurrency -= float64(seats)
+			nominalConcurrencyShares, _, _ := plSpecCommons(plState.pl)
+			shareSum -= float64(*nominalConcurrencyShares)
+		}
+	}
 	for plName, plState := range meal.newPLStates {
 		nominalConcurrencyShares, lendablePercent, borrowingLimitPercent := plSpecCommons(plState.pl)
-		// The use of math.Ceil here means that the results might sum
-		// to a little more than serverConcurrencyLimit but the
-		// difference will be negligible.
-		concurrencyLimit := int(math.Ceil(float64(meal.cfgCtlr.serverConcurrencyLimit) * float64(*nominalConcurrencyShares) / meal.shareSum))
+		var concurrencyLimit int
+		if seats, ok := meal.cfgCtlr.absoluteConcurrency[plName]; ok && seats > 0 {
+			concurrencyLimit = seats
+		} else {
+			// The use of math.Ceil here means that the results might sum
+			// to a little more than serverConcurrencyLimit but the
+			// difference will be negligible.
+			concurrencyLimit = int(math.Ceil(availableConcurrency * float64(*nominalConcurrencyShares) / shareSum))
+		}
 		var lendableCL, borrowingCL int
 		if lendablePercent != nil {
 			lendableCL = int(math.Round(float64(concurrencyLimit) * float64(*lendablePercent) / 100))
@@ -877,9 +1220,11 @@ func (meal *cfgMeal) finishQueueSetReconfigsLocked() {
 }
 
 // queueSetCompleterForPL returns an appropriate QueueSetCompleter for the
-// given priority level configuration.  Returns nil and an error if the given
-// object is malformed in a way that is a problem for this package.
-func queueSetCompleterForPL(qsf fq.QueueSetFactory, queues fq.QueueSet, pl *flowcontrol.PriorityLevelConfiguration, reqsIntPair metrics.RatioedGaugePair, execSeatsObs metrics.RatioedGauge, seatDemandGauge metrics.Gauge) (fq.QueueSetCompleter, error) {
+// given priority level configuration.  A positive entry for the level in
+// maxQueueLength overrides the queue length limit its API object requests.
+// Returns nil and an error if the given object is malformed in a way that is
+// a problem for this package.
+func queueSetCompleterForPL(qsf fq.QueueSetFactory, queues fq.QueueSet, pl *flowcontrol.PriorityLevelConfiguration, maxQueueLength map[string]int, reqsIntPair metrics.RatioedGaugePair, execSeatsObs metrics.RatioedGauge, seatDemandGauge metrics.Gauge) (fq.QueueSetCompleter, error) {
 	if (pl.Spec.Type == flowcontrol.PriorityLevelEnablementLimited) != (pl.Spec.Limited != nil) {
 		return nil, errors.New("broken union structure at the top, for Limited")
 	}
@@ -906,6 +1251,9 @@ func queueSetCompleterForPL(qsf fq.QueueSetFactory, queues fq.QueueSet, pl *flow
 	} else {
 		qcQS = fq.QueuingConfig{Name: pl.Name, DesiredNumQueues: -1}
 	}
+	if limit, ok := maxQueueLength[pl.Name]; ok && limit > 0 && qcQS.DesiredNumQueues > 0 {
+		qcQS.QueueLengthLimit = limit
+	}
 	var qsc fq.QueueSetCompleter
 	var err error
 	if queues != nil {
@@ -940,6 +1288,14 @@ func (meal *cfgMeal) presyncFlowSchemaStatus(fs *flowcontrol.FlowSchema, isDangl
 		return
 	}
 	now := meal.cfgCtlr.clock.Now()
+	if danglingCondition.Status != desiredStatus {
+		continuum.EmitDanglingStatusChange(continuum.DanglingStatusChange{
+			FlowSchema: fs.Name,
+			Time:       continuum.TimestampFrom(now),
+			Old:        string(danglingCondition.Status),
+			New:        string(desiredStatus),
+		})
+	}
 	meal.fsStatusUpdates = append(meal.fsStatusUpdates, fsStatusUpdate{
 		flowSchema: fs,
 		condition: flowcontrol.FlowSchemaCondition{
@@ -961,7 +1317,7 @@ func (meal *cfgMeal) imaginePL(proto *flowcontrol.PriorityLevelConfiguration) {
 	execSeatsObs := meal.cfgCtlr.execSeatsGaugeVec.NewForLabelValuesSafe(0, 1, labelValues)
 	seatDemandIntegrator := fq.NewNamedIntegrator(meal.cfgCtlr.clock, proto.Name)
 	seatDemandRatioedGauge := metrics.ApiserverSeatDemands.NewForLabelValuesSafe(0, 1, []string{proto.Name})
-	qsCompleter, err := queueSetCompleterForPL(meal.cfgCtlr.queueSetFactory, nil, proto, reqsGaugePair,
+	qsCompleter, err := queueSetCompleterForPL(meal.cfgCtlr.queueSetFactory, nil, proto, meal.cfgCtlr.maxQueueLength, reqsGaugePair,
 		execSeatsObs, metrics.NewUnionGauge(seatDemandIntegrator, seatDemandRatioedGauge))
 	if err != nil {
 		// This can not happen because proto is one of the mandatory
@@ -988,36 +1344,13 @@ func (meal *cfgMeal) imaginePL(proto *flowcontrol.PriorityLevelConfiguration) {
 func (cfgCtlr *configController) startRequest(ctx context.Context, rd RequestDigest,
 	noteFn func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string),
 	workEstimator func() fcrequest.WorkEstimate,
-	queueNoteFn fq.QueueNoteFn) (fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, isExempt bool, req fq.Request, startWaitingTime time.Time) {
+	queueNoteFn fq.QueueNoteFn) (fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, isExempt bool, req fq.Request, startWaitingTime time.Time, cleanup context.CancelFunc) {
 	klog.V(7).Infof("startRequest(%#+v)", rd)
 	cfgCtlr.lock.RLock()
 	defer cfgCtlr.lock.RUnlock()
-	var selectedFlowSchema, catchAllFlowSchema *flowcontrol.FlowSchema
-	for _, fs := range cfgCtlr.flowSchemas {
-		if matchesFlowSchema(rd, fs) {
-			selectedFlowSchema = fs
-			break
-		}
-		if fs.Name == flowcontrol.FlowSchemaNameCatchAll {
-			catchAllFlowSchema = fs
-		}
-	}
-	if selectedFlowSchema == nil {
-		// This should never happen. If the requestDigest's User is a part of
-		// system:authenticated or system:unauthenticated, the catch-all flow
-		// schema should match it. However, if that invariant somehow fails,
-		// fallback to the catch-all flow schema anyway.
-		if catchAllFlowSchema == nil {
-			// This should absolutely never, ever happen! APF guarantees two
-			// undeletable flow schemas at all times: an exempt flow schema and a
-			// catch-all flow schema.
-			panic(fmt.Sprintf("no fallback catch-all flow schema found for request %#+v and user %#+v", rd.RequestInfo, rd.User))
-		}
-		selectedFlowSchema = catchAllFlowSchema
-		klog.Warningf("no match found for request %#+v and user %#+v; selecting catchAll=%s as fallback flow schema", rd.RequestInfo, rd.User, fcfmt.Fmt(selectedFlowSchema))
-	}
+	classifyStart := cfgCtlr.clock.Now()
+	selectedFlowSchema, plState := cfgCtlr.classifyReadLocked(rd)
 	plName := selectedFlowSchema.Spec.PriorityLevelConfiguration.Name
-	plState := cfgCtlr.priorityLevelStates[plName]
 	var numQueues int32
 	var hashValue uint64
 	var flowDistinguisher string
@@ -1030,19 +1363,74 @@ func (cfgCtlr *configController) startRequest(ctx context.Context, rd RequestDig
 			hashValue = hashFlowID(selectedFlowSchema.Name, flowDistinguisher)
 		}
 	}
+	metrics.ObserveClassificationDuration(ctx, plName, selectedFlowSchema.Name, cfgCtlr.clock.Since(classifyStart))
 
 	noteFn(selectedFlowSchema, plState.pl, flowDistinguisher)
+	if cfgCtlr.forceRejectPLs[plName] {
+		// The reject-all flag simulates the priority level being
+		// completely unavailable, so the request is rejected on
+		// arrival without estimating its work or queuing it.
+		klog.V(7).Infof("startRequest(%#+v) => fsName=%q, plName=%q, force-rejected", rd, selectedFlowSchema.Name, plName)
+		return selectedFlowSchema, plState.pl, plState.pl.Spec.Type == flowcontrol.PriorityLevelEnablementExempt, nil, time.Time{}, nil
+	}
 	workEstimate := workEstimator()
 
 	if plState.pl.Spec.Type != flowcontrol.PriorityLevelEnablementExempt {
 		startWaitingTime = cfgCtlr.clock.Now()
 	}
 	klog.V(7).Infof("startRequest(%#+v) => fsName=%q, distMethod=%#+v, plName=%q, numQueues=%d", rd, selectedFlowSchema.Name, selectedFlowSchema.Spec.DistinguisherMethod, plName, numQueues)
+	if waitLimit := cfgCtlr.requestWaitLimitByPL[plName]; waitLimit > 0 && plState.pl.Spec.Type != flowcontrol.PriorityLevelEnablementExempt {
+		// Bound the queue wait with a derived context.  The deadline
+		// only matters while the request waits for its decision; once
+		// dispatched the queuing logic no longer watches this context.
+		// The caller invokes cleanup after the request is finished.
+		ctx, cleanup = context.WithTimeout(ctx, waitLimit)
+	}
 	req, idle := plState.queues.StartRequest(ctx, &workEstimate, hashValue, flowDistinguisher, selectedFlowSchema.Name, rd.RequestInfo, rd.User, queueNoteFn)
 	if idle {
 		cfgCtlr.maybeReapReadLocked(plName, plState)
 	}
-	return selectedFlowSchema, plState.pl, plState.pl.Spec.Type == flowcontrol.PriorityLevelEnablementExempt, req, startWaitingTime
+	return selectedFlowSchema, plState.pl, plState.pl.Spec.Type == flowcontrol.PriorityLevelEnablementExempt, req, startWaitingTime, cleanup
+}
+
+// classifyReadLocked returns the FlowSchema matching the given request
+// digest and the state of the priority level the schema references,
+// falling back to the catch-all schema if nothing else matches.
+func (cfgCtlr *configController) classifyReadLocked(rd RequestDigest) (*flowcontrol.FlowSchema, *priorityLevelState) {
+	var selectedFlowSchema, catchAllFlowSchema *flowcontrol.FlowSchema
+	for _, fs := range cfgCtlr.flowSchemas {
+		if matchesFlowSchema(rd, fs) {
+			selectedFlowSchema = fs
+			break
+		}
+		if fs.Name == flowcontrol.FlowSchemaNameCatchAll {
+			catchAllFlowSchema = fs
+		}
+	}
+	if selectedFlowSchema == nil {
+		// This should never happen. If the requestDigest's User is a part of
+		// system:authenticated or system:unauthenticated, the catch-all flow
+		// schema should match it. However, if that invariant somehow fails,
+		// fallback to the catch-all flow schema anyway.
+		if catchAllFlowSchema == nil {
+			// This should absolutely never, ever happen! APF guarantees two
+			// undeletable flow schemas at all times: an exempt flow schema and a
+			// catch-all flow schema.
+			panic(fmt.Sprintf("no fallback catch-all flow schema found for request %#+v and user %#+v", rd.RequestInfo, rd.User))
+		}
+		selectedFlowSchema = catchAllFlowSchema
+		klog.Warningf("no match found for request %#+v and user %#+v; selecting catchAll=%s as fallback flow schema", rd.RequestInfo, rd.User, fcfmt.Fmt(selectedFlowSchema))
+	}
+	return selectedFlowSchema, cfgCtlr.priorityLevelStates[selectedFlowSchema.Spec.PriorityLevelConfiguration.Name]
+}
+
+// IsExempt classifies the given request digest and reports whether it
+// lands in an exempt priority level.
+func (cfgCtlr *configController) IsExempt(rd RequestDigest) bool {
+	cfgCtlr.lock.RLock()
+	defer cfgCtlr.lock.RUnlock()
+	_, plState := cfgCtlr.classifyReadLocked(rd)
+	return plState.pl.Spec.Type == flowcontrol.PriorityLevelEnablementExempt
 }
 
 // maybeReap will remove the last internal traces of the named
@@ -1062,7 +1450,7 @@ func (cfgCtlr *configController) maybeReap(plName string) {
 		return
 	}
 	klog.V(3).Infof("Triggered API priority and fairness config reloading because priority level %s is undesired and idle", plName)
-	cfgCtlr.configQueue.Add(0)
+	cfgCtlr.noteConfigChange()
 }
 
 // maybeReapLocked requires the cfgCtlr's lock to already be held and
@@ -1075,7 +1463,7 @@ func (cfgCtlr *configController) maybeReapReadLocked(plName string, plState *pri
 		return
 	}
 	klog.V(3).Infof("Triggered API priority and fairness config reloading because priority level %s is undesired and idle", plName)
-	cfgCtlr.configQueue.Add(0)
+	cfgCtlr.noteConfigChange()
 }
 
 // computeFlowDistinguisher extracts the flow distinguisher according to the given method
@@ -1105,6 +1493,53 @@ func hashFlowID(fsName, fDistinguisher string) uint64 {
 	return binary.LittleEndian.Uint64(sum[:8])
 }
 
+// lentSeats returns how many of the priority level's nominal seats are
+// currently allocated to other levels, zero if it is not lending.
+func lentSeats(plState *priorityLevelState) int {
+	if d := plState.nominalCL - plState.currentCL; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// borrowedSeats returns how many seats beyond its nominal limit the
+// priority level is currently allowed to use, zero if it is not borrowing.
+func borrowedSeats(plState *priorityLevelState) int {
+	if d := plState.currentCL - plState.nominalCL; d > 0 {
+		return d
+	}
+	return 0
+}
+
+// borrowingStatus reports whether the named priority level is
+// currently allowed to use seats beyond its nominal limit, and if so
+// which levels are lending seats, sorted by name.  It describes the
+// level's seat allocation at the moment of the call, not where any
+// particular request's seats came from; the borrowing controller
+// adjusts allocations periodically, not per request.
+func (cfgCtlr *configController) borrowingStatus(plName string) (bool, []string) {
+	cfgCtlr.lock.RLock()
+	defer cfgCtlr.lock.RUnlock()
+	plState := cfgCtlr.priorityLevelStates[plName]
+	if plState == nil || borrowedSeats(plState) == 0 {
+		return false, nil
+	}
+	var lenders []string
+	for otherName, otherState := range cfgCtlr.priorityLevelStates {
+		if lentSeats(otherState) > 0 {
+			lenders = append(lenders, otherName)
+		}
+	}
+	sort.Strings(lenders)
+	return true, lenders
+}
+
+// Clock returns the clock this controller reads, satisfying
+// TestableInterface.
+func (cfgCtlr *configController) Clock() clock.PassiveClock {
+	return cfgCtlr.clock
+}
+
 func relDiff(x, y float64) float64 {
 	diff := math.Abs(x - y)
 	den := math.Max(math.Abs(x), math.Abs(y))
@@ -17,6 +17,7 @@ limitations under the License.
 package flowcontrol
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -29,7 +30,11 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+	"k8s.io/klog/v2"
 )
 
 const (
@@ -45,6 +50,381 @@ func (cfgCtlr *configController) Install(c *mux.PathRecorderMux) {
 	c.UnlistedHandleFunc("/debug/api_priority_and_fairness/dump_queues", cfgCtlr.dumpQueues)
 	// 3. row per request
 	c.UnlistedHandleFunc("/debug/api_priority_and_fairness/dump_requests", cfgCtlr.dumpRequests)
+	// JSON dump of the digested FlowSchemas, which may differ from the
+	// API objects while a config change is propagating
+	c.UnlistedHandleFunc("/debug/flowcontrol/flowschemas", cfgCtlr.dumpFlowSchemas)
+	// JSON dump of per-priority-level concurrency lending and borrowing
+	c.UnlistedHandleFunc("/debug/flowcontrol/borrowing", cfgCtlr.dumpBorrowing)
+	// freeze/unfreeze config digestion, for deterministic experiments
+	c.UnlistedHandleFunc("/debug/flowcontrol/pause_digestion", cfgCtlr.pauseDigestion)
+	c.UnlistedHandleFunc("/debug/flowcontrol/resume_digestion", cfgCtlr.resumeDigestion)
+	// JSON dump of the accumulated per-flow counters, optionally
+	// resetting them in the same critical section
+	c.UnlistedHandleFunc("/debug/flowcontrol/counters", cfgCtlr.dumpCounters)
+	// JSON dump of the current and high-water queue depth per
+	// priority level; the high-water marks reset together with the
+	// counters
+	c.UnlistedHandleFunc("/debug/flowcontrol/queue_depths", cfgCtlr.dumpQueueDepths)
+	// JSON dump of percentiles over recent per-FlowSchema wait and
+	// execution durations
+	c.UnlistedHandleFunc("/debug/flowcontrol/latencies", cfgCtlr.dumpLatencies)
+	// JSON dump of recent lifecycle events with the trace rules that
+	// matched them, optionally filtered to one named rule
+	c.UnlistedHandleFunc("/debug/flowcontrol/trace_events", cfgCtlr.dumpTraceEvents)
+	// report (GET) or set (POST) the run phase that gates
+	// phase-constrained trace rules
+	c.UnlistedHandleFunc("/debug/flowcontrol/run_phase", cfgCtlr.handleRunPhase)
+	// toggle rejection of all requests of a priority level, for
+	// fault-injection experiments
+	c.UnlistedHandleFunc("/debug/flowcontrol/reject_all", cfgCtlr.handleRejectAll)
+	// named point-in-time snapshots of counters and seats, with
+	// diffing against an earlier snapshot
+	c.UnlistedHandleFunc("/debug/flowcontrol/snapshot", cfgCtlr.dumpSnapshot)
+	// JSON dump of the requests currently executing, i.e. what is
+	// occupying seats right now
+	c.UnlistedHandleFunc("/debug/flowcontrol/inflight", cfgCtlr.dumpInflight)
+	// Server-Sent Events stream of the markers of every request, fed
+	// by a catch-all tracer; registration happens here because Install
+	// runs before the filter serves traffic.  A repeated Install must
+	// not register a second copy, and Run unregisters the tracer when
+	// the controller stops.
+	if cfgCtlr.eventsTracer == nil {
+		cfgCtlr.eventsTracer = &continuum.RequestTracer{
+			Name:  "debug-events",
+			Rules: []continuum.Rule{{Name: "all", BaseMarker: continuum.MarkerRequestEnter}},
+			Sink:  &cfgCtlr.events,
+		}
+		continuum.RegisterTracer(cfgCtlr.eventsTracer)
+	}
+	c.UnlistedHandleFunc("/debug/flowcontrol/events", cfgCtlr.streamEvents)
+	if cfgCtlr.queueSelectionLogging {
+		// JSON dump of how many requests each queue of each priority
+		// level received; see TestableConfig.QueueSelectionLogging
+		c.UnlistedHandleFunc("/debug/flowcontrol/queue_selections", cfgCtlr.dumpQueueSelections)
+	}
+	if cfgCtlr.syntheticInjection {
+		// fill a priority level with fake load; see InjectSynthetic
+		c.UnlistedHandleFunc("/debug/flowcontrol/inject_synthetic", cfgCtlr.injectSynthetic)
+	}
+}
+
+// injectSynthetic enqueues one synthetic request per POST, described
+// by the query parameters: `priorityLevel` and `flowSchema` name the
+// target, `seats` gives the initial seats (default 1), and `hold`
+// gives how long the seats stay occupied, as a Go duration (default
+// 1s).  The request is injected asynchronously; a later rejection or
+// cancellation only shows up in the log.
+func (cfgCtlr *configController) injectSynthetic(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	plName := r.URL.Query().Get("priorityLevel")
+	fsName := r.URL.Query().Get("flowSchema")
+	if plName == "" || fsName == "" {
+		http.Error(w, "priorityLevel and flowSchema are required", http.StatusBadRequest)
+		return
+	}
+	seats := uint64(1)
+	if s := r.URL.Query().Get("seats"); s != "" {
+		var err error
+		if seats, err = strconv.ParseUint(s, 10, 64); err != nil || seats < 1 {
+			http.Error(w, fmt.Sprintf("bad seats %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+	hold := time.Second
+	if s := r.URL.Query().Get("hold"); s != "" {
+		var err error
+		if hold, err = time.ParseDuration(s); err != nil {
+			http.Error(w, fmt.Sprintf("bad hold %q", s), http.StatusBadRequest)
+			return
+		}
+	}
+	cfgCtlr.lock.RLock()
+	known := cfgCtlr.priorityLevelStates[plName] != nil
+	cfgCtlr.lock.RUnlock()
+	if !known {
+		http.Error(w, fmt.Sprintf("no priority level named %q", plName), http.StatusNotFound)
+		return
+	}
+	go func() {
+		if err := cfgCtlr.InjectSynthetic(plName, fsName, fcrequest.WorkEstimate{InitialSeats: seats}, hold); err != nil {
+			klog.Errorf("synthetic request failed: %v", err)
+		}
+	}()
+	fmt.Fprintln(w, "synthetic request injected")
+}
+
+// dumpCounters responds with the per-flow counts accumulated since
+// startup or the last reset.  With `?reset=true` the counters are
+// also cleared, atomically with the snapshot, so that each experiment
+// starts from zero without restarting the server.
+func (cfgCtlr *configController) dumpCounters(w http.ResponseWriter, r *http.Request) {
+	reset, _ := strconv.ParseBool(r.URL.Query().Get("reset"))
+	entries := cfgCtlr.counters.snapshot(reset)
+	if reset {
+		// The per-experiment reset also starts a fresh queue-depth
+		// high-water mark, pulling the gauge back to what is waiting
+		// right now.
+		for _, depth := range cfgCtlr.queueDepths.snapshot(true) {
+			if !cfgCtlr.suppressMetrics {
+				metrics.SetInqueueRequestsHighWatermark(r.Context(), depth.PriorityLevel, depth.Waiting)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpQueueDepths responds with the current and high-water number of
+// waiting requests per priority level.
+func (cfgCtlr *configController) dumpQueueDepths(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.queueDepths.snapshot(false)
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpQueueSelections responds with the per-priority-level histogram
+// of requests per queue, accumulated while queue selection logging is
+// enabled.
+func (cfgCtlr *configController) dumpQueueSelections(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.queueSelections.snapshot()
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpLatencies responds with p50/p90/p99 wait and execution times
+// per FlowSchema, computed over a bounded window of the most recent
+// samples.  Unlike the registered histograms this needs no scrape and
+// no bucket interpolation, at the price of covering only the window.
+func (cfgCtlr *configController) dumpLatencies(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.latencies.snapshot()
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpTraceEvents responds with the retained lifecycle events, oldest
+// first, each annotated with the trace rules that matched the request
+// as "tracer/rule" (or "none").  With `?rule=<tracer>/<rule>` only the
+// events matched by that rule are listed, which separates the effects
+// of overlapping rules.
+func (cfgCtlr *configController) dumpTraceEvents(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.history.snapshot(r.URL.Query().Get("rule"))
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpInflight responds with the requests currently executing, per
+// priority level and oldest first, each with its execution start
+// timestamp.  A handler stuck long enough to block a flow shows up
+// at the top of its priority level.
+func (cfgCtlr *configController) dumpInflight(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.inflight.snapshot()
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// dumpSnapshot captures the current counters and per-priority-level
+// seat accounting.  With `?name=<name>` the capture is also stored
+// under that name, up to a bounded number of names; with
+// `?since=<name>` the response is the difference between the capture
+// and the stored snapshot of that name instead of the capture itself.
+// Capturing before a phase under one name and diffing after it makes
+// per-phase analysis need no external bookkeeping.
+func (cfgCtlr *configController) dumpSnapshot(w http.ResponseWriter, r *http.Request) {
+	cur := flowSnapshot{
+		Time:     cfgCtlr.clock.Now().UnixNano(),
+		Counters: cfgCtlr.counters.snapshot(false),
+		Seats:    cfgCtlr.borrowingEntries(),
+	}
+	if name := r.URL.Query().Get("name"); name != "" {
+		if err := cfgCtlr.snapshots.put(name, cur); err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+	}
+	out := cur
+	if since := r.URL.Query().Get("since"); since != "" {
+		old, ok := cfgCtlr.snapshots.get(since)
+		if !ok {
+			http.Error(w, fmt.Sprintf("no snapshot named %q", since), http.StatusNotFound)
+			return
+		}
+		out = diffSnapshots(cur, old)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(out))
+}
+
+// handleRunPhase reports or changes the run phase that gates
+// phase-constrained trace rules: GET returns the current phase, POST
+// with a "phase" parameter sets it, and POST without one clears it.
+func (cfgCtlr *configController) handleRunPhase(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		fmt.Fprintln(w, continuum.RunPhase())
+	case http.MethodPost:
+		phase := r.URL.Query().Get("phase")
+		continuum.SetRunPhase(phase)
+		fmt.Fprintf(w, "run phase set to %q\n", phase)
+	default:
+		http.Error(w, "only GET and POST are allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleRejectAll reports or changes which priority levels reject all
+// their requests on arrival: GET returns the names of the flagged
+// levels as a JSON list, POST with "priorityLevel" and "enabled"
+// parameters sets or clears the flag for one level.  While the flag is
+// set, startRequest rejects matching requests immediately with reason
+// "reject-all", without queuing, simulating the level being completely
+// unavailable.
+func (cfgCtlr *configController) handleRejectAll(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		cfgCtlr.lock.RLock()
+		names := make([]string, 0, len(cfgCtlr.forceRejectPLs))
+		for name := range cfgCtlr.forceRejectPLs {
+			names = append(names, name)
+		}
+		cfgCtlr.lock.RUnlock()
+		sort.Strings(names)
+		w.Header().Set("Content-Type", "application/json")
+		runtime.HandleError(json.NewEncoder(w).Encode(names))
+	case http.MethodPost:
+		plName := r.URL.Query().Get("priorityLevel")
+		if plName == "" {
+			http.Error(w, "priorityLevel is required", http.StatusBadRequest)
+			return
+		}
+		enabled := true
+		if s := r.URL.Query().Get("enabled"); s != "" {
+			var err error
+			if enabled, err = strconv.ParseBool(s); err != nil {
+				http.Error(w, fmt.Sprintf("bad enabled %q", s), http.StatusBadRequest)
+				return
+			}
+		}
+		cfgCtlr.lock.Lock()
+		if enabled {
+			cfgCtlr.forceRejectPLs[plName] = true
+		} else {
+			delete(cfgCtlr.forceRejectPLs, plName)
+		}
+		cfgCtlr.lock.Unlock()
+		fmt.Fprintf(w, "reject-all for priority level %q set to %t\n", plName, enabled)
+	default:
+		http.Error(w, "only GET and POST are allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// pauseDigestion freezes config digestion.  The last-applied
+// configuration stays in effect; changes to the config objects are
+// buffered until digestion is resumed.
+func (cfgCtlr *configController) pauseDigestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfgCtlr.lock.Lock()
+	defer cfgCtlr.lock.Unlock()
+	if !cfgCtlr.digestionPaused {
+		cfgCtlr.digestionPaused = true
+		continuum.EmitMarker(continuum.Marker{ID: continuum.MarkerConfigDigestionPaused, Time: continuum.TimestampFrom(cfgCtlr.clock.Now())})
+	}
+	fmt.Fprintln(w, "config digestion paused")
+}
+
+// resumeDigestion unfreezes config digestion and, if any config
+// change arrived while paused, schedules a sync to apply it.
+func (cfgCtlr *configController) resumeDigestion(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "only POST is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	cfgCtlr.lock.Lock()
+	defer cfgCtlr.lock.Unlock()
+	if cfgCtlr.digestionPaused {
+		cfgCtlr.digestionPaused = false
+		continuum.EmitMarker(continuum.Marker{ID: continuum.MarkerConfigDigestionResumed, Time: continuum.TimestampFrom(cfgCtlr.clock.Now())})
+		if cfgCtlr.digestionDeferred {
+			cfgCtlr.digestionDeferred = false
+			cfgCtlr.noteConfigChange()
+		}
+	}
+	fmt.Fprintln(w, "config digestion resumed")
+}
+
+// borrowingDebugEntry is one priority level in the
+// /debug/flowcontrol/borrowing response.
+type borrowingDebugEntry struct {
+	Name          string `json:"name"`
+	NominalCL     int    `json:"nominalConcurrencyLimit"`
+	CurrentCL     int    `json:"currentConcurrencyLimit"`
+	LentSeats     int    `json:"lentSeats"`
+	BorrowedSeats int    `json:"borrowedSeats"`
+}
+
+func (cfgCtlr *configController) dumpBorrowing(w http.ResponseWriter, r *http.Request) {
+	entries := cfgCtlr.borrowingEntries()
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
+}
+
+// borrowingEntries returns the per-priority-level seat accounting,
+// sorted by name.
+func (cfgCtlr *configController) borrowingEntries() []borrowingDebugEntry {
+	cfgCtlr.lock.Lock()
+	defer cfgCtlr.lock.Unlock()
+	plNames := make([]string, 0, len(cfgCtlr.priorityLevelStates))
+	for plName := range cfgCtlr.priorityLevelStates {
+		plNames = append(plNames, plName)
+	}
+	sort.Strings(plNames)
+	entries := make([]borrowingDebugEntry, 0, len(plNames))
+	for _, plName := range plNames {
+		plState := cfgCtlr.priorityLevelStates[plName]
+		entries = append(entries, borrowingDebugEntry{
+			Name:          plState.pl.Name,
+			NominalCL:     plState.nominalCL,
+			CurrentCL:     plState.currentCL,
+			LentSeats:     lentSeats(plState),
+			BorrowedSeats: borrowedSeats(plState),
+		})
+	}
+	return entries
+}
+
+// flowSchemaDebugEntry is one digested FlowSchema in the
+// /debug/flowcontrol/flowschemas response, in matching order.
+type flowSchemaDebugEntry struct {
+	Name                string `json:"name"`
+	MatchingPrecedence  int32  `json:"matchingPrecedence"`
+	PriorityLevel       string `json:"priorityLevel"`
+	DistinguisherMethod string `json:"distinguisherMethod,omitempty"`
+	Dangling            bool   `json:"dangling"`
+}
+
+func (cfgCtlr *configController) dumpFlowSchemas(w http.ResponseWriter, r *http.Request) {
+	cfgCtlr.lock.Lock()
+	defer cfgCtlr.lock.Unlock()
+	entries := make([]flowSchemaDebugEntry, 0, len(cfgCtlr.flowSchemas))
+	for _, fs := range cfgCtlr.flowSchemas {
+		entry := flowSchemaDebugEntry{
+			Name:               fs.Name,
+			MatchingPrecedence: fs.Spec.MatchingPrecedence,
+			PriorityLevel:      fs.Spec.PriorityLevelConfiguration.Name,
+		}
+		if fs.Spec.DistinguisherMethod != nil {
+			entry.DistinguisherMethod = string(fs.Spec.DistinguisherMethod.Type)
+		}
+		_, havePL := cfgCtlr.priorityLevelStates[fs.Spec.PriorityLevelConfiguration.Name]
+		entry.Dangling = !havePL
+		entries = append(entries, entry)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	runtime.HandleError(json.NewEncoder(w).Encode(entries))
 }
 
 func (cfgCtlr *configController) dumpPriorityLevels(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,136 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+	"sync"
+)
+
+// counterKey identifies one flow in the accumulated counters.
+type counterKey struct {
+	priorityLevel string
+	flowSchema    string
+}
+
+// flowCounters are the counts accumulated for one flow since startup
+// or the last reset.
+type flowCounters struct {
+	classified int64
+	queued     int64
+	dispatched int64
+	borrowed   int64
+	rejected   int64
+}
+
+// apfCounters accumulates per-flow counts that, unlike the registered
+// metrics, can be snapshotted and reset atomically.  This supports
+// per-experiment accounting without restarting the server.
+type apfCounters struct {
+	lock    sync.Mutex
+	perFlow map[counterKey]*flowCounters
+}
+
+// getLocked returns the counters for the given flow, creating them if
+// needed.  c.lock must be held.
+func (c *apfCounters) getLocked(plName, fsName string) *flowCounters {
+	if c.perFlow == nil {
+		c.perFlow = map[counterKey]*flowCounters{}
+	}
+	key := counterKey{priorityLevel: plName, flowSchema: fsName}
+	fc := c.perFlow[key]
+	if fc == nil {
+		fc = &flowCounters{}
+		c.perFlow[key] = fc
+	}
+	return fc
+}
+
+func (c *apfCounters) noteClassified(plName, fsName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getLocked(plName, fsName).classified++
+}
+
+func (c *apfCounters) noteQueued(plName, fsName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getLocked(plName, fsName).queued++
+}
+
+func (c *apfCounters) noteDispatched(plName, fsName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getLocked(plName, fsName).dispatched++
+}
+
+// noteBorrowed counts a request that began executing while its
+// priority level was using seats borrowed from other levels.
+func (c *apfCounters) noteBorrowed(plName, fsName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getLocked(plName, fsName).borrowed++
+}
+
+func (c *apfCounters) noteRejected(plName, fsName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.getLocked(plName, fsName).rejected++
+}
+
+// counterDebugEntry is one flow in the /debug/flowcontrol/counters
+// response.
+type counterDebugEntry struct {
+	PriorityLevel string `json:"priorityLevel"`
+	FlowSchema    string `json:"flowSchema"`
+	Classified    int64  `json:"classified"`
+	Queued        int64  `json:"queued"`
+	Dispatched    int64  `json:"dispatched"`
+	Borrowed      int64  `json:"borrowed"`
+	Rejected      int64  `json:"rejected"`
+}
+
+// snapshot returns the accumulated counts, sorted by priority level
+// and then flow schema.  If reset is true the counters are cleared in
+// the same critical section, so no increment is lost between the read
+// and the clear.
+func (c *apfCounters) snapshot(reset bool) []counterDebugEntry {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	entries := make([]counterDebugEntry, 0, len(c.perFlow))
+	for key, fc := range c.perFlow {
+		entries = append(entries, counterDebugEntry{
+			PriorityLevel: key.priorityLevel,
+			FlowSchema:    key.flowSchema,
+			Classified:    fc.classified,
+			Queued:        fc.queued,
+			Dispatched:    fc.dispatched,
+			Borrowed:      fc.borrowed,
+			Rejected:      fc.rejected,
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].PriorityLevel != entries[j].PriorityLevel {
+			return entries[i].PriorityLevel < entries[j].PriorityLevel
+		}
+		return entries[i].FlowSchema < entries[j].FlowSchema
+	})
+	if reset {
+		c.perFlow = nil
+	}
+	return entries
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestAPFCountersSnapshotAndReset(t *testing.T) {
+	var c apfCounters
+	c.noteClassified("system", "system-nodes")
+	c.noteClassified("system", "system-nodes")
+	c.noteQueued("system", "system-nodes")
+	c.noteDispatched("system", "system-nodes")
+	c.noteDispatched("system", "system-nodes")
+	c.noteRejected("workload-low", "service-accounts")
+	c.noteQueued("global-default", "global-default")
+
+	entries := c.snapshot(true)
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %#v", entries)
+	}
+	if entries[0].PriorityLevel != "global-default" || entries[1].PriorityLevel != "system" || entries[2].PriorityLevel != "workload-low" {
+		t.Errorf("entries not sorted by priority level: %#v", entries)
+	}
+	if entries[1].Classified != 2 || entries[1].Queued != 1 || entries[1].Dispatched != 2 || entries[1].Rejected != 0 {
+		t.Errorf("wrong counts for system flow: %#v", entries[1])
+	}
+
+	if after := c.snapshot(false); len(after) != 0 {
+		t.Errorf("expected counters to be empty after reset, got %#v", after)
+	}
+}
+
+func TestAPFCountersConcurrentResetLosesNothing(t *testing.T) {
+	var c apfCounters
+	const writers = 4
+	const perWriter = 1000
+	collected := make(chan int64)
+	stop := make(chan struct{})
+	go func() {
+		var total int64
+		for {
+			select {
+			case <-stop:
+				collected <- total
+				return
+			default:
+			}
+			for _, e := range c.snapshot(true) {
+				total += e.Dispatched
+			}
+		}
+	}()
+	var writerWG sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		writerWG.Add(1)
+		go func() {
+			defer writerWG.Done()
+			for j := 0; j < perWriter; j++ {
+				c.noteDispatched("exempt", "exempt")
+			}
+		}()
+	}
+	writerWG.Wait()
+	close(stop)
+	total := <-collected
+	for _, e := range c.snapshot(false) {
+		total += e.Dispatched
+	}
+	if total != writers*perWriter {
+		t.Errorf("expected %d dispatches across snapshots, got %d", writers*perWriter, total)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+func TestConfigDebounceCoalesces(t *testing.T) {
+	cfgCtlr := &configController{
+		clock:                testclock.NewFakePassiveClock(time.Now()),
+		configDebounceWindow: 50 * time.Millisecond,
+	}
+	cfgCtlr.configQueue = workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[int](200*time.Millisecond, 8*time.Hour),
+		workqueue.TypedRateLimitingQueueConfig[int]{Name: "debounce_test_queue"})
+	defer cfgCtlr.configQueue.ShutDown()
+
+	// The workqueue's delaying logic reads the real clock, so the
+	// window is measured with it.
+	for i := 0; i < 3; i++ {
+		cfgCtlr.noteConfigChange()
+	}
+	if got := cfgCtlr.configQueue.Len(); got != 0 {
+		t.Errorf("expected the work item to be held back during the window, queue length %d", got)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for cfgCtlr.configQueue.Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the work item never became ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := cfgCtlr.configQueue.Len(); got != 1 {
+		t.Errorf("expected the burst to coalesce into one work item, queue length %d", got)
+	}
+}
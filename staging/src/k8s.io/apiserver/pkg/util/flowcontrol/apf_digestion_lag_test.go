@@ -0,0 +1,72 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	testclock "k8s.io/utils/clock/testing"
+)
+
+// markerCapture collects markers emitted through the package-level
+// sink.  The tests here run sequentially in one process, so no lock
+// is needed.
+type markerCapture struct {
+	markers []continuum.Marker
+}
+
+func (c *markerCapture) Write(m continuum.Marker) {
+	c.markers = append(c.markers, m)
+}
+
+func TestNoteDigestionLag(t *testing.T) {
+	capture := &markerCapture{}
+	continuum.SetMarkerSink(capture)
+	clk := testclock.NewFakePassiveClock(time.Now())
+	cfgCtlr := &configController{name: "test", clock: clk, digestionLagThreshold: time.Second}
+
+	pendingSince := clk.Now().UnixNano()
+	cfgCtlr.noteDigestionLag(pendingSince)
+	if len(capture.markers) != 0 {
+		t.Errorf("expected no marker for a promptly digested change, got %v", capture.markers)
+	}
+
+	clk.SetTime(clk.Now().Add(2 * time.Second))
+	cfgCtlr.noteDigestionLag(pendingSince)
+	if len(capture.markers) != 1 {
+		t.Fatalf("expected one marker for a lagging change, got %v", capture.markers)
+	}
+	if capture.markers[0].ID != continuum.MarkerConfigDigestionLagging {
+		t.Errorf("expected marker ID %d, got %d", continuum.MarkerConfigDigestionLagging, capture.markers[0].ID)
+	}
+
+	capture.markers = nil
+	cfgCtlr.noteDigestionLag(0)
+	if len(capture.markers) != 0 {
+		t.Errorf("expected no marker when no change was pending, got %v", capture.markers)
+	}
+
+	unbounded := &configController{name: "test", clock: clk}
+	unbounded.noteDigestionLag(pendingSince)
+	if len(capture.markers) != 0 {
+		t.Errorf("expected no marker with the check disabled, got %v", capture.markers)
+	}
+}
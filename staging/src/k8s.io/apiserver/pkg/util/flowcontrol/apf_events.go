@@ -0,0 +1,138 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+)
+
+// eventClientBuffer is the number of events buffered per connected
+// event-stream client; events beyond it are dropped for that client
+// rather than slowing down request handling.
+const eventClientBuffer = 256
+
+// eventBroadcaster is a continuum.MarkerSink that fans markers out to
+// the clients of the events debug endpoint.  Its Write never blocks.
+type eventBroadcaster struct {
+	lock        sync.Mutex
+	subscribers map[chan continuum.Marker]struct{}
+}
+
+func (b *eventBroadcaster) Write(m continuum.Marker) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	for ch := range b.subscribers {
+		select {
+		case ch <- m:
+		default:
+			// The client is not keeping up; drop the event for it.
+		}
+	}
+}
+
+func (b *eventBroadcaster) subscribe() chan continuum.Marker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.subscribers == nil {
+		b.subscribers = map[chan continuum.Marker]struct{}{}
+	}
+	ch := make(chan continuum.Marker, eventClientBuffer)
+	b.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (b *eventBroadcaster) unsubscribe(ch chan continuum.Marker) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	delete(b.subscribers, ch)
+}
+
+// eventStreamEntry is one event on the events debug endpoint.
+type eventStreamEntry struct {
+	Event         string `json:"event"`
+	Marker        int    `json:"marker"`
+	Time          int64  `json:"time"`
+	FlowSchema    string `json:"flowSchema"`
+	PriorityLevel string `json:"priorityLevel"`
+	RunID         string `json:"runID,omitempty"`
+	TraceID       string `json:"traceID,omitempty"`
+}
+
+// eventName gives the marker identifiers that the catch-all event
+// tracer produces readable names on the stream.
+func eventName(markerID int) string {
+	switch markerID {
+	case continuum.MarkerRequestEnter:
+		return "enter"
+	case continuum.MarkerRequestEnqueued:
+		return "enqueue"
+	case continuum.MarkerRequestDequeued:
+		return "dispatch"
+	case continuum.MarkerRequestFinish:
+		return "finish"
+	case continuum.MarkerCancelledWhileQueued:
+		return "cancelled-while-queued"
+	default:
+		return "marker"
+	}
+}
+
+// streamEvents serves the events debug endpoint: a Server-Sent Events
+// stream of the markers of every traced request, one JSON entry per
+// event, until the client goes away.  In a binary built without the
+// continuum tag the stream stays silent.
+func (cfgCtlr *configController) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	ch := cfgCtlr.events.subscribe()
+	defer cfgCtlr.events.unsubscribe(ch)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case m := <-ch:
+			entry, err := json.Marshal(eventStreamEntry{
+				Event:         eventName(m.ID),
+				Marker:        m.ID,
+				Time:          m.Time,
+				FlowSchema:    m.FlowSchema,
+				PriorityLevel: m.PriorityLevel,
+				RunID:         m.RunID,
+				TraceID:       m.TraceID,
+			})
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", entry); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
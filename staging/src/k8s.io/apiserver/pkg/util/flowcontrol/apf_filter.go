@@ -18,12 +18,18 @@ package flowcontrol
 
 import (
 	"context"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	endpointsrequest "k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/pkg/server/httplog"
 	"k8s.io/apiserver/pkg/server/mux"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
 	"k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/eventclock"
 	fqs "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/queueset"
@@ -75,6 +81,11 @@ type Interface interface {
 	// Install installs debugging endpoints to the web-server.
 	Install(c *mux.PathRecorderMux)
 
+	// IsExempt reports whether a request characterized by the given
+	// digest would be classified into an exempt priority level,
+	// without going through the full Handle path.
+	IsExempt(requestDigest RequestDigest) bool
+
 	// WatchTracker provides the WatchTracker interface.
 	WatchTracker
 
@@ -83,6 +94,20 @@ type Interface interface {
 	MaxSeatsTracker
 }
 
+// TestableInterface extends Interface with accessors that only tests
+// need.  NewTestable returns one so that a test can reach the
+// injected clock without retaining the concrete controller type.
+type TestableInterface interface {
+	Interface
+
+	// Clock returns the clock the filter reads for all of its timing.
+	// When the TestableConfig left QueueSetFactory nil and supplied an
+	// event clock here, the queuing implementation reads the same
+	// clock, so advancing a fake deterministically drives both
+	// queueing decisions and duration measurement.
+	Clock() clock.PassiveClock
+}
+
 // This request filter implements https://github.com/kubernetes/enhancements/blob/master/keps/sig-api-machinery/1040-priority-and-fairness/README.md
 
 // New creates a new instance to implement API priority and fairness
@@ -143,12 +168,180 @@ type TestableConfig struct {
 	// RatioedGaugePairVec for metrics about seats occupied by all phases of execution
 	ExecSeatsGaugeVec metrics.RatioedGaugeVec
 
-	// QueueSetFactory for the queuing implementation
+	// QueueSetFactory for the queuing implementation.  Nil means one
+	// built on Clock when Clock is an event clock, so that queueing
+	// and duration measurement read the same injected clock and
+	// cannot drift apart in tests.
 	QueueSetFactory fq.QueueSetFactory
+
+	// ExecutionCPUAccounting selects whether the CPU time consumed
+	// during each request's execution phase is measured (via
+	// per-thread getrusage accounting, where available) and attached
+	// to the lifecycle event and a metric.  The measurement pins the
+	// executing goroutine to its thread and excludes work handed off
+	// to other goroutines.
+	ExecutionCPUAccounting bool
+
+	// HighResolutionTimingMetrics selects whether wait and execution
+	// durations are additionally recorded in microsecond-bucketed
+	// histogram variants, which resolve the sub-millisecond phases
+	// that the regular buckets cannot.
+	HighResolutionTimingMetrics bool
+
+	// OnReject, if non-nil, is invoked for every request that the
+	// filter rejects.  The reason is the same string used for the
+	// corresponding count in the rejected-requests metric:
+	// "queue-full", "concurrency-limit", or "time-out"; requests cut
+	// off by the reject_all debug endpoint report "reject-all".
+	OnReject func(rd RequestDigest, reason string)
+
+	// OnConfigApplied, if non-nil, is invoked after each successful
+	// config digestion with the computed nominal seat allocation,
+	// keyed by priority level name.  It is called with the
+	// controller's lock held and must return quickly without calling
+	// back into the filter.  The map is freshly allocated per call
+	// and may be retained.
+	OnConfigApplied func(allocation map[string]int)
+
+	// MaxExecutingByFlowSchema optionally caps how many requests of
+	// each named FlowSchema execute at once, isolating a noisy flow
+	// from sibling flows that share its priority level.  The cap is
+	// enforced after fair queuing admits the request; an over-cap
+	// request waits for a slot, within the priority level's wait
+	// limit.  FlowSchemas without an entry are unconstrained.
+	MaxExecutingByFlowSchema map[string]int
+
+	// StrictFIFOByFlowSchema optionally forces requests of the named
+	// FlowSchemas to begin execution strictly in arrival order: a
+	// request's execFn does not start until the previous request of
+	// the same FlowSchema has started its own.  The gate is applied
+	// after fair queuing admits the request; a request held at the
+	// gate counts as queued and emits queued markers, and keeps
+	// occupying its seats while it waits.  The priority level
+	// therefore needs enough concurrency for the older requests to
+	// dispatch, or the gate stalls until the waiters' contexts
+	// expire.  This intentionally reduces concurrency and exists only
+	// for measurements that need deterministic intra-flow ordering.
+	StrictFIFOByFlowSchema map[string]bool
+
+	// AbsoluteConcurrency optionally pins named priority levels to an
+	// absolute nominal seat count instead of a share-derived one.  The
+	// pinned seats are taken off the top of ServerConcurrencyLimit and
+	// the remaining priority levels divide what is left in proportion
+	// to their shares.  The pinned allocations must not sum to more
+	// than ServerConcurrencyLimit.
+	AbsoluteConcurrency map[string]int
+
+	// MaxQueueLength optionally overrides, per priority level, the
+	// maximum number of requests that may wait in each of the level's
+	// queues, independent of its concurrency.  The override replaces
+	// the queue length the API object requests when the level's
+	// queuesets are constructed; requests beyond it are rejected with
+	// the queue-full reason as usual.  Levels absent from the map, and
+	// levels that do not queue, keep their configured behavior.
+	MaxQueueLength map[string]int
+
+	// EnableSyntheticInjection selects whether the debug endpoint for
+	// injecting synthetic requests is served; see InjectSynthetic.
+	// Off by default because the endpoint manufactures load.
+	EnableSyntheticInjection bool
+
+	// ImmediateDispatchEpsilon, if positive, makes queue waits below
+	// it count on a companion "immediate dispatch" metric in addition
+	// to the wait histogram, whose smallest bucket cannot separate
+	// sub-resolution waits from real ones.  Zero disables the counter.
+	ImmediateDispatchEpsilon time.Duration
+
+	// SuppressMetrics disables the per-request Prometheus metric
+	// observations in Handle (outcome and dispatch counts, wait and
+	// execution durations, seat-seconds, execution CPU) while leaving
+	// tracer emissions, lifecycle events, and the debug counters in
+	// place.  This strips the measurement overhead out of the most
+	// latency-sensitive experiments; dispatch semantics are unchanged.
+	SuppressMetrics bool
+
+	// ExecutionPprofLabels selects whether execFn runs under pprof
+	// labels naming the request's priority level and flow schema, so
+	// that CPU profiles taken during a run attribute handler work to
+	// the flow that dispatched it.  Off by default because labeling
+	// has a small per-request cost.
+	ExecutionPprofLabels bool
+
+	// MaxRequestDuration, if positive, bounds each request's total
+	// time in the filter — queue wait plus execution — independently
+	// of the client's own deadline.  Handle enforces it by deriving a
+	// child context with that deadline, which is what the queueset
+	// and everything downstream of Handle observe; Handle still does
+	// not return while execFn runs, the bound only cancels the
+	// context.  A request that outlives the bound emits a marker and
+	// counts on a metric.  The bound is measured with the real clock
+	// because the injected PassiveClock cannot produce timers.
+	MaxRequestDuration time.Duration
+
+	// RequestWaitLimitByPriorityLevel optionally bounds how long a
+	// request may wait in its queue, per priority level.  A queued
+	// request of a priority level with a positive entry here is
+	// cancelled out of its queue once it has waited that long.  Levels
+	// without an entry are bounded only by the request's own context.
+	RequestWaitLimitByPriorityLevel map[string]time.Duration
+
+	// ConfigWorkerCount is the number of goroutines that process
+	// config change events; values below one mean one.  The work
+	// queue collapses all pending changes into a single item and
+	// never hands that item to two workers at once, so digestions
+	// never overlap and the derived seat allocation always reflects
+	// one consistent listing regardless of the count; extra workers
+	// only let a fresh change be picked up while another worker is
+	// still finishing the bookkeeping of the previous one.
+	ConfigWorkerCount int
+
+	// DigestionLagThreshold, if positive, emits a marker whenever a
+	// config change has been pending for longer than this when its
+	// digestion begins, making config-propagation lag visible on the
+	// run's timeline.  Zero disables the check.
+	DigestionLagThreshold time.Duration
+
+	// ConfigDebounceWindow, if positive, delays the digestion
+	// triggered by a noticed config change by this much, so that a
+	// burst of changes arriving faster than the window coalesces into
+	// a single digestion — and a single config-applied notification —
+	// instead of reclassifying repeatedly.  The window adds to the
+	// measured digestion lag, so a DigestionLagThreshold should
+	// account for it.  Zero digests as soon as a worker is free.
+	ConfigDebounceWindow time.Duration
+
+	// QueueSelectionLogging selects logging, for each request assigned
+	// to a queue, of the flow distinguisher that fed the assignment
+	// hash and the queue index that came out, and accumulation of a
+	// per-priority-level histogram of requests per queue served by the
+	// queue_selections debug endpoint.  The histogram quantifies how
+	// evenly a flow's requests spread across the queues of its level,
+	// i.e. whether shuffle-sharding is working as expected.
+	QueueSelectionLogging bool
+
+	// RuntimeStatsAccounting selects whether each lifecycle event
+	// carries the process's goroutine count and most recent GC pause,
+	// read from a periodic sample of the Go runtime.  This helps rule
+	// runtime effects in or out when interpreting latency spikes.
+	RuntimeStatsAccounting bool
+
+	// TraceIDFunc generates the trace id given to a traced request
+	// that did not supply one itself.  Nil means a fresh UUID per
+	// request; tests substitute a deterministic generator so that
+	// assertions on emitted markers are stable.
+	TraceIDFunc func() string
+
+	// PriorityLevelClasses optionally maps priority-level name to a
+	// user-defined class that appears as the "class" label on the
+	// ALPHA metric vectors of this package, so queries can aggregate
+	// related levels (e.g. all "workload" vs all "system" levels)
+	// without enumerating them by name.  Levels absent from the
+	// mapping report an empty class.
+	PriorityLevelClasses map[string]string
 }
 
 // NewTestable is extra flexible to facilitate testing
-func NewTestable(config TestableConfig) Interface {
+func NewTestable(config TestableConfig) TestableInterface {
 	return newTestableController(config)
 }
 
@@ -157,17 +350,265 @@ func (cfgCtlr *configController) Handle(ctx context.Context, requestDigest Reque
 	workEstimator func() fcrequest.WorkEstimate,
 	queueNoteFn fq.QueueNoteFn,
 	execFn func()) {
-	fs, pl, isExempt, req, startWaitingTime := cfgCtlr.startRequest(ctx, requestDigest, noteFn, workEstimator, queueNoteFn)
+	// The filter-imposed bound on the request's total
+	// queue-plus-execution time is enforced by deriving a child
+	// context, so the queueset and everything downstream observe the
+	// cancellation; Handle itself still waits for execFn to return.
+	// The bound is measured with the real clock because the injected
+	// PassiveClock cannot produce timers.
+	var admissionDeadline time.Time
+	if d := cfgCtlr.maxRequestDuration; d > 0 {
+		admissionDeadline = time.Now().Add(d)
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, admissionDeadline)
+		defer cancel()
+	}
+	// The tracers are matched once here; the markers and lifecycle
+	// event below all reuse the result.
+	var username string
+	if requestDigest.User != nil {
+		username = requestDigest.User.GetName()
+	}
+	// The authenticated identity is stashed by the impersonation
+	// filter; absent impersonation it coincides with the effective
+	// one.
+	authenticatedUsername := continuum.AuthenticatedUserFrom(ctx)
+	if authenticatedUsername == "" {
+		authenticatedUsername = username
+	}
+	matchedTracers := continuum.MatchTracers(requestDigest.RequestInfo, username, authenticatedUsername)
+	runID := continuum.RunIDFrom(ctx)
+	if len(matchedTracers) > 0 {
+		traceID := continuum.TraceIDFrom(ctx)
+		if traceID == "" {
+			traceID = cfgCtlr.traceIDFunc()
+		}
+		for i := range matchedTracers {
+			matchedTracers[i].TraceID = traceID
+		}
+		// Entering and leaving a queue are emitted as their own
+		// events, distinct from classification, by hooking the
+		// queue-note callback.  The names become available through the
+		// classification callback before the request can be queued.
+		var classifiedFS, classifiedPL string
+		innerNoteFn := noteFn
+		noteFn = func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string) {
+			classifiedFS, classifiedPL = fs.Name, pl.Name
+			innerNoteFn(fs, pl, flowDistinguisher)
+		}
+		innerQueueNoteFn := queueNoteFn
+		queueNoteFn = func(inQueue bool) {
+			id := continuum.MarkerRequestDequeued
+			if inQueue {
+				id = continuum.MarkerRequestEnqueued
+			}
+			now := cfgCtlr.clock.Now()
+			for _, mt := range matchedTracers {
+				mt.EmitMarker(continuum.Marker{ID: id, Time: continuum.TimestampFrom(now), FlowSchema: classifiedFS, PriorityLevel: classifiedPL, RunID: runID, TraceID: mt.TraceID})
+			}
+			innerQueueNoteFn(inQueue)
+		}
+	}
+	// The queue-depth high-water mark is maintained from the queue
+	// transitions regardless of tracing.  The priority level name
+	// becomes available through the classification callback before
+	// the request can be queued.
+	var depthPL string
+	depthNoteFn := noteFn
+	noteFn = func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string) {
+		depthPL = pl.Name
+		depthNoteFn(fs, pl, flowDistinguisher)
+	}
+	depthQueueNoteFn := queueNoteFn
+	queueNoteFn = func(inQueue bool) {
+		if inQueue {
+			if depth, newMax := cfgCtlr.queueDepths.noteEnqueued(depthPL); newMax && !cfgCtlr.suppressMetrics {
+				metrics.SetInqueueRequestsHighWatermark(ctx, depthPL, depth)
+			}
+		} else {
+			cfgCtlr.queueDepths.noteDequeued(depthPL)
+		}
+		depthQueueNoteFn(inQueue)
+	}
+
+	// The gap between classification and the enqueue transition is
+	// observed as its own histogram; a non-trivial gap points to
+	// contention on the way into the queueset rather than to queue
+	// wait proper.
+	if !cfgCtlr.suppressMetrics {
+		var classifiedAt time.Time
+		var gapFS, gapPL string
+		innerNoteFn := noteFn
+		noteFn = func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string) {
+			gapFS, gapPL = fs.Name, pl.Name
+			classifiedAt = cfgCtlr.clock.Now()
+			innerNoteFn(fs, pl, flowDistinguisher)
+		}
+		enqueued := false
+		innerQueueNoteFn := queueNoteFn
+		queueNoteFn = func(inQueue bool) {
+			if inQueue && !enqueued {
+				enqueued = true
+				metrics.ObserveClassificationToEnqueueGap(ctx, gapPL, gapFS, cfgCtlr.clock.Since(classifiedAt))
+			}
+			innerQueueNoteFn(inQueue)
+		}
+	}
+
+	// The flow distinguisher is one of the inputs of the queue
+	// assignment hash, so the selection log line reports it alongside
+	// the index that came out.
+	var selectionDistinguisher string
+	if cfgCtlr.queueSelectionLogging {
+		innerNoteFn := noteFn
+		noteFn = func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string) {
+			selectionDistinguisher = flowDistinguisher
+			innerNoteFn(fs, pl, flowDistinguisher)
+		}
+	}
+
+	// A strict-FIFO ticket is taken at classification time, which is
+	// the order requests pass through the filter.
+	var fifoGate *fsFIFOGate
+	var fifoTicket uint64
+	if len(cfgCtlr.strictFIFOByFS) > 0 {
+		innerNoteFn := noteFn
+		noteFn = func(fs *flowcontrol.FlowSchema, pl *flowcontrol.PriorityLevelConfiguration, flowDistinguisher string) {
+			if g := cfgCtlr.fsFIFOGate(fs.Name); g != nil {
+				fifoGate, fifoTicket = g, g.take()
+			}
+			innerNoteFn(fs, pl, flowDistinguisher)
+		}
+	}
+	fs, pl, isExempt, req, startWaitingTime, cleanup := cfgCtlr.startRequest(ctx, requestDigest, noteFn, workEstimator, queueNoteFn)
+	if cleanup != nil {
+		defer cleanup()
+	}
+	if fifoGate != nil {
+		// Take the ticket out of the rotation no matter how this
+		// request ends, so that a rejected or cancelled request does
+		// not stall its successors.
+		defer fifoGate.abandon(fifoTicket)
+	}
 	queued := startWaitingTime != time.Time{}
+	if cfgCtlr.queueSelectionLogging && req != nil {
+		if queueIdx, ok := fqs.ChosenQueueIndex(req); ok {
+			cfgCtlr.queueSelections.note(pl.Name, queueIdx)
+			klog.V(4).Infof("%s: queue selection fsName=%q, plName=%q, flowDistinguisher=%q, queueIndex=%d", cfgCtlr.name, fs.Name, pl.Name, selectionDistinguisher, queueIdx)
+		}
+	}
+	if !cfgCtlr.suppressMetrics {
+		metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeClassified)
+	}
+	cfgCtlr.counters.noteClassified(pl.Name, fs.Name)
+	if queued {
+		if !cfgCtlr.suppressMetrics {
+			metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeQueued)
+		}
+		cfgCtlr.counters.noteQueued(pl.Name, fs.Name)
+	}
+	if len(matchedTracers) > 0 {
+		now := cfgCtlr.clock.Now()
+		for _, mt := range matchedTracers {
+			mt.ObserveRequest(now, requestDigest.RequestInfo, requestDigest.User)
+			mt.EmitMarker(continuum.Marker{ID: mt.Rule.EnterMarker(), Time: continuum.TimestampFrom(now), FlowSchema: fs.Name, PriorityLevel: pl.Name, RunID: runID, TraceID: mt.TraceID})
+		}
+	}
+	var executed bool
+	var startExecutionTime time.Time
+	var executionDuration time.Duration
+	var executionCPU time.Duration
+	var borrowedExecution bool
+	var borrowedFrom []string
+	defer func() {
+		now := cfgCtlr.clock.Now()
+		if !admissionDeadline.IsZero() && !time.Now().Before(admissionDeadline) {
+			if !cfgCtlr.suppressMetrics {
+				metrics.AddRequestDeadlineExceeded(ctx, pl.Name, fs.Name)
+			}
+			for _, mt := range matchedTracers {
+				mt.EmitMarker(continuum.Marker{ID: continuum.MarkerRequestDeadlineExceeded, Time: continuum.TimestampFrom(now), FlowSchema: fs.Name, PriorityLevel: pl.Name, RunID: runID, TraceID: mt.TraceID})
+			}
+		}
+		matchedRule := "none"
+		if len(matchedTracers) > 0 {
+			names := make([]string, len(matchedTracers))
+			for i, mt := range matchedTracers {
+				mt.EmitMarker(continuum.Marker{ID: mt.Rule.FinishMarker(), Time: continuum.TimestampFrom(now), FlowSchema: fs.Name, PriorityLevel: pl.Name, RunID: runID, TraceID: mt.TraceID})
+				names[i] = mt.Tracer.Name + "/" + mt.Rule.Name
+			}
+			matchedRule = strings.Join(names, ",")
+		}
+		var waitingDuration time.Duration
+		if queued {
+			if executed {
+				waitingDuration = startExecutionTime.Sub(startWaitingTime)
+			} else {
+				waitingDuration = now.Sub(startWaitingTime)
+			}
+		}
+		ev := continuum.LifecycleEvent{
+			FlowSchema:         fs.Name,
+			PriorityLevel:      pl.Name,
+			MatchedRule:        matchedRule,
+			Queued:             queued,
+			StartWaitingTime:   continuum.TimeNanos(startWaitingTime),
+			StartExecutionTime: continuum.TimeNanos(startExecutionTime),
+			WaitingDuration:    waitingDuration,
+			ExecutionDuration:  executionDuration,
+			ExecutionCPU:       executionCPU,
+			WaitLimit:          cfgCtlr.requestWaitLimitByPL[pl.Name],
+			Executed:           executed,
+			Borrowed:           borrowedExecution,
+			BorrowedFrom:       borrowedFrom,
+			Labels:             continuum.HeaderLabelsFrom(ctx),
+		}
+		if cfgCtlr.runtimeStats != nil {
+			ev.Goroutines, ev.LastGCPause = cfgCtlr.runtimeStats.read()
+		}
+		if req != nil && continuum.Verbose() {
+			if hand, queueIdx, ok := fqs.ShuffleShardDetails(req); ok {
+				ev.ShuffleShardHand = hand
+				ev.QueueIndex = queueIdx
+			}
+		}
+		cfgCtlr.history.add(ev)
+		if len(matchedTracers) > 0 {
+			continuum.EmitLifecycleEvent(ev)
+		}
+	}()
 	if req == nil {
+		forceRejected := cfgCtlr.isForceRejected(pl.Name)
+		if !cfgCtlr.suppressMetrics {
+			metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeRejected)
+		}
+		cfgCtlr.counters.noteRejected(pl.Name, fs.Name)
+		if cfgCtlr.onReject != nil {
+			// Rejection at this point happened upon arrival; whether
+			// that was for queue length or concurrency depends on
+			// whether the priority level queues at all, unless the
+			// reject-all flag cut the request off first.
+			reason := "concurrency-limit"
+			if forceRejected {
+				reason = "reject-all"
+			} else if !isExempt && pl.Spec.Limited.LimitResponse.Type == flowcontrol.LimitResponseTypeQueue {
+				reason = "queue-full"
+			}
+			cfgCtlr.onReject(requestDigest, reason)
+		}
+		if forceRejected {
+			now := cfgCtlr.clock.Now()
+			for _, mt := range matchedTracers {
+				mt.EmitMarker(continuum.Marker{ID: continuum.MarkerForceRejected, Time: continuum.TimestampFrom(now), FlowSchema: fs.Name, PriorityLevel: pl.Name, RunID: runID, TraceID: mt.TraceID})
+			}
+		}
 		if queued {
-			observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
+			cfgCtlr.observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
 		}
 		klog.V(7).Infof("Handle(%#+v) => fsName=%q, distMethod=%#+v, plName=%q, isExempt=%v, reject", requestDigest, fs.Name, fs.Spec.DistinguisherMethod, pl.Name, isExempt)
 		return
 	}
 	klog.V(7).Infof("Handle(%#+v) => fsName=%q, distMethod=%#+v, plName=%q, isExempt=%v, queued=%v", requestDigest, fs.Name, fs.Spec.DistinguisherMethod, pl.Name, isExempt, queued)
-	var executed bool
 	idle, panicking := true, true
 	defer func() {
 		klog.V(7).Infof("Handle(%#+v) => fsName=%q, distMethod=%#+v, plName=%q, isExempt=%v, queued=%v, Finish() => panicking=%v idle=%v",
@@ -177,27 +618,357 @@ func (cfgCtlr *configController) Handle(ctx context.Context, requestDigest Reque
 		}
 	}()
 	idle = req.Finish(func() {
+		if fifoGate != nil {
+			if !fifoGate.ready(fifoTicket) {
+				// Waiting for the FlowSchema's previous request to
+				// begin executing counts as queuing.
+				if !queued {
+					queued = true
+					startWaitingTime = cfgCtlr.clock.Now()
+					if !cfgCtlr.suppressMetrics {
+						metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeQueued)
+					}
+					cfgCtlr.counters.noteQueued(pl.Name, fs.Name)
+				}
+				queueNoteFn(true)
+				ok := fifoGate.wait(ctx, fifoTicket)
+				queueNoteFn(false)
+				if !ok {
+					return
+				}
+			}
+			fifoGate.advance(fifoTicket)
+		}
+		if sem := cfgCtlr.fsExecSemaphore(fs.Name); sem != nil {
+			select {
+			case sem <- struct{}{}:
+			default:
+				// The FlowSchema is at its cap; this counts as queuing
+				// even if fair queuing admitted the request directly.
+				if !queued {
+					queued = true
+					startWaitingTime = cfgCtlr.clock.Now()
+					if !cfgCtlr.suppressMetrics {
+						metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeQueued)
+					}
+					cfgCtlr.counters.noteQueued(pl.Name, fs.Name)
+				}
+				if !cfgCtlr.waitForFSSlot(ctx, sem, pl.Name) {
+					return
+				}
+			}
+			defer func() { <-sem }()
+		}
 		if queued {
-			observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
+			cfgCtlr.observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
+		}
+		if !cfgCtlr.suppressMetrics {
+			metrics.AddDispatch(ctx, pl.Name, fs.Name)
+			metrics.AddFlowSchemaOutcome(ctx, fs.Name, metrics.OutcomeDispatched)
 		}
-		metrics.AddDispatch(ctx, pl.Name, fs.Name)
+		cfgCtlr.counters.noteDispatched(pl.Name, fs.Name)
 		fqs.OnRequestDispatched(req)
 		executed = true
-		startExecutionTime := cfgCtlr.clock.Now()
+		startExecutionTime = cfgCtlr.clock.Now()
+		if b, lenders := cfgCtlr.borrowingStatus(pl.Name); b {
+			borrowedExecution, borrowedFrom = true, lenders
+			cfgCtlr.counters.noteBorrowed(pl.Name, fs.Name)
+		}
+		inflightEntry := inflightDebugEntry{
+			PriorityLevel:      pl.Name,
+			FlowSchema:         fs.Name,
+			User:               username,
+			TraceID:            continuum.TraceIDFrom(ctx),
+			StartExecutionTime: startExecutionTime.UnixNano(),
+		}
+		if requestDigest.RequestInfo != nil {
+			inflightEntry.Verb = requestDigest.RequestInfo.Verb
+			inflightEntry.Path = requestDigest.RequestInfo.Path
+		}
+		inflightID := cfgCtlr.inflight.add(inflightEntry)
+		defer cfgCtlr.inflight.remove(inflightID)
+		defer func() {
+			executionDuration = cfgCtlr.clock.Since(startExecutionTime)
+			if tracker, ok := blockedTrackerFrom(ctx); ok {
+				// Time the handler reported blocked on non-APF work,
+				// via MarkBlocked/MarkUnblocked, is not attributed to
+				// execution.
+				if executionDuration -= tracker.blockedDuration(); executionDuration < 0 {
+					executionDuration = 0
+				}
+			}
+			httplog.AddKeyValue(ctx, "apf_execution_time", executionDuration)
+			if !cfgCtlr.suppressMetrics {
+				metrics.ObserveExecutionDurationWithExemplar(ctx, pl.Name, fs.Name, executionDuration, continuum.TraceIDFrom(ctx))
+				if we, ok := fqs.RequestWorkEstimate(req); ok {
+					metrics.AddSeatSeconds(ctx, pl.Name, int(we.MaxSeats()), executionDuration)
+				}
+			}
+			cfgCtlr.latencies.noteExecution(fs.Name, executionDuration)
+		}()
+		// A panic in execFn is surfaced as its own event before being
+		// re-raised, so a run can detect handler crashes; the deferred
+		// accounting above still runs, and the server's own recovery
+		// keeps turning the panic into a 500.
 		defer func() {
-			executionTime := cfgCtlr.clock.Since(startExecutionTime)
-			httplog.AddKeyValue(ctx, "apf_execution_time", executionTime)
-			metrics.ObserveExecutionDuration(ctx, pl.Name, fs.Name, executionTime)
+			if r := recover(); r != nil {
+				now := cfgCtlr.clock.Now()
+				if !cfgCtlr.suppressMetrics {
+					metrics.AddExecutionPanic(ctx, pl.Name, fs.Name)
+				}
+				for _, mt := range matchedTracers {
+					mt.EmitMarker(continuum.Marker{ID: continuum.MarkerExecutionPanicked, Time: continuum.TimestampFrom(now), FlowSchema: fs.Name, PriorityLevel: pl.Name, RunID: runID, TraceID: mt.TraceID})
+				}
+				klog.Errorf("APF request execution panicked: fsName=%q, plName=%q, panic=%v", fs.Name, pl.Name, r)
+				panic(r)
+			}
 		}()
-		execFn()
+		if cfgCtlr.cpuAccounting {
+			// Pin the goroutine to its thread so that the two rusage
+			// readings refer to the same thread.
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			if startCPU, ok := continuum.ThreadCPUTime(); ok {
+				defer func() {
+					if endCPU, ok := continuum.ThreadCPUTime(); ok {
+						executionCPU = endCPU - startCPU
+						if !cfgCtlr.suppressMetrics {
+							metrics.ObserveExecutionCPU(ctx, pl.Name, fs.Name, executionCPU)
+						}
+					}
+				}()
+			}
+		}
+		if cfgCtlr.pprofLabeling {
+			// Labeling lets a CPU profile taken during a run
+			// attribute handler work to the flow that dispatched it.
+			pprof.Do(ctx, pprof.Labels("priority_level", pl.Name, "flow_schema", fs.Name), func(context.Context) {
+				execFn()
+			})
+		} else {
+			execFn()
+		}
 	})
 	if queued && !executed {
-		observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
+		cfgCtlr.observeQueueWaitTime(ctx, pl.Name, fs.Name, strconv.FormatBool(req != nil), cfgCtlr.clock.Since(startWaitingTime))
+	}
+	if !executed {
+		// The only way a request with a non-nil req fails to execute is
+		// being cancelled out of its queue before dispatch.
+		cfgCtlr.counters.noteRejected(pl.Name, fs.Name)
+		if cfgCtlr.onReject != nil {
+			cfgCtlr.onReject(requestDigest, "time-out")
+		}
 	}
 	panicking = false
 }
 
-func observeQueueWaitTime(ctx context.Context, priorityLevelName, flowSchemaName, execute string, waitTime time.Duration) {
-	metrics.ObserveWaitingDuration(ctx, priorityLevelName, flowSchemaName, execute, waitTime)
+// InjectSynthetic enqueues a synthetic request in the named priority
+// level, attributed to the named FlowSchema, occupying the estimated
+// seats for the hold duration.  Successive synthetic requests hash to
+// distinct flows so that they spread over the level's queues.  The
+// call blocks until the synthetic request finishes or is rejected;
+// run it in its own goroutine to fill a level with background load.
+// It exists for load testing and is reachable over HTTP only when
+// TestableConfig.EnableSyntheticInjection is set.
+func (cfgCtlr *configController) InjectSynthetic(plName, fsName string, work fcrequest.WorkEstimate, hold time.Duration) error {
+	cfgCtlr.lock.RLock()
+	plState := cfgCtlr.priorityLevelStates[plName]
+	if plState == nil {
+		cfgCtlr.lock.RUnlock()
+		return fmt.Errorf("no priority level named %q", plName)
+	}
+	if plState.pl.Spec.Type == flowcontrol.PriorityLevelEnablementExempt {
+		cfgCtlr.lock.RUnlock()
+		return fmt.Errorf("priority level %q is exempt and does not limit", plName)
+	}
+	flowDistinguisher := fmt.Sprintf("synthetic-%d", cfgCtlr.syntheticSeq.Add(1))
+	hashValue := hashFlowID(fsName, flowDistinguisher)
+	req, idle := plState.queues.StartRequest(context.Background(), &work, hashValue, flowDistinguisher, fsName, "synthetic request", "synthetic user", func(inQueue bool) {})
+	if idle {
+		cfgCtlr.maybeReapReadLocked(plName, plState)
+	}
+	cfgCtlr.lock.RUnlock()
+	if req == nil {
+		return fmt.Errorf("synthetic request rejected by priority level %q", plName)
+	}
+	var executed bool
+	idle = req.Finish(func() {
+		executed = true
+		time.Sleep(hold)
+	})
+	if idle {
+		cfgCtlr.maybeReap(plName)
+	}
+	if !executed {
+		return fmt.Errorf("synthetic request cancelled out of priority level %q", plName)
+	}
+	return nil
+}
+
+// fsFIFOGate serializes execution starts for one FlowSchema.  Each
+// request takes a ticket at classification time and may not begin
+// executing until every earlier ticket has either begun executing or
+// been abandoned.
+type fsFIFOGate struct {
+	lock sync.Mutex
+	cond sync.Cond
+	// next is the ticket the next arrival will take; turn is the
+	// ticket currently allowed to proceed.
+	next, turn uint64
+	// abandoned holds tickets whose requests ended before their turn
+	// arrived, so that the turn can skip past them; without this an
+	// early rejection would stall every later ticket forever.
+	abandoned map[uint64]bool
+}
+
+// take hands out the next ticket.
+func (g *fsFIFOGate) take() uint64 {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	t := g.next
+	g.next++
+	return t
+}
+
+// ready reports whether the given ticket may proceed without waiting.
+func (g *fsFIFOGate) ready(t uint64) bool {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.turn == t
+}
+
+// wait blocks until it is the given ticket's turn or the context is
+// done, and reports whether the turn arrived.
+func (g *fsFIFOGate) wait(ctx context.Context, t uint64) bool {
+	stop := context.AfterFunc(ctx, func() {
+		g.lock.Lock()
+		defer g.lock.Unlock()
+		g.cond.Broadcast()
+	})
+	defer stop()
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	for g.turn != t {
+		if ctx.Err() != nil {
+			return false
+		}
+		g.cond.Wait()
+	}
+	return true
+}
+
+// advance passes the turn on from the given ticket, if it holds the
+// turn.  It is called when the ticket's request begins executing.
+func (g *fsFIFOGate) advance(t uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.turn == t {
+		g.passTurnLocked()
+	}
+}
+
+// abandon is called when the ticket's Handle call returns.  If the
+// ticket's request never began executing — rejected on arrival, or
+// cancelled while waiting — the turn must not stop at the ticket: if
+// it holds the turn already, the turn passes on; otherwise the ticket
+// is recorded so that the turn skips it later.  For a request that
+// did execute this is a no-op, since advance already passed its turn.
+func (g *fsFIFOGate) abandon(t uint64) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.turn == t {
+		g.passTurnLocked()
+	} else if t > g.turn {
+		if g.abandoned == nil {
+			g.abandoned = map[uint64]bool{}
+		}
+		g.abandoned[t] = true
+	}
+}
+
+// passTurnLocked increments the turn, skipping past abandoned
+// tickets, and wakes the waiters.  The caller must hold the lock.
+func (g *fsFIFOGate) passTurnLocked() {
+	g.turn++
+	for g.abandoned[g.turn] {
+		delete(g.abandoned, g.turn)
+		g.turn++
+	}
+	g.cond.Broadcast()
+}
+
+// fsFIFOGate returns the gate serializing execution starts for the
+// named FlowSchema, or nil if the FlowSchema is not configured for
+// strict FIFO.  The gate is created on first use.
+func (cfgCtlr *configController) fsFIFOGate(fsName string) *fsFIFOGate {
+	if !cfgCtlr.strictFIFOByFS[fsName] {
+		return nil
+	}
+	cfgCtlr.fifoLock.Lock()
+	defer cfgCtlr.fifoLock.Unlock()
+	gate := cfgCtlr.fsFIFOGates[fsName]
+	if gate == nil {
+		if cfgCtlr.fsFIFOGates == nil {
+			cfgCtlr.fsFIFOGates = map[string]*fsFIFOGate{}
+		}
+		gate = &fsFIFOGate{}
+		gate.cond.L = &gate.lock
+		cfgCtlr.fsFIFOGates[fsName] = gate
+	}
+	return gate
+}
+
+// fsExecSemaphore returns the semaphore bounding concurrent
+// executions of the named FlowSchema, or nil if it has no cap.  The
+// semaphore is created on first use with the configured capacity.
+func (cfgCtlr *configController) fsExecSemaphore(fsName string) chan struct{} {
+	limit := cfgCtlr.maxExecutingByFS[fsName]
+	if limit <= 0 {
+		return nil
+	}
+	cfgCtlr.fsExecLock.Lock()
+	defer cfgCtlr.fsExecLock.Unlock()
+	sem := cfgCtlr.fsExecSemaphores[fsName]
+	if sem == nil {
+		if cfgCtlr.fsExecSemaphores == nil {
+			cfgCtlr.fsExecSemaphores = map[string]chan struct{}{}
+		}
+		sem = make(chan struct{}, limit)
+		cfgCtlr.fsExecSemaphores[fsName] = sem
+	}
+	return sem
+}
+
+// waitForFSSlot blocks until a slot in sem frees, the request's
+// context is done, or the priority level's wait limit passes, and
+// reports whether a slot was acquired.  The wait limit is measured
+// with the real clock because the injected PassiveClock cannot
+// produce timers.
+func (cfgCtlr *configController) waitForFSSlot(ctx context.Context, sem chan struct{}, plName string) bool {
+	var limitCh <-chan time.Time
+	if waitLimit := cfgCtlr.requestWaitLimitByPL[plName]; waitLimit > 0 {
+		timer := time.NewTimer(waitLimit)
+		defer timer.Stop()
+		limitCh = timer.C
+	}
+	select {
+	case sem <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-limitCh:
+		return false
+	}
+}
+
+func (cfgCtlr *configController) observeQueueWaitTime(ctx context.Context, priorityLevelName, flowSchemaName, execute string, waitTime time.Duration) {
+	if !cfgCtlr.suppressMetrics {
+		metrics.ObserveWaitingDurationWithExemplar(ctx, priorityLevelName, flowSchemaName, execute, waitTime, continuum.TraceIDFrom(ctx))
+		if eps := cfgCtlr.immediateDispatchEpsilon; eps > 0 && waitTime < eps {
+			metrics.AddImmediateDispatch(ctx, priorityLevelName, flowSchemaName)
+		}
+	}
 	endpointsrequest.TrackAPFQueueWaitLatency(ctx, waitTime)
+	cfgCtlr.latencies.noteWait(flowSchemaName, waitTime)
 }
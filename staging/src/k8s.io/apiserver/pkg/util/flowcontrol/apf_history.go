@@ -0,0 +1,116 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"strings"
+	"sync"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+)
+
+// lifecycleHistorySize bounds how many of the most recent lifecycle
+// events are retained for the trace_events debug endpoint.
+const lifecycleHistorySize = 1000
+
+// lifecycleHistory retains the most recent lifecycle events in a
+// fixed-size ring, so that the trace rule matching of historical
+// requests can be inspected after the fact.
+type lifecycleHistory struct {
+	lock   sync.Mutex
+	events []continuum.LifecycleEvent
+	next   int
+	full   bool
+}
+
+func (h *lifecycleHistory) add(ev continuum.LifecycleEvent) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.events == nil {
+		h.events = make([]continuum.LifecycleEvent, lifecycleHistorySize)
+	}
+	h.events[h.next] = ev
+	h.next = (h.next + 1) % len(h.events)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// traceEventDebugEntry is one stored lifecycle event in the
+// /debug/flowcontrol/trace_events response.
+type traceEventDebugEntry struct {
+	FlowSchema         string `json:"flowSchema"`
+	PriorityLevel      string `json:"priorityLevel"`
+	MatchedRule        string `json:"matchedRule"`
+	Queued             bool   `json:"queued"`
+	Executed           bool   `json:"executed"`
+	StartWaitingTime   int64  `json:"startWaitingTime,omitempty"`
+	StartExecutionTime int64  `json:"startExecutionTime,omitempty"`
+	WaitingDuration    string `json:"waitingDuration,omitempty"`
+	ExecutionDuration  string `json:"executionDuration,omitempty"`
+}
+
+// snapshot returns the retained events oldest first, keeping only
+// those whose matched-rule list contains the given qualified rule
+// name; the empty string keeps every event.
+func (h *lifecycleHistory) snapshot(rule string) []traceEventDebugEntry {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.events)
+	}
+	entries := make([]traceEventDebugEntry, 0, n)
+	for i := 0; i < n; i++ {
+		idx := i
+		if h.full {
+			idx = (h.next + i) % len(h.events)
+		}
+		ev := h.events[idx]
+		if rule != "" && !matchedRuleContains(ev.MatchedRule, rule) {
+			continue
+		}
+		entry := traceEventDebugEntry{
+			FlowSchema:         ev.FlowSchema,
+			PriorityLevel:      ev.PriorityLevel,
+			MatchedRule:        ev.MatchedRule,
+			Queued:             ev.Queued,
+			Executed:           ev.Executed,
+			StartWaitingTime:   ev.StartWaitingTime,
+			StartExecutionTime: ev.StartExecutionTime,
+		}
+		if ev.WaitingDuration != 0 {
+			entry.WaitingDuration = ev.WaitingDuration.String()
+		}
+		if ev.ExecutionDuration != 0 {
+			entry.ExecutionDuration = ev.ExecutionDuration.String()
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// matchedRuleContains reports whether the comma-separated matched-rule
+// list contains the given name; "none" is matchable like any other.
+func matchedRuleContains(list, rule string) bool {
+	for _, name := range strings.Split(list, ",") {
+		if name == rule {
+			return true
+		}
+	}
+	return false
+}
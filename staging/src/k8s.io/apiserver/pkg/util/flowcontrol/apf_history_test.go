@@ -0,0 +1,74 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+)
+
+func TestLifecycleHistoryFilter(t *testing.T) {
+	var h lifecycleHistory
+	h.add(continuum.LifecycleEvent{FlowSchema: "a", MatchedRule: "t/one"})
+	h.add(continuum.LifecycleEvent{FlowSchema: "b", MatchedRule: "t/one,t/two"})
+	h.add(continuum.LifecycleEvent{FlowSchema: "c", MatchedRule: "none"})
+
+	flowSchemas := func(entries []traceEventDebugEntry) []string {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.FlowSchema
+		}
+		return names
+	}
+
+	cases := []struct {
+		rule string
+		want []string
+	}{
+		{rule: "", want: []string{"a", "b", "c"}},
+		{rule: "t/one", want: []string{"a", "b"}},
+		{rule: "t/two", want: []string{"b"}},
+		{rule: "none", want: []string{"c"}},
+		{rule: "t/absent", want: []string{}},
+	}
+	for _, tc := range cases {
+		got := flowSchemas(h.snapshot(tc.rule))
+		if fmt.Sprint(got) != fmt.Sprint(tc.want) {
+			t.Errorf("rule %q: expected events %v, got %v", tc.rule, tc.want, got)
+		}
+	}
+}
+
+func TestLifecycleHistoryWraps(t *testing.T) {
+	var h lifecycleHistory
+	const extra = 10
+	for i := 0; i < lifecycleHistorySize+extra; i++ {
+		h.add(continuum.LifecycleEvent{FlowSchema: fmt.Sprintf("fs%d", i), MatchedRule: "none"})
+	}
+	got := h.snapshot("")
+	if len(got) != lifecycleHistorySize {
+		t.Fatalf("expected %d retained events, got %d", lifecycleHistorySize, len(got))
+	}
+	if got[0].FlowSchema != fmt.Sprintf("fs%d", extra) {
+		t.Errorf("expected the oldest retained event to be fs%d, got %s", extra, got[0].FlowSchema)
+	}
+	if got[len(got)-1].FlowSchema != fmt.Sprintf("fs%d", lifecycleHistorySize+extra-1) {
+		t.Errorf("expected the newest retained event to be fs%d, got %s", lifecycleHistorySize+extra-1, got[len(got)-1].FlowSchema)
+	}
+}
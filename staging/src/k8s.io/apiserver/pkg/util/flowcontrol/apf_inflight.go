@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+	"sync"
+)
+
+// inflightDebugEntry is one currently executing request in the
+// /debug/flowcontrol/inflight response.
+type inflightDebugEntry struct {
+	PriorityLevel string `json:"priorityLevel"`
+	FlowSchema    string `json:"flowSchema"`
+	Verb          string `json:"verb,omitempty"`
+	Path          string `json:"path,omitempty"`
+	User          string `json:"user,omitempty"`
+	TraceID       string `json:"traceID,omitempty"`
+
+	// StartExecutionTime is when the request began executing, in
+	// nanoseconds since the Unix epoch.
+	StartExecutionTime int64 `json:"startExecutionTime"`
+}
+
+// inflightRegistry tracks the requests currently executing, added at
+// dispatch and removed when execution ends, so the inflight debug
+// endpoint can show what is occupying seats right now.
+type inflightRegistry struct {
+	lock   sync.Mutex
+	nextID uint64
+	byID   map[uint64]inflightDebugEntry
+}
+
+// add registers an executing request and returns the token to remove
+// it with.
+func (r *inflightRegistry) add(entry inflightDebugEntry) uint64 {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.byID == nil {
+		r.byID = map[uint64]inflightDebugEntry{}
+	}
+	id := r.nextID
+	r.nextID++
+	r.byID[id] = entry
+	return id
+}
+
+// remove unregisters the request added under the given token.
+func (r *inflightRegistry) remove(id uint64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.byID, id)
+}
+
+// snapshot returns the currently executing requests, sorted by
+// priority level and then by execution start time, oldest first, so
+// a stuck handler shows up at the top of its level.
+func (r *inflightRegistry) snapshot() []inflightDebugEntry {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	entries := make([]inflightDebugEntry, 0, len(r.byID))
+	for _, entry := range r.byID {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].PriorityLevel != entries[j].PriorityLevel {
+			return entries[i].PriorityLevel < entries[j].PriorityLevel
+		}
+		return entries[i].StartExecutionTime < entries[j].StartExecutionTime
+	})
+	return entries
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestInflightRegistry(t *testing.T) {
+	var r inflightRegistry
+	if got := r.snapshot(); len(got) != 0 {
+		t.Errorf("expected an empty snapshot, got %v", got)
+	}
+	idA := r.add(inflightDebugEntry{PriorityLevel: "workload-low", FlowSchema: "b", StartExecutionTime: 200})
+	idB := r.add(inflightDebugEntry{PriorityLevel: "system", FlowSchema: "c", StartExecutionTime: 300})
+	idC := r.add(inflightDebugEntry{PriorityLevel: "workload-low", FlowSchema: "a", StartExecutionTime: 100})
+
+	flowSchemas := func(entries []inflightDebugEntry) []string {
+		names := make([]string, len(entries))
+		for i, entry := range entries {
+			names[i] = entry.FlowSchema
+		}
+		return names
+	}
+	if got, want := flowSchemas(r.snapshot()), []string{"c", "a", "b"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected order %v, got %v", want, got)
+	}
+
+	r.remove(idC)
+	if got, want := flowSchemas(r.snapshot()), []string{"c", "b"}; fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected %v after removal, got %v", want, got)
+	}
+	r.remove(idA)
+	r.remove(idB)
+	r.remove(idB) // removing twice is harmless
+	if got := r.snapshot(); len(got) != 0 {
+		t.Errorf("expected an empty snapshot after all removals, got %v", got)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// latencyReservoirSize bounds how many of the most recent samples of
+// each kind are retained per FlowSchema.
+const latencyReservoirSize = 1000
+
+// durationRing retains the most recent samples in a fixed-size ring.
+type durationRing struct {
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+func (r *durationRing) add(d time.Duration) {
+	if r.samples == nil {
+		r.samples = make([]time.Duration, latencyReservoirSize)
+	}
+	r.samples[r.next] = d
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// sorted returns the retained samples in ascending order.
+func (r *durationRing) sorted() []time.Duration {
+	n := r.next
+	if r.full {
+		n = len(r.samples)
+	}
+	out := append([]time.Duration(nil), r.samples[:n]...)
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// flowLatencies holds the recent wait and execution durations of one
+// FlowSchema.
+type flowLatencies struct {
+	wait      durationRing
+	execution durationRing
+}
+
+// apfLatencies retains a bounded window of recent per-FlowSchema wait
+// and execution durations, so that percentiles can be read directly
+// from a debug endpoint without a Prometheus scrape.
+type apfLatencies struct {
+	lock    sync.Mutex
+	perFlow map[string]*flowLatencies
+}
+
+// getLocked returns the latencies of the given FlowSchema, creating
+// them if needed.  l.lock must be held.
+func (l *apfLatencies) getLocked(fsName string) *flowLatencies {
+	if l.perFlow == nil {
+		l.perFlow = map[string]*flowLatencies{}
+	}
+	fl := l.perFlow[fsName]
+	if fl == nil {
+		fl = &flowLatencies{}
+		l.perFlow[fsName] = fl
+	}
+	return fl
+}
+
+func (l *apfLatencies) noteWait(fsName string, d time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.getLocked(fsName).wait.add(d)
+}
+
+func (l *apfLatencies) noteExecution(fsName string, d time.Duration) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.getLocked(fsName).execution.add(d)
+}
+
+// latencySummary reports percentiles over the retained samples of one
+// kind, rendered as Duration strings for direct human consumption.
+type latencySummary struct {
+	Count int    `json:"count"`
+	P50   string `json:"p50"`
+	P90   string `json:"p90"`
+	P99   string `json:"p99"`
+}
+
+// latencyDebugEntry is one FlowSchema in the
+// /debug/flowcontrol/latencies response.
+type latencyDebugEntry struct {
+	FlowSchema string          `json:"flowSchema"`
+	Wait       *latencySummary `json:"wait,omitempty"`
+	Execution  *latencySummary `json:"execution,omitempty"`
+}
+
+// percentile returns the nearest-rank q-th percentile of the given
+// ascending samples.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	idx := int(q*float64(len(sorted))+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= len(sorted) {
+		idx = len(sorted) - 1
+	}
+	return sorted[idx]
+}
+
+// summarize renders the ring's percentiles, or nil if it has no
+// samples yet.
+func summarize(r *durationRing) *latencySummary {
+	sorted := r.sorted()
+	if len(sorted) == 0 {
+		return nil
+	}
+	return &latencySummary{
+		Count: len(sorted),
+		P50:   percentile(sorted, 0.50).String(),
+		P90:   percentile(sorted, 0.90).String(),
+		P99:   percentile(sorted, 0.99).String(),
+	}
+}
+
+// snapshot returns the per-FlowSchema summaries, sorted by FlowSchema
+// name.
+func (l *apfLatencies) snapshot() []latencyDebugEntry {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	entries := make([]latencyDebugEntry, 0, len(l.perFlow))
+	for fsName, fl := range l.perFlow {
+		entries = append(entries, latencyDebugEntry{
+			FlowSchema: fsName,
+			Wait:       summarize(&fl.wait),
+			Execution:  summarize(&fl.execution),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].FlowSchema < entries[j].FlowSchema })
+	return entries
+}
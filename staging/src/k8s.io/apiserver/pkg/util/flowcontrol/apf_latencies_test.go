@@ -0,0 +1,69 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	var latencies apfLatencies
+	// 1ms..100ms, in an order that exercises the sort.
+	for i := 100; i >= 1; i-- {
+		latencies.noteWait("fs-a", time.Duration(i)*time.Millisecond)
+	}
+	latencies.noteExecution("fs-a", 7*time.Millisecond)
+	entries := latencies.snapshot()
+	if len(entries) != 1 || entries[0].FlowSchema != "fs-a" {
+		t.Fatalf("unexpected snapshot: %#v", entries)
+	}
+	wait := entries[0].Wait
+	if wait == nil || wait.Count != 100 {
+		t.Fatalf("unexpected wait summary: %#v", wait)
+	}
+	if wait.P50 != "50ms" || wait.P90 != "90ms" || wait.P99 != "99ms" {
+		t.Errorf("unexpected wait percentiles: %#v", wait)
+	}
+	execution := entries[0].Execution
+	if execution == nil || execution.Count != 1 || execution.P50 != "7ms" || execution.P99 != "7ms" {
+		t.Errorf("unexpected execution summary: %#v", execution)
+	}
+}
+
+func TestLatencyReservoirBounded(t *testing.T) {
+	var latencies apfLatencies
+	// More samples than the reservoir holds; only the most recent
+	// latencyReservoirSize may remain.
+	for i := 0; i < 3*latencyReservoirSize; i++ {
+		latencies.noteWait("fs-b", time.Duration(i)*time.Microsecond)
+	}
+	entries := latencies.snapshot()
+	if len(entries) != 1 {
+		t.Fatalf("unexpected snapshot: %#v", entries)
+	}
+	wait := entries[0].Wait
+	if wait == nil || wait.Count != latencyReservoirSize {
+		t.Fatalf("expected the reservoir to retain %d samples, got %#v", latencyReservoirSize, wait)
+	}
+	// Only the final window's samples remain, so even the median is
+	// above everything from the earlier windows.
+	min := time.Duration(2*latencyReservoirSize) * time.Microsecond
+	if p50, err := time.ParseDuration(wait.P50); err != nil || p50 < min {
+		t.Errorf("expected p50 of at least %v, got %q", min, wait.P50)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+	"sync"
+)
+
+// queueDepth is the current and high-water number of requests waiting
+// at one priority level.
+type queueDepth struct {
+	waiting int
+	max     int
+}
+
+// queueDepthTracker keeps, per priority level, the number of requests
+// currently waiting and the high-water mark of that number since
+// startup or the last reset.  It is maintained from the queue
+// transitions Handle observes rather than read out of the queuesets,
+// so one enqueue-path update covers fair queuing, FIFO gates, and
+// FlowSchema caps alike.  The running maximum is what sizes queues
+// after a benchmark; the instantaneous depth is usually back to zero
+// by the time anyone looks.
+type queueDepthTracker struct {
+	lock sync.Mutex
+	byPL map[string]*queueDepth
+}
+
+// getLocked returns the depth record for the given priority level,
+// creating it if needed.  t.lock must be held.
+func (t *queueDepthTracker) getLocked(plName string) *queueDepth {
+	if t.byPL == nil {
+		t.byPL = map[string]*queueDepth{}
+	}
+	d := t.byPL[plName]
+	if d == nil {
+		d = &queueDepth{}
+		t.byPL[plName] = d
+	}
+	return d
+}
+
+// noteEnqueued records one more request waiting at the priority level
+// and returns the new depth along with whether it is a fresh
+// high-water mark.
+func (t *queueDepthTracker) noteEnqueued(plName string) (int, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	d := t.getLocked(plName)
+	d.waiting++
+	if d.waiting > d.max {
+		d.max = d.waiting
+		return d.waiting, true
+	}
+	return d.waiting, false
+}
+
+// noteDequeued records one request leaving the priority level's
+// queues, whether it was dispatched or rejected.
+func (t *queueDepthTracker) noteDequeued(plName string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	d := t.getLocked(plName)
+	if d.waiting > 0 {
+		d.waiting--
+	}
+}
+
+// queueDepthDebugEntry is one priority level in the
+// /debug/flowcontrol/queue_depths response.
+type queueDepthDebugEntry struct {
+	PriorityLevel string `json:"priorityLevel"`
+	Waiting       int    `json:"waiting"`
+	MaxWaiting    int    `json:"maxWaiting"`
+}
+
+// snapshot returns the per-priority-level depths, sorted by name.  If
+// reset is true each high-water mark is set back to the current depth
+// in the same critical section, so no enqueue is lost between the
+// read and the clear; the returned entries are the pre-reset values.
+func (t *queueDepthTracker) snapshot(reset bool) []queueDepthDebugEntry {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entries := make([]queueDepthDebugEntry, 0, len(t.byPL))
+	for plName, d := range t.byPL {
+		entries = append(entries, queueDepthDebugEntry{
+			PriorityLevel: plName,
+			Waiting:       d.waiting,
+			MaxWaiting:    d.max,
+		})
+		if reset {
+			d.max = d.waiting
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PriorityLevel < entries[j].PriorityLevel
+	})
+	return entries
+}
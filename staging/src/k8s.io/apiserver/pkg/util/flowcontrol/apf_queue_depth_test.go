@@ -0,0 +1,62 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"testing"
+)
+
+func TestQueueDepthTracker(t *testing.T) {
+	var tr queueDepthTracker
+	enqueue := func(plName string, wantDepth int, wantNewMax bool) {
+		t.Helper()
+		depth, newMax := tr.noteEnqueued(plName)
+		if depth != wantDepth || newMax != wantNewMax {
+			t.Errorf("noteEnqueued(%q): expected (%d, %v), got (%d, %v)", plName, wantDepth, wantNewMax, depth, newMax)
+		}
+	}
+	enqueue("system", 1, true)
+	enqueue("system", 2, true)
+	tr.noteDequeued("system")
+	enqueue("system", 2, false)
+	enqueue("system", 3, true)
+	tr.noteDequeued("system")
+	tr.noteDequeued("system")
+	enqueue("global-default", 1, true)
+
+	entries := tr.snapshot(false)
+	if len(entries) != 2 || entries[0].PriorityLevel != "global-default" || entries[1].PriorityLevel != "system" {
+		t.Fatalf("expected entries sorted by priority level, got %#v", entries)
+	}
+	if entries[1].Waiting != 1 || entries[1].MaxWaiting != 3 {
+		t.Errorf("wrong depths for system: %#v", entries[1])
+	}
+
+	// Resetting pulls the high-water mark back to the current depth
+	// and returns the pre-reset values.
+	if entries := tr.snapshot(true); entries[1].MaxWaiting != 3 {
+		t.Errorf("expected the pre-reset mark in the reset response, got %#v", entries[1])
+	}
+	if entries := tr.snapshot(false); entries[1].MaxWaiting != 1 {
+		t.Errorf("expected the mark reset to the current depth, got %#v", entries[1])
+	}
+
+	tr.noteDequeued("absent")
+	if entries := tr.snapshot(false); entries[0].Waiting != 0 && entries[1].Waiting != 0 {
+		t.Errorf("expected depths never to go negative, got %#v", entries)
+	}
+}
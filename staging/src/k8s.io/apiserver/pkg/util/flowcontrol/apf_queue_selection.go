@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"sort"
+	"sync"
+)
+
+// queueSelectionTracker counts, per priority level, how many requests
+// were assigned to each queue index.  A flow's requests should spread
+// over the queues its hands cover; a histogram concentrated on a few
+// indices means the shuffle-sharding is not distributing as expected.
+// Maintained only while queue selection logging is enabled; see
+// TestableConfig.QueueSelectionLogging.
+type queueSelectionTracker struct {
+	lock sync.Mutex
+	byPL map[string]map[int]int
+}
+
+// note records one request assigned to the given queue index at the
+// given priority level.
+func (t *queueSelectionTracker) note(plName string, queueIndex int) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.byPL == nil {
+		t.byPL = map[string]map[int]int{}
+	}
+	counts := t.byPL[plName]
+	if counts == nil {
+		counts = map[int]int{}
+		t.byPL[plName] = counts
+	}
+	counts[queueIndex]++
+}
+
+// queueSelectionCount is one queue of one priority level in the
+// /debug/flowcontrol/queue_selections response.
+type queueSelectionCount struct {
+	QueueIndex int `json:"queueIndex"`
+	Requests   int `json:"requests"`
+}
+
+// queueSelectionDebugEntry is one priority level in the
+// /debug/flowcontrol/queue_selections response.  Queues that never
+// received a request are absent.
+type queueSelectionDebugEntry struct {
+	PriorityLevel string                `json:"priorityLevel"`
+	Queues        []queueSelectionCount `json:"queues"`
+}
+
+// snapshot returns the per-priority-level histograms, sorted by level
+// name and queue index.
+func (t *queueSelectionTracker) snapshot() []queueSelectionDebugEntry {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	entries := make([]queueSelectionDebugEntry, 0, len(t.byPL))
+	for plName, counts := range t.byPL {
+		entry := queueSelectionDebugEntry{PriorityLevel: plName, Queues: make([]queueSelectionCount, 0, len(counts))}
+		for queueIndex, requests := range counts {
+			entry.Queues = append(entry.Queues, queueSelectionCount{QueueIndex: queueIndex, Requests: requests})
+		}
+		sort.Slice(entry.Queues, func(i, j int) bool {
+			return entry.Queues[i].QueueIndex < entry.Queues[j].QueueIndex
+		})
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].PriorityLevel < entries[j].PriorityLevel
+	})
+	return entries
+}
@@ -0,0 +1,52 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// runtimeStatsSamplePeriod is how often the runtime stats attached to
+// lifecycle events are refreshed.  ReadMemStats stops the world, so
+// the sampling is periodic rather than per request; the attached
+// values may accordingly be up to one period old.
+const runtimeStatsSamplePeriod = time.Second
+
+// runtimeStats holds the most recent periodic sample of the Go
+// runtime state that lifecycle events report.
+type runtimeStats struct {
+	goroutines  atomic.Int64
+	lastGCPause atomic.Int64
+}
+
+// sample refreshes the held values from the runtime.
+func (s *runtimeStats) sample() {
+	s.goroutines.Store(int64(runtime.NumGoroutine()))
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	if m.NumGC > 0 {
+		s.lastGCPause.Store(int64(m.PauseNs[(m.NumGC+255)%256]))
+	}
+}
+
+// read returns the goroutine count and most recent GC pause from the
+// last sample.
+func (s *runtimeStats) read() (int, time.Duration) {
+	return int(s.goroutines.Load()), time.Duration(s.lastGCPause.Load())
+}
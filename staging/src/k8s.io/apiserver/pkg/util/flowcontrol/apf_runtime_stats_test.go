@@ -0,0 +1,38 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestRuntimeStatsSample(t *testing.T) {
+	var s runtimeStats
+	if goroutines, gcPause := s.read(); goroutines != 0 || gcPause != 0 {
+		t.Errorf("expected zero values before the first sample, got %d, %v", goroutines, gcPause)
+	}
+	runtime.GC()
+	s.sample()
+	goroutines, gcPause := s.read()
+	if goroutines <= 0 {
+		t.Errorf("expected a positive goroutine count, got %d", goroutines)
+	}
+	if gcPause <= 0 {
+		t.Errorf("expected a positive GC pause after a forced collection, got %v", gcPause)
+	}
+}
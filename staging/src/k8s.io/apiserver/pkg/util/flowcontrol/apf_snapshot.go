@@ -0,0 +1,110 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"sync"
+)
+
+// maxStoredSnapshots bounds how many named snapshots the snapshot
+// debug endpoint retains; capturing under a fresh name beyond this
+// fails, while recapturing under an existing name always succeeds.
+const maxStoredSnapshots = 16
+
+// flowSnapshot is the state the snapshot debug endpoint captures at
+// one instant: the accumulated per-flow counters and the
+// per-priority-level seat accounting.  In a diff response, Time is
+// the elapsed time between the two captures and the counter fields
+// are the differences.
+type flowSnapshot struct {
+	// Time is the capture time in nanoseconds since the Unix epoch,
+	// or, in a diff, the nanoseconds elapsed between the captures.
+	Time     int64                 `json:"time"`
+	Counters []counterDebugEntry   `json:"counters"`
+	Seats    []borrowingDebugEntry `json:"seats"`
+}
+
+// snapshotStore holds the named snapshots, bounded by
+// maxStoredSnapshots.
+type snapshotStore struct {
+	lock   sync.Mutex
+	byName map[string]flowSnapshot
+}
+
+// put stores the snapshot under the given name, replacing any
+// previous snapshot of that name.  It fails if the store is full and
+// the name is new.
+func (s *snapshotStore) put(name string, snap flowSnapshot) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.byName == nil {
+		s.byName = map[string]flowSnapshot{}
+	}
+	if _, exists := s.byName[name]; !exists && len(s.byName) >= maxStoredSnapshots {
+		return fmt.Errorf("already holding %d snapshots, not storing %q", len(s.byName), name)
+	}
+	s.byName[name] = snap
+	return nil
+}
+
+// get returns the snapshot stored under the given name, if any.
+func (s *snapshotStore) get(name string) (flowSnapshot, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	snap, ok := s.byName[name]
+	return snap, ok
+}
+
+// diffSnapshots returns the change from old to cur: elapsed time,
+// counter differences per flow, and seat differences per priority
+// level.  Flows and priority levels absent from old count as all
+// zero; ones absent from cur are omitted.
+func diffSnapshots(cur, old flowSnapshot) flowSnapshot {
+	oldCounters := make(map[counterKey]counterDebugEntry, len(old.Counters))
+	for _, e := range old.Counters {
+		oldCounters[counterKey{priorityLevel: e.PriorityLevel, flowSchema: e.FlowSchema}] = e
+	}
+	counters := make([]counterDebugEntry, 0, len(cur.Counters))
+	for _, e := range cur.Counters {
+		o := oldCounters[counterKey{priorityLevel: e.PriorityLevel, flowSchema: e.FlowSchema}]
+		e.Classified -= o.Classified
+		e.Queued -= o.Queued
+		e.Dispatched -= o.Dispatched
+		e.Borrowed -= o.Borrowed
+		e.Rejected -= o.Rejected
+		counters = append(counters, e)
+	}
+	oldSeats := make(map[string]borrowingDebugEntry, len(old.Seats))
+	for _, e := range old.Seats {
+		oldSeats[e.Name] = e
+	}
+	seats := make([]borrowingDebugEntry, 0, len(cur.Seats))
+	for _, e := range cur.Seats {
+		o := oldSeats[e.Name]
+		e.NominalCL -= o.NominalCL
+		e.CurrentCL -= o.CurrentCL
+		e.LentSeats -= o.LentSeats
+		e.BorrowedSeats -= o.BorrowedSeats
+		seats = append(seats, e)
+	}
+	return flowSnapshot{
+		Time:     cur.Time - old.Time,
+		Counters: counters,
+		Seats:    seats,
+	}
+}
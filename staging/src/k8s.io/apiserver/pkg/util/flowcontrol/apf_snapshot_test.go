@@ -0,0 +1,83 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSnapshotStoreBound(t *testing.T) {
+	var s snapshotStore
+	for i := 0; i < maxStoredSnapshots; i++ {
+		if err := s.put(fmt.Sprintf("snap%d", i), flowSnapshot{}); err != nil {
+			t.Fatalf("unexpected error storing snapshot %d: %v", i, err)
+		}
+	}
+	if err := s.put("one-too-many", flowSnapshot{}); err == nil {
+		t.Error("expected an error storing beyond the bound")
+	}
+	if err := s.put("snap0", flowSnapshot{Time: 42}); err != nil {
+		t.Errorf("unexpected error recapturing an existing name: %v", err)
+	}
+	if snap, ok := s.get("snap0"); !ok || snap.Time != 42 {
+		t.Errorf("expected the recaptured snapshot, got %v, %t", snap, ok)
+	}
+	if _, ok := s.get("absent"); ok {
+		t.Error("expected no snapshot under an absent name")
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	old := flowSnapshot{
+		Time: 1000,
+		Counters: []counterDebugEntry{
+			{PriorityLevel: "system", FlowSchema: "system-nodes", Classified: 5, Queued: 2, Dispatched: 4, Rejected: 1},
+		},
+		Seats: []borrowingDebugEntry{
+			{Name: "system", NominalCL: 10, CurrentCL: 10},
+		},
+	}
+	cur := flowSnapshot{
+		Time: 3000,
+		Counters: []counterDebugEntry{
+			{PriorityLevel: "system", FlowSchema: "system-nodes", Classified: 9, Queued: 3, Dispatched: 7, Rejected: 1},
+			{PriorityLevel: "workload-low", FlowSchema: "service-accounts", Classified: 2},
+		},
+		Seats: []borrowingDebugEntry{
+			{Name: "system", NominalCL: 10, CurrentCL: 12, BorrowedSeats: 2},
+		},
+	}
+
+	diff := diffSnapshots(cur, old)
+	if diff.Time != 2000 {
+		t.Errorf("expected elapsed time 2000, got %d", diff.Time)
+	}
+	want := []counterDebugEntry{
+		{PriorityLevel: "system", FlowSchema: "system-nodes", Classified: 4, Queued: 1, Dispatched: 3, Rejected: 0},
+		{PriorityLevel: "workload-low", FlowSchema: "service-accounts", Classified: 2},
+	}
+	if fmt.Sprint(diff.Counters) != fmt.Sprint(want) {
+		t.Errorf("expected counter diff %v, got %v", want, diff.Counters)
+	}
+	wantSeats := []borrowingDebugEntry{
+		{Name: "system", NominalCL: 0, CurrentCL: 2, BorrowedSeats: 2},
+	}
+	if fmt.Sprint(diff.Seats) != fmt.Sprint(wantSeats) {
+		t.Errorf("expected seat diff %v, got %v", wantSeats, diff.Seats)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// BinaryRecordVersion is the version byte of the binary marker record
+// written by a BinarySink.  A decoder refuses records with a version
+// it does not know.
+const BinaryRecordVersion = 1
+
+// BinaryRecordSize is the size of one binary marker record.  Each
+// record is fixed-width, little-endian, and laid out as:
+//
+//	offset  0: version, one byte (BinaryRecordVersion)
+//	offset  1: marker ID, uint16
+//	offset  3: time in nanoseconds since the Unix epoch, int64
+//	offset 11: trace id, 16 bytes; see the flag bits below
+//	offset 27: flags, uint64
+//
+// A 32-hex-digit trace id (the W3C trace-context form) is stored as
+// its 16 decoded bytes with binaryFlagTraceIDHex set; any other trace
+// id is stored as raw bytes, zero-padded, and truncated to 16 bytes
+// with binaryFlagTraceIDTruncated set if longer.  The flow schema,
+// priority level, and run id are deliberately not recorded; the
+// format trades them away to keep the hot path to a fixed-width copy.
+const BinaryRecordSize = 35
+
+const (
+	// binaryFlagTraceIDHex marks a trace id field holding the 16
+	// decoded bytes of a 32-hex-digit id.
+	binaryFlagTraceIDHex = 1 << 0
+	// binaryFlagTraceIDTruncated marks a trace id that was longer
+	// than 16 bytes and lost its tail.
+	binaryFlagTraceIDTruncated = 1 << 1
+)
+
+// binaryRecord encodes one marker in the layout documented at
+// BinaryRecordSize.
+func binaryRecord(m Marker) [BinaryRecordSize]byte {
+	var rec [BinaryRecordSize]byte
+	rec[0] = BinaryRecordVersion
+	binary.LittleEndian.PutUint16(rec[1:3], uint16(m.ID))
+	binary.LittleEndian.PutUint64(rec[3:11], uint64(m.Time))
+	var flags uint64
+	if raw, err := hex.DecodeString(m.TraceID); err == nil && len(raw) == 16 {
+		copy(rec[11:27], raw)
+		flags |= binaryFlagTraceIDHex
+	} else {
+		if len(m.TraceID) > 16 {
+			flags |= binaryFlagTraceIDTruncated
+		}
+		copy(rec[11:27], m.TraceID)
+	}
+	binary.LittleEndian.PutUint64(rec[27:35], flags)
+	return rec
+}
+
+// ReadBinaryMarker reads one binary marker record from r and returns
+// the decoded marker.  It returns io.EOF at a clean record boundary
+// and io.ErrUnexpectedEOF if the stream ends mid-record.  A truncated
+// trace id comes back without its lost tail; the other marker fields
+// are not part of the format and come back empty.
+func ReadBinaryMarker(r io.Reader) (Marker, error) {
+	var rec [BinaryRecordSize]byte
+	if _, err := io.ReadFull(r, rec[:]); err != nil {
+		return Marker{}, err
+	}
+	if rec[0] != BinaryRecordVersion {
+		return Marker{}, fmt.Errorf("unknown continuum binary record version %d", rec[0])
+	}
+	m := Marker{
+		ID:   int(binary.LittleEndian.Uint16(rec[1:3])),
+		Time: int64(binary.LittleEndian.Uint64(rec[3:11])),
+	}
+	flags := binary.LittleEndian.Uint64(rec[27:35])
+	if flags&binaryFlagTraceIDHex != 0 {
+		m.TraceID = hex.EncodeToString(rec[11:27])
+	} else {
+		end := 27
+		for end > 11 && rec[end-1] == 0 {
+			end--
+		}
+		m.TraceID = string(rec[11:end])
+	}
+	return m, nil
+}
+
+// BinarySink writes fixed-width binary marker records to an io.Writer,
+// typically a file handed to an offline decoder later; see
+// ReadBinaryMarker for the format.  Like the SocketSink, a single
+// goroutine drains a bounded channel; if the channel is full the
+// marker is dropped and counted instead of blocking the caller.
+type BinarySink struct {
+	w       io.Writer
+	records chan [BinaryRecordSize]byte
+	quit    chan struct{}
+	flushed chan struct{}
+	closed  atomic.Bool
+	dropped atomic.Uint64
+}
+
+// NewBinarySink writes binary marker records to w, buffering up to
+// bufferSize records.  The caller retains ownership of w and may
+// close it once Close has returned.
+func NewBinarySink(w io.Writer, bufferSize int) *BinarySink {
+	s := &BinarySink{
+		w:       w,
+		records: make(chan [BinaryRecordSize]byte, bufferSize),
+		quit:    make(chan struct{}),
+		flushed: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements MarkerSink.  It never blocks; if the buffer is
+// full or the sink is closed the marker is dropped and counted.
+func (s *BinarySink) Write(m Marker) {
+	if s.closed.Load() {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.records <- binaryRecord(m):
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of markers dropped because the sink
+// could not keep up or had been closed.
+func (s *BinarySink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close drains the records already buffered into the writer.  It does
+// not close the writer.  Markers written after Close are dropped.
+func (s *BinarySink) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	close(s.quit)
+	<-s.flushed
+	return nil
+}
+
+func (s *BinarySink) run() {
+	defer close(s.flushed)
+	for {
+		select {
+		case rec := <-s.records:
+			s.writeOne(rec)
+		case <-s.quit:
+			for {
+				select {
+				case rec := <-s.records:
+					s.writeOne(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *BinarySink) writeOne(rec [BinaryRecordSize]byte) {
+	if _, err := s.w.Write(rec[:]); err != nil {
+		s.dropped.Add(1)
+	}
+}
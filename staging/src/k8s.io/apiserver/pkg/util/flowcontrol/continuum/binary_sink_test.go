@@ -0,0 +1,94 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestBinarySinkRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewBinarySink(&buf, 16)
+	written := []Marker{
+		{ID: MarkerPodCreateEnter, Time: 1234567890, TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"},
+		{ID: MarkerPodCreateFinish, Time: 1234567999, TraceID: "short"},
+		{ID: MarkerRequestDeadlineExceeded, Time: 1234568111},
+	}
+	for _, m := range written {
+		sink.Write(m)
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close binary sink: %v", err)
+	}
+	if got, want := buf.Len(), len(written)*BinaryRecordSize; got != want {
+		t.Fatalf("expected %d bytes, got %d", want, got)
+	}
+
+	for i, want := range written {
+		got, err := ReadBinaryMarker(&buf)
+		if err != nil {
+			t.Fatalf("failed to decode record %d: %v", i, err)
+		}
+		if got.ID != want.ID || got.Time != want.Time || got.TraceID != want.TraceID {
+			t.Errorf("record %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+	if _, err := ReadBinaryMarker(&buf); err != io.EOF {
+		t.Errorf("expected io.EOF at the end of the stream, got %v", err)
+	}
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Errorf("expected no drops, got %d", dropped)
+	}
+
+	sink.Write(Marker{ID: MarkerPodCreateEnter})
+	if dropped := sink.Dropped(); dropped != 1 {
+		t.Errorf("expected a write after Close to be dropped, got %d drops", dropped)
+	}
+}
+
+func TestBinarySinkTruncatesLongTraceIDs(t *testing.T) {
+	var buf bytes.Buffer
+	sink := NewBinarySink(&buf, 1)
+	sink.Write(Marker{ID: MarkerPodCreateEnter, TraceID: "a-trace-id-well-beyond-sixteen-bytes"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close binary sink: %v", err)
+	}
+	got, err := ReadBinaryMarker(&buf)
+	if err != nil {
+		t.Fatalf("failed to decode the record: %v", err)
+	}
+	if got.TraceID != "a-trace-id-well-" {
+		t.Errorf("expected the trace id truncated to 16 bytes, got %q", got.TraceID)
+	}
+}
+
+func TestReadBinaryMarkerErrors(t *testing.T) {
+	short := make([]byte, BinaryRecordSize-1)
+	short[0] = BinaryRecordVersion
+	if _, err := ReadBinaryMarker(bytes.NewReader(short)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected io.ErrUnexpectedEOF for a partial record, got %v", err)
+	}
+
+	bad := make([]byte, BinaryRecordSize)
+	bad[0] = BinaryRecordVersion + 1
+	if _, err := ReadBinaryMarker(bytes.NewReader(bad)); err == nil {
+		t.Error("expected an error for an unknown record version")
+	}
+}
@@ -0,0 +1,359 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package continuum emits timeline markers for requests passing through
+// the API Priority and Fairness filter.  Markers carry absolute
+// wall-clock timestamps in nanoseconds since the Unix epoch, rather
+// than only durations, so that events recorded by different apiserver
+// replicas can be aligned on one timeline given synchronized clocks.
+//
+// Emission is compiled in only under the "continuum" build tag; see
+// Enabled.  Without the tag the emitting functions are no-ops and no
+// request is matched for tracing, so a production binary pays nothing
+// for the instrumentation.
+package continuum
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Well-known marker identifiers.  Each traced flow uses a pair of
+// identifiers: the even one marks entry into the filter, the following
+// odd one marks completion.
+const (
+	// MarkerJobCreateEnter and MarkerJobCreateFinish bracket the
+	// handling of a request to create a Job.
+	MarkerJobCreateEnter  = 200
+	MarkerJobCreateFinish = 201
+
+	// MarkerPodCreateEnter and MarkerPodCreateFinish bracket the
+	// handling of a request to create a Pod.
+	MarkerPodCreateEnter  = 204
+	MarkerPodCreateFinish = 205
+
+	// MarkerLeaseRenewEnter and MarkerLeaseRenewFinish bracket the
+	// handling of a leader-election lease create or update from a
+	// control-plane component, making the background contention of
+	// that continuous traffic visible on the timeline.
+	MarkerLeaseRenewEnter  = 206
+	MarkerLeaseRenewFinish = 207
+
+	// MarkerCancelledWhileQueued marks a request dropping out of its
+	// queue because its context was cancelled before it was
+	// dispatched, e.g. because the client disconnected or timed out.
+	MarkerCancelledWhileQueued = 210
+
+	// MarkerRequestDeadlineExceeded marks a request whose total time
+	// in the filter — queue wait plus execution — exceeded the
+	// filter-imposed maximum; see TestableConfig.MaxRequestDuration.
+	// It is emitted whether the bound cut the request off while
+	// queued or while executing.
+	MarkerRequestDeadlineExceeded = 211
+
+	// MarkerRequestEnqueued and MarkerRequestDequeued bracket a traced
+	// request's time in a queue, as reported through the queue-note
+	// callback.  Enqueueing is emitted as its own event, distinct from
+	// classification, so the classification-to-enqueue latency is
+	// measurable; requests dispatched without queuing emit neither.
+	MarkerRequestEnqueued = 212
+	MarkerRequestDequeued = 213
+
+	// MarkerForceRejected marks a request rejected because its
+	// priority level had the reject-all flag set through the debug
+	// endpoint, simulating that level being completely unavailable.
+	MarkerForceRejected = 214
+
+	// MarkerExecutionPanicked marks a request whose execution
+	// panicked.  The panic is re-raised after emission, so the
+	// server's own recovery still turns it into a 500; the marker is
+	// what lets a run detect handler crashes.  It is odd and thus a
+	// finish marker, since the request did reach execution.
+	MarkerExecutionPanicked = 215
+
+	// MarkerDanglingStatusChanged marks a FlowSchema's Dangling
+	// condition flipping, i.e. its referenced PriorityLevelConfiguration
+	// appearing or disappearing.
+	MarkerDanglingStatusChanged = 220
+
+	// MarkerConfigDigestionPaused and MarkerConfigDigestionResumed
+	// bracket an interval during which config digestion was frozen
+	// through the debug endpoints, so the classification configuration
+	// was held stable.
+	MarkerConfigDigestionPaused  = 222
+	MarkerConfigDigestionResumed = 223
+
+	// MarkerConfigDigestionLagging marks a config change whose
+	// digestion began only after the change had been pending for
+	// longer than the configured threshold; see
+	// TestableConfig.DigestionLagThreshold.  Time paused through the
+	// debug endpoints counts as pending.
+	MarkerConfigDigestionLagging = 224
+
+	// MarkerWatchRegistered and MarkerWatchUnregistered mark a watch
+	// being added to or removed from the watch tracker that feeds
+	// work estimation for mutating requests.  They occupy the config
+	// range because they describe system state rather than the
+	// handling of a traced request; the accompanying log line carries
+	// the resource and the resulting active-watch count.
+	MarkerWatchRegistered   = 226
+	MarkerWatchUnregistered = 227
+
+	// MarkerRequestEnter and MarkerRequestFinish bracket the handling
+	// of any traced request, regardless of what it asks for.  They are
+	// the marker pair for catch-all rules, such as the one feeding the
+	// events debug endpoint.
+	MarkerRequestEnter  = 230
+	MarkerRequestFinish = 231
+)
+
+// MarkerCategory groups markers for purposes that apply to a whole
+// class of them at once, such as choosing a log verbosity; see
+// SetMarkerLogVerbosity.
+type MarkerCategory string
+
+const (
+	// CategoryEnter covers markers emitted when something begins:
+	// rule enter markers and the enqueued marker.
+	CategoryEnter MarkerCategory = "enter"
+
+	// CategoryFinish covers markers emitted when something completes:
+	// rule finish markers and the dequeued marker.
+	CategoryFinish MarkerCategory = "finish"
+
+	// CategoryReject covers markers for requests that did not get to
+	// execute, such as cancellation while queued.
+	CategoryReject MarkerCategory = "reject"
+
+	// CategoryConfig covers markers about the configuration rather
+	// than an individual request: dangling-status flips and the
+	// digestion pause/resume/lag markers.
+	CategoryConfig MarkerCategory = "config"
+)
+
+// categoryOf maps a marker identifier to its category.  Config
+// markers occupy the 220s; among the per-request markers the
+// cancellation, deadline, and force-reject markers count as
+// rejections and the remaining ones follow the even-enter/odd-finish
+// pairing convention.
+func categoryOf(id int) MarkerCategory {
+	switch {
+	case id == MarkerCancelledWhileQueued, id == MarkerRequestDeadlineExceeded, id == MarkerForceRejected:
+		return CategoryReject
+	case id >= MarkerDanglingStatusChanged && id < MarkerRequestEnter:
+		return CategoryConfig
+	case id%2 == 0:
+		return CategoryEnter
+	default:
+		return CategoryFinish
+	}
+}
+
+// Marker is a single timestamped event in the handling of a request.
+type Marker struct {
+	// ID identifies what the marker marks; see the constants above.
+	ID int
+
+	// Time is the absolute wall-clock time of the event, in
+	// nanoseconds since the Unix epoch.
+	Time int64
+
+	// FlowSchema and PriorityLevel are the names resulting from
+	// request classification.
+	FlowSchema    string
+	PriorityLevel string
+
+	// RunID is the benchmark run the request belongs to, as supplied
+	// by the client through the run-id header; empty for markers not
+	// tied to a run.
+	RunID string
+
+	// TraceID is the externally supplied trace id of the request, or
+	// empty if none was supplied.
+	TraceID string
+}
+
+// Category returns the category of the marker's ID.
+func (m Marker) Category() MarkerCategory {
+	return categoryOf(m.ID)
+}
+
+// LifecycleEvent summarizes the queueing and execution of one request.
+// It reports both the absolute start timestamps and the derived
+// durations; the former are what allow cross-replica timeline
+// reconstruction.
+type LifecycleEvent struct {
+	FlowSchema    string
+	PriorityLevel string
+
+	// MatchedRule names the trace rules that matched the request, as
+	// "tracer/rule" comma-separated in match order, or "none" when no
+	// rule matched.  With overlapping rules every match appears, which
+	// is what makes the overlap visible.
+	MatchedRule string
+
+	// Queued indicates whether the request spent any time waiting in a
+	// queue.
+	Queued bool
+
+	// StartWaitingTime is the absolute time at which the request
+	// started waiting in its queue, in nanoseconds since the Unix
+	// epoch, or zero if the request never waited.
+	StartWaitingTime int64
+
+	// StartExecutionTime is the absolute time at which execution of
+	// the request began, in nanoseconds since the Unix epoch, or zero
+	// if the request was rejected without executing.
+	StartExecutionTime int64
+
+	// WaitingDuration and ExecutionDuration are the corresponding
+	// relative measurements; zero for phases the request did not go
+	// through.
+	WaitingDuration   time.Duration
+	ExecutionDuration time.Duration
+
+	// ExecutionCPU is the CPU time (user plus system) consumed by the
+	// executing thread during the execution phase, or zero if CPU
+	// accounting is disabled or unavailable.  It excludes work handed
+	// off to other goroutines; comparing it with ExecutionDuration
+	// separates genuine handler work from scheduling contention.
+	ExecutionCPU time.Duration
+
+	// Borrowed indicates that the request's priority level was
+	// operating above its nominal concurrency limit, on seats
+	// borrowed from other levels, when the request began executing.
+	// BorrowedFrom then names the levels that were lending seats at
+	// that moment, sorted.  Borrowing is a periodic adjustment of the
+	// per-level limits, so this describes the allocation in force at
+	// dispatch rather than the provenance of this request's own
+	// seats; it explains how a level admitted more than its nominal
+	// share.
+	Borrowed     bool
+	BorrowedFrom []string
+
+	// Labels are the key/value pairs copied from the request headers
+	// configured via SetHeaderLabels, or nil if the request carried
+	// none of them.
+	Labels map[string]string
+
+	// Goroutines is the number of goroutines in the process and
+	// LastGCPause the duration of the most recent garbage-collection
+	// pause, both read from a periodic runtime-stats sample rather
+	// than per request.  They are zero when runtime-stats accounting
+	// is disabled, and help separate latency caused by the Go runtime
+	// from latency caused by queuing.
+	Goroutines  int
+	LastGCPause time.Duration
+
+	// WaitLimit is the effective maximum queue wait imposed on the
+	// request by its priority level, or zero if the controller imposes
+	// no bound beyond the request's own context.
+	WaitLimit time.Duration
+
+	// Executed is false if the request was rejected.
+	Executed bool
+
+	// ShuffleShardHand is the set of queue indices dealt for the
+	// request when it was shuffle-sharded into a queue, and QueueIndex
+	// is the index that was chosen from that hand.  These are
+	// populated only for queued requests and only when Verbose
+	// tracing is enabled; otherwise ShuffleShardHand is nil.
+	ShuffleShardHand []int
+	QueueIndex       int
+}
+
+// Verbose indicates whether expensive-to-gather detail, such as the
+// shuffle-sharding hand of each queued request, should be collected
+// and emitted.  It is tied to klog verbosity level 6, matching the
+// level at which the queueset logs its queue choices.
+func Verbose() bool {
+	return klog.V(6).Enabled()
+}
+
+// TimeNanos renders t as nanoseconds since the Unix epoch, mapping the
+// zero time to zero.
+func TimeNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// monotonicReference is the instant captured when the process
+// started, against which monotonic timestamps are measured.
+var monotonicReference = time.Now()
+
+// monotonicTimestamps selects how TimestampFrom renders instants; see
+// UseMonotonicTimestamps.
+var monotonicTimestamps bool
+
+// UseMonotonicTimestamps selects between wall-clock marker timestamps
+// (the default) and offsets from a monotonic reference captured at
+// process start.  Wall timestamps allow aligning markers from
+// different replicas on one timeline but are subject to NTP jumps;
+// monotonic offsets measure durations precisely within one process.
+// Like SetMarkerSink, this must be called before the filter serves
+// traffic.
+func UseMonotonicTimestamps(enable bool) {
+	monotonicTimestamps = enable
+}
+
+// TimestampFrom renders the given instant, typically read from the
+// caller's clock.PassiveClock, as a marker timestamp.  By default
+// that is nanoseconds since the Unix epoch; in monotonic mode it is
+// the offset from the process-start reference, computed through the
+// monotonic clock reading when the instant carries one.
+func TimestampFrom(t time.Time) int64 {
+	if monotonicTimestamps {
+		return t.Sub(monotonicReference).Nanoseconds()
+	}
+	return t.UnixNano()
+}
+
+// DanglingStatusChange records a FlowSchema's Dangling condition
+// flipping during a run, e.g. because its referenced
+// PriorityLevelConfiguration was deleted or created.
+type DanglingStatusChange struct {
+	// FlowSchema is the name of the FlowSchema whose status flipped.
+	FlowSchema string
+
+	// Time is the marker timestamp of the flip; see TimestampFrom.
+	Time int64
+
+	// Old and New are the previous and current values of the
+	// condition's status.  Old is empty the first time the status is
+	// computed.
+	Old, New string
+}
+
+// WatchTrackChange describes a watch being registered in or removed
+// from the watch tracker that feeds work estimation for mutating
+// requests.
+type WatchTrackChange struct {
+	// Registered is true for a registration and false for a removal.
+	Registered bool
+
+	// Time is the marker timestamp of the change; see TimestampFrom.
+	Time int64
+
+	// Group and Resource identify what the watch watches.
+	Group, Resource string
+
+	// ActiveCount is the number of watches the tracker holds for the
+	// same identifier after the change.
+	ActiveCount int
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimestampFrom(t *testing.T) {
+	instant := monotonicReference.Add(3 * time.Second)
+	if got, want := TimestampFrom(instant), instant.UnixNano(); got != want {
+		t.Errorf("wall mode: got %d, want %d", got, want)
+	}
+	UseMonotonicTimestamps(true)
+	defer UseMonotonicTimestamps(false)
+	if got, want := TimestampFrom(instant), (3 * time.Second).Nanoseconds(); got != want {
+		t.Errorf("monotonic mode: got %d, want %d", got, want)
+	}
+}
+
+func TestCategoryOf(t *testing.T) {
+	cases := []struct {
+		id   int
+		want MarkerCategory
+	}{
+		{MarkerJobCreateEnter, CategoryEnter},
+		{MarkerJobCreateFinish, CategoryFinish},
+		{MarkerRequestEnqueued, CategoryEnter},
+		{MarkerRequestDequeued, CategoryFinish},
+		{MarkerCancelledWhileQueued, CategoryReject},
+		{MarkerRequestDeadlineExceeded, CategoryReject},
+		{MarkerForceRejected, CategoryReject},
+		{MarkerExecutionPanicked, CategoryFinish},
+		{MarkerDanglingStatusChanged, CategoryConfig},
+		{MarkerConfigDigestionPaused, CategoryConfig},
+		{MarkerConfigDigestionResumed, CategoryConfig},
+		{MarkerConfigDigestionLagging, CategoryConfig},
+		{MarkerWatchRegistered, CategoryConfig},
+		{MarkerWatchUnregistered, CategoryConfig},
+		{MarkerRequestEnter, CategoryEnter},
+		{MarkerRequestFinish, CategoryFinish},
+	}
+	for _, tc := range cases {
+		if got := categoryOf(tc.id); got != tc.want {
+			t.Errorf("categoryOf(%d): expected %q, got %q", tc.id, tc.want, got)
+		}
+	}
+}
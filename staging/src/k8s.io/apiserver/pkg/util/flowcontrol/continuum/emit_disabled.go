@@ -0,0 +1,54 @@
+//go:build !continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Enabled reports whether the binary was built with the continuum
+// build tag; in this build it was not, so the emitting functions
+// below are no-ops and MatchTracers matches nothing.
+const Enabled = false
+
+// MatchTracers matches nothing in a build without the continuum tag,
+// which keeps the filter from wrapping any request for tracing.
+func MatchTracers(ri *request.RequestInfo, username, authenticatedUsername string) []MatchedRule {
+	return nil
+}
+
+// EmitMarker is a no-op in a build without the continuum tag.
+func EmitMarker(m Marker) {}
+
+// EmitDanglingStatusChange is a no-op in a build without the
+// continuum tag.
+func EmitDanglingStatusChange(c DanglingStatusChange) {}
+
+// EmitWatchTrackChange is a no-op in a build without the continuum
+// tag.
+func EmitWatchTrackChange(c WatchTrackChange) {}
+
+// EmitLifecycleEvent is a no-op in a build without the continuum tag.
+func EmitLifecycleEvent(ev LifecycleEvent) {}
+
+// EmitSpan is a no-op in a build without the continuum tag.
+func EmitSpan(s Span) {}
+
+// RecordSpan is a no-op in a build without the continuum tag.
+func RecordSpan(name string, startNanos int64) {}
@@ -0,0 +1,123 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"k8s.io/klog/v2"
+)
+
+// Enabled reports whether the binary was built with the continuum
+// build tag.  Without it the emitting functions in this file compile
+// to no-ops and MatchTracers matches nothing, so the filter's hot
+// path carries no tracing overhead.
+const Enabled = true
+
+// MatchTracers evaluates each registered tracer's ruleset against the
+// request described by ri and the effective and authenticated
+// usernames, in registration order, and returns one MatchedRule for
+// each tracer that matched.  A tracer in flow discovery mode matches
+// only the first request of each distinct flow, plus its periodic
+// heartbeats; see FirstSeenHeartbeat.
+func MatchTracers(ri *request.RequestInfo, username, authenticatedUsername string) []MatchedRule {
+	var matched []MatchedRule
+	for _, t := range tracers {
+		if rule := MatchRule(t.Rules, ri, username, authenticatedUsername); rule != nil {
+			if t.FirstSeenHeartbeat > 0 {
+				emit, first := t.shouldEmitFirstSeen(time.Now(), ri, username)
+				if !emit {
+					continue
+				}
+				if first {
+					klog.Infof("CONTINUUM first-seen tracer=%q verb=%q resource=%q user=%q", t.Name, ri.Verb, ri.Resource, username)
+				}
+			}
+			matched = append(matched, MatchedRule{Tracer: t, Rule: rule})
+		}
+	}
+	return matched
+}
+
+// EmitMarker writes the given marker to the configured sink, which is
+// the log unless replaced via SetMarkerSink.
+func EmitMarker(m Marker) {
+	sink.Write(m)
+}
+
+// EmitDanglingStatusChange writes the given status change to the log,
+// along with a marker to the configured sink.
+func EmitDanglingStatusChange(c DanglingStatusChange) {
+	EmitMarker(Marker{ID: MarkerDanglingStatusChanged, Time: c.Time, FlowSchema: c.FlowSchema})
+	klog.Infof("CONTINUUM dangling flowSchema=%q time=%d old=%q new=%q", c.FlowSchema, c.Time, c.Old, c.New)
+}
+
+// EmitWatchTrackChange writes the given watch-tracker change to the
+// log, along with a marker to the configured sink.
+func EmitWatchTrackChange(c WatchTrackChange) {
+	id := MarkerWatchUnregistered
+	if c.Registered {
+		id = MarkerWatchRegistered
+	}
+	EmitMarker(Marker{ID: id, Time: c.Time})
+	klog.Infof("CONTINUUM watch registered=%t time=%d group=%q resource=%q active=%d", c.Registered, c.Time, c.Group, c.Resource, c.ActiveCount)
+}
+
+// EmitLifecycleEvent writes the given lifecycle event to the log and
+// records the waiting and execution phases as spans, so that a
+// replaced span sink sees them alongside spans recorded by external
+// wrappers via RecordSpan.
+func EmitLifecycleEvent(ev LifecycleEvent) {
+	if ev.Queued {
+		EmitSpan(Span{Name: "apf/wait/" + ev.FlowSchema, Start: ev.StartWaitingTime, End: ev.StartWaitingTime + ev.WaitingDuration.Nanoseconds()})
+	}
+	if ev.Executed {
+		EmitSpan(Span{Name: "apf/execute/" + ev.FlowSchema, Start: ev.StartExecutionTime, End: ev.StartExecutionTime + ev.ExecutionDuration.Nanoseconds()})
+	}
+	msg := fmt.Sprintf("CONTINUUM lifecycle flowSchema=%q priorityLevel=%q queued=%t startWaitingTime=%d startExecutionTime=%d waitingDuration=%d executionDuration=%d executionCPU=%d waitLimit=%d executed=%t",
+		ev.FlowSchema, ev.PriorityLevel, ev.Queued, ev.StartWaitingTime, ev.StartExecutionTime, ev.WaitingDuration.Nanoseconds(), ev.ExecutionDuration.Nanoseconds(), ev.ExecutionCPU.Nanoseconds(), ev.WaitLimit.Nanoseconds(), ev.Executed)
+	if ev.Borrowed {
+		msg += fmt.Sprintf(" borrowed=true borrowedFrom=%v", ev.BorrowedFrom)
+	}
+	if ev.Labels != nil {
+		msg += fmt.Sprintf(" labels=%v", ev.Labels)
+	}
+	if ev.Goroutines > 0 {
+		msg += fmt.Sprintf(" goroutines=%d lastGCPause=%d", ev.Goroutines, ev.LastGCPause.Nanoseconds())
+	}
+	if ev.ShuffleShardHand != nil {
+		msg += fmt.Sprintf(" hand=%v queueIndex=%d", ev.ShuffleShardHand, ev.QueueIndex)
+	}
+	klog.Info(msg)
+}
+
+// EmitSpan writes the given span to the configured sink, for callers
+// that already know both endpoints.
+func EmitSpan(s Span) {
+	spanSink.Record(s)
+}
+
+// RecordSpan records a span that began at startNanos (as returned by
+// Now or TimestampFrom) and ends now.
+func RecordSpan(name string, startNanos int64) {
+	spanSink.Record(Span{Name: name, Start: startNanos, End: Now()})
+}
@@ -0,0 +1,191 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// markerFileEntry is the JSON shape of one marker in a FileSink's
+// output, one object per line.
+type markerFileEntry struct {
+	Marker        int    `json:"marker"`
+	Time          int64  `json:"time"`
+	FlowSchema    string `json:"flowSchema,omitempty"`
+	PriorityLevel string `json:"priorityLevel,omitempty"`
+	RunID         string `json:"runID,omitempty"`
+	TraceID       string `json:"traceID,omitempty"`
+}
+
+// FileSink writes one JSON object per marker to files in a directory,
+// starting a new file whenever the current one reaches a size limit.
+// Capturing to files decouples the trace from container stdout, which
+// log drivers may truncate or rate-limit.  Like the SocketSink, a
+// single goroutine drains a bounded channel; if the channel is full
+// the marker is dropped and counted instead of blocking the caller,
+// so a disk stall never adds request latency.
+type FileSink struct {
+	dir      string
+	maxBytes int64
+
+	markers chan Marker
+	quit    chan struct{}
+	flushed chan struct{}
+	closed  atomic.Bool
+	dropped atomic.Uint64
+
+	// The following are owned by the draining goroutine.
+	file     *os.File
+	written  int64
+	nextSeq  int
+	closeErr error
+}
+
+// NewFileSink writes markers into the given directory, rotating to a
+// new file once the current one reaches maxFileBytes, and buffering
+// up to bufferSize markers.  The directory must exist.
+func NewFileSink(dir string, maxFileBytes int64, bufferSize int) (*FileSink, error) {
+	s := &FileSink{
+		dir:      dir,
+		maxBytes: maxFileBytes,
+		markers:  make(chan Marker, bufferSize),
+		quit:     make(chan struct{}),
+		flushed:  make(chan struct{}),
+	}
+	// Open the first file here so that a bad directory surfaces as a
+	// construction error rather than as dropped markers later.
+	if err := s.rotate(); err != nil {
+		return nil, err
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write implements MarkerSink.  It never blocks; if the buffer is
+// full or the sink is closed the marker is dropped and counted.
+func (s *FileSink) Write(m Marker) {
+	if s.closed.Load() {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.markers <- m:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of markers dropped because the sink
+// could not keep up or had been closed.
+func (s *FileSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close drains the markers already buffered, syncs the current file
+// to disk, and closes it.  Markers written after Close are dropped.
+func (s *FileSink) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	close(s.quit)
+	<-s.flushed
+	return s.closeErr
+}
+
+func (s *FileSink) run() {
+	defer close(s.flushed)
+	for {
+		select {
+		case m := <-s.markers:
+			s.writeOne(m)
+		case <-s.quit:
+			for {
+				select {
+				case m := <-s.markers:
+					s.writeOne(m)
+				default:
+					s.closeErr = s.closeFile()
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeOne appends one marker to the current file, rotating first if
+// the file has reached the size limit.
+func (s *FileSink) writeOne(m Marker) {
+	if s.written >= s.maxBytes {
+		if err := s.rotate(); err != nil {
+			s.dropped.Add(1)
+			return
+		}
+	}
+	line, err := json.Marshal(markerFileEntry{
+		Marker:        m.ID,
+		Time:          m.Time,
+		FlowSchema:    m.FlowSchema,
+		PriorityLevel: m.PriorityLevel,
+		RunID:         m.RunID,
+		TraceID:       m.TraceID,
+	})
+	if err != nil {
+		s.dropped.Add(1)
+		return
+	}
+	line = append(line, '\n')
+	n, err := s.file.Write(line)
+	s.written += int64(n)
+	if err != nil {
+		s.dropped.Add(1)
+	}
+}
+
+// rotate syncs and closes the current file, if any, and opens the
+// next one in the sequence.
+func (s *FileSink) rotate() error {
+	if err := s.closeFile(); err != nil {
+		return err
+	}
+	name := filepath.Join(s.dir, fmt.Sprintf("markers-%06d.jsonl", s.nextSeq))
+	file, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open continuum marker file %q: %w", name, err)
+	}
+	s.file = file
+	s.written = 0
+	s.nextSeq++
+	return nil
+}
+
+// closeFile syncs the current file to disk and closes it.
+func (s *FileSink) closeFile() error {
+	if s.file == nil {
+		return nil
+	}
+	syncErr := s.file.Sync()
+	closeErr := s.file.Close()
+	s.file = nil
+	if syncErr != nil {
+		return syncErr
+	}
+	return closeErr
+}
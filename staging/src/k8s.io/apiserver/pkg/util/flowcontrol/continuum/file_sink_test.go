@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileSinkWritesJSONLines(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewFileSink(dir, 1<<20, 16)
+	if err != nil {
+		t.Fatalf("failed to create file sink: %v", err)
+	}
+	sink.Write(Marker{ID: MarkerPodCreateEnter, Time: 1234567890, FlowSchema: "fs", PriorityLevel: "pl", RunID: "run-1"})
+	sink.Write(Marker{ID: MarkerPodCreateFinish, Time: 1234567999, FlowSchema: "fs", PriorityLevel: "pl"})
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close file sink: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "markers-000000.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to read marker file: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), string(data))
+	}
+	var entry markerFileEntry
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("first line is not valid JSON: %v", err)
+	}
+	want := markerFileEntry{Marker: MarkerPodCreateEnter, Time: 1234567890, FlowSchema: "fs", PriorityLevel: "pl", RunID: "run-1"}
+	if entry != want {
+		t.Errorf("expected first entry %+v, got %+v", want, entry)
+	}
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Errorf("expected no drops, got %d", dropped)
+	}
+
+	sink.Write(Marker{ID: MarkerPodCreateEnter})
+	if dropped := sink.Dropped(); dropped != 1 {
+		t.Errorf("expected a write after Close to be dropped, got %d drops", dropped)
+	}
+}
+
+func TestFileSinkRotates(t *testing.T) {
+	dir := t.TempDir()
+	// Each line is several dozen bytes, so a tiny limit forces a
+	// rotation after every line.
+	sink, err := NewFileSink(dir, 1, 16)
+	if err != nil {
+		t.Fatalf("failed to create file sink: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		sink.Write(Marker{ID: MarkerPodCreateEnter, Time: int64(i)})
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close file sink: %v", err)
+	}
+	names, err := filepath.Glob(filepath.Join(dir, "markers-*.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to list marker files: %v", err)
+	}
+	if len(names) != 3 {
+		t.Errorf("expected 3 files after rotation, got %v", names)
+	}
+}
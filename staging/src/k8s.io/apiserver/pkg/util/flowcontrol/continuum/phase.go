@@ -0,0 +1,42 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import "sync/atomic"
+
+// runPhase names the current phase of the benchmark run.  Unlike the
+// configuration set before the filter serves traffic, the phase
+// changes while requests are in flight — a harness advances it from
+// warmup to measurement mid-run — so it is read atomically on the
+// match path.
+var runPhase atomic.Value
+
+// SetRunPhase names the current phase of the benchmark run, e.g.
+// "warmup", "measure", or "cooldown".  Rules constrained by Phases
+// match only while the current phase is one of theirs; the empty
+// string clears the phase.  The run-phase debug endpoint calls this
+// on behalf of a harness.
+func SetRunPhase(phase string) {
+	runPhase.Store(phase)
+}
+
+// RunPhase returns the current run phase, or the empty string when
+// none is set.
+func RunPhase() string {
+	phase, _ := runPhase.Load().(string)
+	return phase
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"strings"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// Rule selects requests to trace and names the markers emitted for
+// them.  All predicate lists are conjunctive: a request matches the
+// rule only if every non-empty list contains the corresponding
+// attribute of the request.  An empty list leaves its attribute
+// unconstrained.
+type Rule struct {
+	// Name identifies the rule in logs and debug output.
+	Name string
+
+	// Verbs restricts the request verbs matched, e.g. "create".
+	Verbs []string
+
+	// Mutating, if non-nil, restricts matching by whether the verb
+	// changes object state: true selects create, update, patch,
+	// delete, and deletecollection; false selects everything else.
+	// This spares rules from enumerating verbs when all mutations (or
+	// all reads) are of interest.
+	Mutating *bool
+
+	// APIGroups restricts the API group of the resource; the core
+	// group is the empty string.  Matching on group (and version)
+	// disambiguates resources that share a name across groups.
+	APIGroups []string
+
+	// APIVersions restricts the API version of the resource, e.g. "v1".
+	APIVersions []string
+
+	// Resources restricts the resource name, e.g. "jobs".
+	Resources []string
+
+	// Subresources restricts the subresource; use [""] to match only
+	// requests to the main resource.
+	Subresources []string
+
+	// Users restricts the username of the requester, e.g.
+	// "system:kube-scheduler".  This is the effective identity the
+	// filter classifies on — when the request is impersonated, the
+	// impersonation filter has already replaced the authenticated
+	// user by the time the digest is built, so Users matches the
+	// impersonated one.  A rule constraining users never matches a
+	// request whose username is unknown.
+	Users []string
+
+	// AuthenticatedUsers restricts the username the requester
+	// authenticated as, before any impersonation took effect; for a
+	// request without impersonation it is the same identity Users
+	// matches.  A rule constraining authenticated users never matches
+	// a request whose authenticated username is unknown.
+	AuthenticatedUsers []string
+
+	// PathPrefixes restricts the request path to those starting with
+	// one of the given prefixes, e.g. "/healthz".  Non-resource
+	// requests, whose RequestInfo carries a meaningful Path but empty
+	// resource fields, are matched only by rules that constrain the
+	// path (and possibly the verb) and nothing else; a rule without
+	// path prefixes never matches them.
+	PathPrefixes []string
+
+	// Phases restricts the rule to the named run phases, e.g.
+	// "warmup" or "measure"; see SetRunPhase.  A rule with phases
+	// matches only while the current phase is one of them, which lets
+	// a harness capture markers during the measurement window alone.
+	// Empty means the rule applies in every phase, including when no
+	// phase is set.
+	Phases []string
+
+	// BaseMarker identifies the marker pair for this rule: the base
+	// (even) value is emitted when a matching request enters the
+	// filter and base+1 when it leaves, following the 0200/0201
+	// convention.
+	BaseMarker int
+}
+
+// EnterMarker returns the marker emitted when a matching request
+// enters the filter.
+func (r *Rule) EnterMarker() int {
+	return r.BaseMarker
+}
+
+// FinishMarker returns the marker emitted when a matching request
+// leaves the filter.
+func (r *Rule) FinishMarker() int {
+	return r.BaseMarker + 1
+}
+
+// Matches tells whether the rule selects the given request, made by
+// the given effective and authenticated usernames (empty if unknown).
+// Non-resource requests are matched by path prefix, verb, and user
+// only.
+func (r *Rule) Matches(ri *request.RequestInfo, username, authenticatedUsername string) bool {
+	if ri == nil {
+		return false
+	}
+	if len(r.Phases) > 0 && !matchOne(r.Phases, RunPhase()) {
+		return false
+	}
+	if len(r.Users) > 0 && !matchOne(r.Users, username) {
+		return false
+	}
+	if len(r.AuthenticatedUsers) > 0 && !matchOne(r.AuthenticatedUsers, authenticatedUsername) {
+		return false
+	}
+	if !ri.IsResourceRequest {
+		// A rule constraining any resource attribute cannot be
+		// satisfied by a request that has none.
+		if len(r.APIGroups)+len(r.APIVersions)+len(r.Resources)+len(r.Subresources) > 0 {
+			return false
+		}
+		return len(r.PathPrefixes) > 0 &&
+			matchPrefix(r.PathPrefixes, ri.Path) &&
+			matchOne(r.Verbs, ri.Verb) &&
+			matchMutating(r.Mutating, ri.Verb)
+	}
+	return matchOne(r.Verbs, ri.Verb) &&
+		matchMutating(r.Mutating, ri.Verb) &&
+		matchOne(r.APIGroups, ri.APIGroup) &&
+		matchOne(r.APIVersions, ri.APIVersion) &&
+		matchOne(r.Resources, ri.Resource) &&
+		matchOne(r.Subresources, ri.Subresource) &&
+		(len(r.PathPrefixes) == 0 || matchPrefix(r.PathPrefixes, ri.Path))
+}
+
+// mutatingVerbs are the request verbs that change object state.
+var mutatingVerbs = map[string]bool{
+	"create":           true,
+	"update":           true,
+	"patch":            true,
+	"delete":           true,
+	"deletecollection": true,
+}
+
+// matchMutating tells whether want is nil or agrees with whether verb
+// mutates.
+func matchMutating(want *bool, verb string) bool {
+	return want == nil || mutatingVerbs[verb] == *want
+}
+
+// matchPrefix tells whether one of the given prefixes starts path.
+func matchPrefix(prefixes []string, path string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchOne tells whether list is empty or contains x.
+func matchOne(list []string, x string) bool {
+	if len(list) == 0 {
+		return true
+	}
+	for _, y := range list {
+		if x == y {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchedRule carries the result of one rule evaluation through the
+// handling of a request, so that the finish emission reuses the rule
+// chosen at entry instead of re-matching.
+type MatchedRule struct {
+	// Tracer is the tracer whose ruleset produced the match.
+	Tracer *RequestTracer
+
+	// Rule is the matched rule, or nil if the request is not traced.
+	Rule *Rule
+
+	// TraceID optionally correlates this request's markers with a
+	// trace supplied from outside, e.g. propagated by the client; it
+	// is empty when no such correlation exists.
+	TraceID string
+}
+
+// MatchRule returns the first rule in rules that matches the request
+// described by ri and the two usernames, or nil if none does.
+func MatchRule(rules []Rule, ri *request.RequestInfo, username, authenticatedUsername string) *Rule {
+	for i := range rules {
+		if rules[i].Matches(ri, username, authenticatedUsername) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// DefaultRules returns the built-in ruleset, covering the job and pod
+// create flows and the control-plane leader-election renewals that
+// continuously compete with them for seats.
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			Name:         "job-create",
+			Verbs:        []string{"create"},
+			APIGroups:    []string{"batch"},
+			APIVersions:  []string{"v1"},
+			Resources:    []string{"jobs"},
+			Subresources: []string{""},
+			BaseMarker:   MarkerJobCreateEnter,
+		},
+		{
+			Name:         "pod-create",
+			Verbs:        []string{"create"},
+			APIGroups:    []string{""},
+			APIVersions:  []string{"v1"},
+			Resources:    []string{"pods"},
+			Subresources: []string{""},
+			BaseMarker:   MarkerPodCreateEnter,
+		},
+		{
+			Name:         "lease-renew",
+			Verbs:        []string{"create", "update"},
+			APIGroups:    []string{"coordination.k8s.io"},
+			Resources:    []string{"leases"},
+			Subresources: []string{""},
+			Users:        []string{"system:kube-controller-manager", "system:kube-scheduler"},
+			BaseMarker:   MarkerLeaseRenewEnter,
+		},
+	}
+}
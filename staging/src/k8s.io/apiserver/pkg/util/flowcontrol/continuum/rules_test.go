@@ -0,0 +1,291 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"testing"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestMatchRule(t *testing.T) {
+	testCases := []struct {
+		name        string
+		requestInfo *request.RequestInfo
+		expectRule  string
+	}{{
+		name:        "job create matches job-create",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "batch", APIVersion: "v1", Resource: "jobs"},
+		expectRule:  "job-create",
+	}, {
+		name:        "pod create matches pod-create",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "", APIVersion: "v1", Resource: "pods"},
+		expectRule:  "pod-create",
+	}, {
+		name:        "CRD sharing the jobs resource name does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "example.com", APIVersion: "v1", Resource: "jobs"},
+	}, {
+		name:        "other version of jobs does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "batch", APIVersion: "v2alpha1", Resource: "jobs"},
+	}, {
+		name:        "subresource does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "batch", APIVersion: "v1", Resource: "jobs", Subresource: "status"},
+	}, {
+		name:        "wrong verb does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "get", APIGroup: "batch", APIVersion: "v1", Resource: "jobs"},
+	}, {
+		name:        "non-resource request does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: false, Verb: "get", Path: "/healthz"},
+	}, {
+		name: "nil request info does not match",
+	}}
+	rules := DefaultRules()
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rule := MatchRule(rules, testCase.requestInfo, "", "")
+			if testCase.expectRule == "" {
+				if rule != nil {
+					t.Errorf("expected no rule to match, got %q", rule.Name)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("expected rule %q to match, got none", testCase.expectRule)
+			}
+			if rule.Name != testCase.expectRule {
+				t.Errorf("expected rule %q to match, got %q", testCase.expectRule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatchRuleByUser(t *testing.T) {
+	leaseUpdate := &request.RequestInfo{IsResourceRequest: true, Verb: "update", APIGroup: "coordination.k8s.io", APIVersion: "v1", Resource: "leases"}
+	rules := DefaultRules()
+	testCases := []struct {
+		name       string
+		username   string
+		expectRule string
+	}{{
+		name:       "scheduler lease renewal matches lease-renew",
+		username:   "system:kube-scheduler",
+		expectRule: "lease-renew",
+	}, {
+		name:       "controller-manager lease renewal matches lease-renew",
+		username:   "system:kube-controller-manager",
+		expectRule: "lease-renew",
+	}, {
+		name:     "another user's lease update does not match",
+		username: "system:node:worker-1",
+	}, {
+		name: "unknown user does not match",
+	}}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rule := MatchRule(rules, leaseUpdate, testCase.username, testCase.username)
+			if testCase.expectRule == "" {
+				if rule != nil {
+					t.Errorf("expected no rule to match, got %q", rule.Name)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("expected rule %q to match, got none", testCase.expectRule)
+			}
+			if rule.Name != testCase.expectRule {
+				t.Errorf("expected rule %q to match, got %q", testCase.expectRule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatchRuleByMutating(t *testing.T) {
+	mutating := true
+	readOnly := false
+	rules := []Rule{{
+		Name:       "pod-mutations",
+		Resources:  []string{"pods"},
+		Mutating:   &mutating,
+		BaseMarker: MarkerPodCreateEnter,
+	}, {
+		Name:       "pod-reads",
+		Resources:  []string{"pods"},
+		Mutating:   &readOnly,
+		BaseMarker: MarkerJobCreateEnter,
+	}}
+	testCases := []struct {
+		verb       string
+		expectRule string
+	}{
+		{verb: "create", expectRule: "pod-mutations"},
+		{verb: "update", expectRule: "pod-mutations"},
+		{verb: "patch", expectRule: "pod-mutations"},
+		{verb: "delete", expectRule: "pod-mutations"},
+		{verb: "deletecollection", expectRule: "pod-mutations"},
+		{verb: "get", expectRule: "pod-reads"},
+		{verb: "list", expectRule: "pod-reads"},
+		{verb: "watch", expectRule: "pod-reads"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.verb, func(t *testing.T) {
+			ri := &request.RequestInfo{IsResourceRequest: true, Verb: testCase.verb, Resource: "pods"}
+			rule := MatchRule(rules, ri, "", "")
+			if rule == nil {
+				t.Fatalf("expected rule %q to match, got none", testCase.expectRule)
+			}
+			if rule.Name != testCase.expectRule {
+				t.Errorf("expected rule %q to match, got %q", testCase.expectRule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatchRuleByPathPrefix(t *testing.T) {
+	rules := []Rule{{
+		Name:         "healthz",
+		PathPrefixes: []string{"/healthz", "/readyz"},
+		BaseMarker:   MarkerPodCreateEnter,
+	}, {
+		Name:         "pod-get",
+		Verbs:        []string{"get"},
+		Resources:    []string{"pods"},
+		PathPrefixes: []string{"/api/v1/namespaces/kube-system"},
+		BaseMarker:   MarkerJobCreateEnter,
+	}}
+	testCases := []struct {
+		name        string
+		requestInfo *request.RequestInfo
+		expectRule  string
+	}{{
+		name:        "healthz matches by prefix",
+		requestInfo: &request.RequestInfo{IsResourceRequest: false, Verb: "get", Path: "/healthz"},
+		expectRule:  "healthz",
+	}, {
+		name:        "subpath of a prefix matches",
+		requestInfo: &request.RequestInfo{IsResourceRequest: false, Verb: "get", Path: "/readyz/poststarthook"},
+		expectRule:  "healthz",
+	}, {
+		name:        "unlisted path does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: false, Verb: "get", Path: "/metrics"},
+	}, {
+		name:        "resource request constrained by both resource and path",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "get", Resource: "pods", Path: "/api/v1/namespaces/kube-system/pods/foo"},
+		expectRule:  "pod-get",
+	}, {
+		name:        "resource request outside the path prefix does not match",
+		requestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "get", Resource: "pods", Path: "/api/v1/namespaces/default/pods/foo"},
+	}, {
+		name:        "non-resource request does not match a resource rule",
+		requestInfo: &request.RequestInfo{IsResourceRequest: false, Verb: "get", Path: "/api/v1/namespaces/kube-system"},
+	}}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rule := MatchRule(rules, testCase.requestInfo, "", "")
+			if testCase.expectRule == "" {
+				if rule != nil {
+					t.Errorf("expected no rule to match, got %q", rule.Name)
+				}
+				return
+			}
+			if rule == nil {
+				t.Fatalf("expected rule %q to match, got none", testCase.expectRule)
+			}
+			if rule.Name != testCase.expectRule {
+				t.Errorf("expected rule %q to match, got %q", testCase.expectRule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatchRuleByPhase(t *testing.T) {
+	defer SetRunPhase("")
+	rules := []Rule{{
+		Name:       "measured-pod-creates",
+		Verbs:      []string{"create"},
+		Resources:  []string{"pods"},
+		Phases:     []string{"measure"},
+		BaseMarker: MarkerPodCreateEnter,
+	}, {
+		Name:       "all-pod-creates",
+		Verbs:      []string{"create"},
+		Resources:  []string{"pods"},
+		BaseMarker: MarkerJobCreateEnter,
+	}}
+	ri := &request.RequestInfo{IsResourceRequest: true, Verb: "create", Resource: "pods"}
+	testCases := []struct {
+		phase      string
+		expectRule string
+	}{
+		{phase: "", expectRule: "all-pod-creates"},
+		{phase: "warmup", expectRule: "all-pod-creates"},
+		{phase: "measure", expectRule: "measured-pod-creates"},
+		{phase: "cooldown", expectRule: "all-pod-creates"},
+	}
+	for _, testCase := range testCases {
+		t.Run("phase="+testCase.phase, func(t *testing.T) {
+			SetRunPhase(testCase.phase)
+			rule := MatchRule(rules, ri, "", "")
+			if rule == nil {
+				t.Fatalf("expected rule %q to match, got none", testCase.expectRule)
+			}
+			if rule.Name != testCase.expectRule {
+				t.Errorf("expected rule %q to match, got %q", testCase.expectRule, rule.Name)
+			}
+		})
+	}
+}
+
+func TestMatchRuleByAuthenticatedUser(t *testing.T) {
+	rules := []Rule{
+		{
+			Name:               "impersonated-by-admin",
+			Resources:          []string{"pods"},
+			AuthenticatedUsers: []string{"admin"},
+			BaseMarker:         MarkerPodCreateEnter,
+		},
+		{
+			Name:       "alice-effective",
+			Resources:  []string{"pods"},
+			Users:      []string{"alice"},
+			BaseMarker: MarkerRequestEnter,
+		},
+	}
+	podCreate := &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods"}
+	testCases := []struct {
+		name          string
+		username      string
+		authenticated string
+		expectedRule  string
+	}{
+		{"impersonated request matches the authenticated identity", "alice", "admin", "impersonated-by-admin"},
+		{"non-impersonated request falls through to the effective rule", "alice", "alice", "alice-effective"},
+		{"unknown authenticated identity never matches", "alice", "", "alice-effective"},
+		{"neither identity matches", "bob", "carol", ""},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rule := MatchRule(rules, podCreate, testCase.username, testCase.authenticated)
+			got := ""
+			if rule != nil {
+				got = rule.Name
+			}
+			if got != testCase.expectedRule {
+				t.Errorf("expected rule %q, got %q", testCase.expectedRule, got)
+			}
+		})
+	}
+}
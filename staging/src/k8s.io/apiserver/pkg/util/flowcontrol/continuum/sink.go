@@ -0,0 +1,182 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"k8s.io/klog/v2"
+)
+
+// MarkerSink consumes emitted markers.  Implementations must not
+// block; a sink that cannot keep up is expected to drop markers
+// rather than delay request processing.
+type MarkerSink interface {
+	Write(m Marker)
+}
+
+// sink is where EmitMarker sends markers.  It defaults to the log and
+// may be replaced once at startup via SetMarkerSink, before the
+// filter serves traffic.
+var sink MarkerSink = logSink{}
+
+// SetMarkerSink replaces the destination of emitted markers.  It must
+// be called before the filter starts handling requests.
+func SetMarkerSink(s MarkerSink) {
+	sink = s
+}
+
+// markerLogVerbosity maps marker categories to the klog verbosity at
+// which the log-based sink writes them; see SetMarkerLogVerbosity.
+// Categories without an entry log unconditionally, as verbosity 0.
+var markerLogVerbosity = map[MarkerCategory]klog.Level{}
+
+// SetMarkerLogVerbosity assigns the klog verbosity level at which the
+// log-based sink writes markers of each given category, so that e.g.
+// reject markers can stay at verbosity 0 while the enter and finish
+// markers of every traced request move to a higher level.  It only
+// affects the log-based sink, and, like SetMarkerSink, must be called
+// before the filter serves traffic.
+func SetMarkerLogVerbosity(byCategory map[MarkerCategory]klog.Level) {
+	markerLogVerbosity = byCategory
+}
+
+// logSink writes markers to the log; this is the default sink.  Each
+// marker logs at the verbosity configured for its category.
+type logSink struct{}
+
+func (logSink) Write(m Marker) {
+	if !klog.V(markerLogVerbosity[categoryOf(m.ID)]).Enabled() {
+		return
+	}
+	msg := fmt.Sprintf("CONTINUUM marker=%04d time=%d flowSchema=%q priorityLevel=%q", m.ID, m.Time, m.FlowSchema, m.PriorityLevel)
+	if m.RunID != "" {
+		msg += fmt.Sprintf(" runID=%q", m.RunID)
+	}
+	if m.TraceID != "" {
+		msg += fmt.Sprintf(" traceID=%q", m.TraceID)
+	}
+	klog.Info(msg)
+}
+
+// markerRecordSize is the size of one binary marker record written by
+// a SocketSink: a little-endian uint32 marker ID, a little-endian
+// int64 timestamp in nanoseconds since the Unix epoch, and four
+// reserved bytes (zero today) for a future trace identifier.
+const markerRecordSize = 16
+
+// SocketSink writes fixed-size binary marker records to a Unix domain
+// socket, avoiding the formatting overhead of the log-based sink.  A
+// single goroutine drains a bounded channel to the socket; if the
+// channel is full the marker is dropped and counted instead of
+// blocking the caller.
+type SocketSink struct {
+	conn    net.Conn
+	records chan [markerRecordSize]byte
+	quit    chan struct{}
+	flushed chan struct{}
+	closed  atomic.Bool
+	dropped atomic.Uint64
+}
+
+// NewSocketSink connects to the Unix domain socket at the given path
+// and returns a SocketSink buffering up to bufferSize records.
+func NewSocketSink(path string, bufferSize int) (*SocketSink, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect continuum socket sink to %q: %w", path, err)
+	}
+	return newSocketSink(conn, bufferSize), nil
+}
+
+// newSocketSink wraps an established connection; split out so that
+// tests can supply an in-memory pipe.
+func newSocketSink(conn net.Conn, bufferSize int) *SocketSink {
+	s := &SocketSink{
+		conn:    conn,
+		records: make(chan [markerRecordSize]byte, bufferSize),
+		quit:    make(chan struct{}),
+		flushed: make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write implements MarkerSink.  It never blocks; if the buffer is
+// full or the sink is closed the marker is dropped and counted.
+func (s *SocketSink) Write(m Marker) {
+	if s.closed.Load() {
+		s.dropped.Add(1)
+		return
+	}
+	var rec [markerRecordSize]byte
+	binary.LittleEndian.PutUint32(rec[0:4], uint32(m.ID))
+	binary.LittleEndian.PutUint64(rec[4:12], uint64(m.Time))
+	select {
+	case s.records <- rec:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// Dropped returns the number of markers dropped because the sink
+// could not keep up.
+func (s *SocketSink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close closes the underlying connection and stops the draining
+// goroutine; closing the connection first unblocks a write the
+// goroutine may be stuck in.  Records still buffered, and markers
+// written after Close, are dropped and counted.
+func (s *SocketSink) Close() error {
+	if s.closed.Swap(true) {
+		return nil
+	}
+	err := s.conn.Close()
+	close(s.quit)
+	<-s.flushed
+	return err
+}
+
+func (s *SocketSink) run() {
+	defer close(s.flushed)
+	for {
+		select {
+		case rec := <-s.records:
+			s.writeOne(rec)
+		case <-s.quit:
+			for {
+				select {
+				case rec := <-s.records:
+					s.writeOne(rec)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *SocketSink) writeOne(rec [markerRecordSize]byte) {
+	if _, err := s.conn.Write(rec[:]); err != nil {
+		s.dropped.Add(1)
+	}
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestSocketSinkRecordFormat(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	sink := newSocketSink(clientConn, 4)
+	defer sink.Close()
+	sink.Write(Marker{ID: MarkerPodCreateEnter, Time: 1234567890})
+	rec := make([]byte, markerRecordSize)
+	if _, err := io.ReadFull(serverConn, rec); err != nil {
+		t.Fatalf("failed to read record: %v", err)
+	}
+	if got := binary.LittleEndian.Uint32(rec[0:4]); got != MarkerPodCreateEnter {
+		t.Errorf("expected marker ID %d, got %d", MarkerPodCreateEnter, got)
+	}
+	if got := int64(binary.LittleEndian.Uint64(rec[4:12])); got != 1234567890 {
+		t.Errorf("expected timestamp 1234567890, got %d", got)
+	}
+	if got := binary.LittleEndian.Uint32(rec[12:16]); got != 0 {
+		t.Errorf("expected reserved bytes to be zero, got %d", got)
+	}
+	if dropped := sink.Dropped(); dropped != 0 {
+		t.Errorf("expected no drops, got %d", dropped)
+	}
+}
+
+func TestSocketSinkDropsWhenFull(t *testing.T) {
+	// The far end of the pipe is never read, so after the first record
+	// occupies the writer goroutine and the second fills the buffer,
+	// further writes must be dropped without blocking.
+	clientConn, _ := net.Pipe()
+	sink := newSocketSink(clientConn, 1)
+	defer sink.Close()
+	for i := 0; i < 10; i++ {
+		sink.Write(Marker{ID: MarkerJobCreateEnter, Time: int64(i)})
+	}
+	if dropped := sink.Dropped(); dropped < 8 {
+		t.Errorf("expected at least 8 dropped markers, got %d", dropped)
+	}
+}
+
+func TestSocketSinkCloseStopsDraining(t *testing.T) {
+	clientConn, serverConn := net.Pipe()
+	go io.Copy(io.Discard, serverConn)
+	sink := newSocketSink(clientConn, 4)
+	sink.Write(Marker{ID: MarkerPodCreateEnter, Time: 1})
+	// Close waits for the draining goroutine to exit, so returning at
+	// all is the regression check against leaking it.
+	if err := sink.Close(); err != nil {
+		t.Fatalf("failed to close socket sink: %v", err)
+	}
+	if err := sink.Close(); err != nil {
+		t.Errorf("expected a second Close to be a no-op, got %v", err)
+	}
+	sink.Write(Marker{ID: MarkerPodCreateEnter, Time: 2})
+	if dropped := sink.Dropped(); dropped == 0 {
+		t.Error("expected a write after Close to be dropped")
+	}
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// Now returns the current instant as a marker timestamp, using the
+// same rendering as TimestampFrom (nanoseconds since the Unix epoch,
+// or the monotonic offset when UseMonotonicTimestamps is in effect).
+// External wrappers should read time through this function so their
+// spans land on the same timeline as the markers.
+func Now() int64 {
+	return TimestampFrom(time.Now())
+}
+
+// Span is a named interval on the marker timeline.
+type Span struct {
+	// Name identifies what the interval covers.
+	Name string
+
+	// Start and End are marker timestamps; see TimestampFrom.
+	Start, End int64
+}
+
+// Duration returns the length of the span.
+func (s Span) Duration() time.Duration {
+	return time.Duration(s.End - s.Start)
+}
+
+// SpanSink consumes recorded spans.  Like MarkerSink implementations
+// it must not block.
+type SpanSink interface {
+	Record(s Span)
+}
+
+// spanSink is where RecordSpan sends spans.  It defaults to the log
+// and may be replaced once at startup via SetSpanSink, before the
+// filter serves traffic.
+var spanSink SpanSink = logSpanSink{}
+
+// SetSpanSink replaces the destination of recorded spans.  Pass
+// NopSpanSink to discard spans in production builds.  It must be
+// called before the filter starts handling requests.
+func SetSpanSink(s SpanSink) {
+	spanSink = s
+}
+
+// NopSpanSink discards every span.
+type NopSpanSink struct{}
+
+func (NopSpanSink) Record(Span) {}
+
+// logSpanSink writes spans to the log; this is the default sink.
+type logSpanSink struct{}
+
+func (logSpanSink) Record(s Span) {
+	klog.Infof("CONTINUUM span name=%q start=%d end=%d duration=%d", s.Name, s.Start, s.End, s.Duration().Nanoseconds())
+}
@@ -0,0 +1,89 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"testing"
+	"time"
+)
+
+// capturingSpanSink collects spans for inspection.
+type capturingSpanSink struct {
+	spans []Span
+}
+
+func (s *capturingSpanSink) Record(span Span) {
+	s.spans = append(s.spans, span)
+}
+
+func TestRecordSpan(t *testing.T) {
+	captured := &capturingSpanSink{}
+	SetSpanSink(captured)
+	defer SetSpanSink(logSpanSink{})
+	start := Now()
+	RecordSpan("test-span", start)
+	if len(captured.spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(captured.spans))
+	}
+	span := captured.spans[0]
+	if span.Name != "test-span" {
+		t.Errorf("expected name %q, got %q", "test-span", span.Name)
+	}
+	if span.Start != start {
+		t.Errorf("expected start %d, got %d", start, span.Start)
+	}
+	if span.End < span.Start {
+		t.Errorf("span ends (%d) before it starts (%d)", span.End, span.Start)
+	}
+}
+
+func TestLifecycleEventSpans(t *testing.T) {
+	captured := &capturingSpanSink{}
+	SetSpanSink(captured)
+	defer SetSpanSink(logSpanSink{})
+	EmitLifecycleEvent(LifecycleEvent{
+		FlowSchema:         "test-fs",
+		PriorityLevel:      "test-pl",
+		Queued:             true,
+		StartWaitingTime:   100,
+		StartExecutionTime: 250,
+		WaitingDuration:    150 * time.Nanosecond,
+		ExecutionDuration:  400 * time.Nanosecond,
+		Executed:           true,
+	})
+	if len(captured.spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(captured.spans))
+	}
+	wait := captured.spans[0]
+	if wait.Name != "apf/wait/test-fs" || wait.Start != 100 || wait.End != 250 {
+		t.Errorf("unexpected wait span %+v", wait)
+	}
+	exec := captured.spans[1]
+	if exec.Name != "apf/execute/test-fs" || exec.Start != 250 || exec.End != 650 {
+		t.Errorf("unexpected execution span %+v", exec)
+	}
+	if got, want := exec.Duration(), 400*time.Nanosecond; got != want {
+		t.Errorf("expected duration %v, got %v", want, got)
+	}
+	captured.spans = nil
+	EmitLifecycleEvent(LifecycleEvent{FlowSchema: "test-fs", PriorityLevel: "test-pl"})
+	if len(captured.spans) != 0 {
+		t.Errorf("expected no spans for an unqueued, unexecuted event, got %d", len(captured.spans))
+	}
+}
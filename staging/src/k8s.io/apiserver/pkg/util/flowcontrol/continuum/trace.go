@@ -0,0 +1,157 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"context"
+	"net/http"
+)
+
+// traceIDKeyType is the unexported type of the context key for the
+// trace id, preventing collisions with keys of other packages.
+type traceIDKeyType int
+
+const traceIDKey traceIDKeyType = 0
+
+// WithTraceID returns a copy of ctx carrying the given externally
+// supplied trace id, which correlates the request's markers, metric
+// exemplars, and lifecycle events with a trace recorded elsewhere.
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceIDFrom returns the trace id carried by ctx, or the empty
+// string if there is none.
+func TraceIDFrom(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey).(string)
+	return traceID
+}
+
+// authenticatedUserKeyType is the unexported type of the context key
+// for the authenticated username.
+type authenticatedUserKeyType int
+
+const authenticatedUserKey authenticatedUserKeyType = 0
+
+// WithAuthenticatedUser returns a copy of ctx carrying the username
+// the requester authenticated as.  The impersonation filter calls
+// this before replacing the context user with the impersonated
+// identity, so that trace rules can still target the authenticated
+// one; see Rule.AuthenticatedUsers.
+func WithAuthenticatedUser(ctx context.Context, username string) context.Context {
+	return context.WithValue(ctx, authenticatedUserKey, username)
+}
+
+// AuthenticatedUserFrom returns the authenticated username carried by
+// ctx, or the empty string if there is none.  The empty string means
+// no impersonation took place, in which case the authenticated and
+// effective identities coincide.
+func AuthenticatedUserFrom(ctx context.Context) string {
+	username, _ := ctx.Value(authenticatedUserKey).(string)
+	return username
+}
+
+// RunIDHeader is the request header through which a benchmark client
+// names the run a request belongs to.  The run id tags every marker
+// emitted for the request, so that markers from consecutive or
+// interleaved runs against one server can be separated afterwards.
+const RunIDHeader = "X-Continuum-Run-Id"
+
+// runIDKeyType is the unexported type of the context key for the run
+// id.
+type runIDKeyType int
+
+const runIDKey runIDKeyType = 0
+
+// WithRunID returns a copy of ctx carrying the given run id.
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey, runID)
+}
+
+// RunIDFrom returns the run id carried by ctx, or the empty string if
+// there is none.
+func RunIDFrom(ctx context.Context) string {
+	runID, _ := ctx.Value(runIDKey).(string)
+	return runID
+}
+
+// maxHeaderLabels bounds how many header names SetHeaderLabels
+// retains, and maxHeaderLabelValueLength bounds how many bytes of
+// each value are copied, so that clients cannot bloat the trace
+// output through oversized or numerous headers.
+const (
+	maxHeaderLabels           = 8
+	maxHeaderLabelValueLength = 128
+)
+
+// headerLabelNames holds the request header names whose values are
+// copied into lifecycle events; see SetHeaderLabels.
+var headerLabelNames []string
+
+// SetHeaderLabels configures the request headers whose values are
+// copied, as key/value labels, into the lifecycle event of every
+// request that carries them.  A client can for example send
+// "X-Continuum-Phase: warmup" to tag its requests with the benchmark
+// phase.  At most maxHeaderLabels names are retained.  Like
+// SetMarkerSink, this must be called before the filter serves
+// traffic.
+func SetHeaderLabels(names []string) {
+	if len(names) > maxHeaderLabels {
+		names = names[:maxHeaderLabels]
+	}
+	headerLabelNames = names
+}
+
+// ExtractHeaderLabels returns the configured labels present in the
+// given header, with each value truncated to
+// maxHeaderLabelValueLength bytes, or nil if no configured header is
+// present.
+func ExtractHeaderLabels(header http.Header) map[string]string {
+	var labels map[string]string
+	for _, name := range headerLabelNames {
+		value := header.Get(name)
+		if value == "" {
+			continue
+		}
+		if len(value) > maxHeaderLabelValueLength {
+			value = value[:maxHeaderLabelValueLength]
+		}
+		if labels == nil {
+			labels = map[string]string{}
+		}
+		labels[name] = value
+	}
+	return labels
+}
+
+// headerLabelsKeyType is the unexported type of the context key for
+// the header labels.
+type headerLabelsKeyType int
+
+const headerLabelsKey headerLabelsKeyType = 0
+
+// WithHeaderLabels returns a copy of ctx carrying the given labels.
+func WithHeaderLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, headerLabelsKey, labels)
+}
+
+// HeaderLabelsFrom returns the labels carried by ctx, or nil if there
+// are none.
+func HeaderLabelsFrom(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(headerLabelsKey).(map[string]string)
+	return labels
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtractHeaderLabels(t *testing.T) {
+	SetHeaderLabels([]string{"X-Continuum-Phase", "X-Continuum-Client"})
+	defer SetHeaderLabels(nil)
+
+	header := http.Header{}
+	header.Set("X-Continuum-Phase", "warmup")
+	header.Set("X-Unconfigured", "ignored")
+	got := ExtractHeaderLabels(header)
+	want := map[string]string{"X-Continuum-Phase": "warmup"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected labels %v, got %v", want, got)
+	}
+
+	if got := ExtractHeaderLabels(http.Header{}); got != nil {
+		t.Errorf("expected nil labels without configured headers, got %v", got)
+	}
+
+	header.Set("X-Continuum-Client", strings.Repeat("x", 2*maxHeaderLabelValueLength))
+	got = ExtractHeaderLabels(header)
+	if len(got["X-Continuum-Client"]) != maxHeaderLabelValueLength {
+		t.Errorf("expected the value to be truncated to %d bytes, got %d", maxHeaderLabelValueLength, len(got["X-Continuum-Client"]))
+	}
+}
+
+func TestSetHeaderLabelsCap(t *testing.T) {
+	names := make([]string, 2*maxHeaderLabels)
+	header := http.Header{}
+	for i := range names {
+		names[i] = fmt.Sprintf("X-Label-%d", i)
+		header.Set(names[i], "v")
+	}
+	SetHeaderLabels(names)
+	defer SetHeaderLabels(nil)
+	if got := ExtractHeaderLabels(header); len(got) != maxHeaderLabels {
+		t.Errorf("expected at most %d labels, got %d", maxHeaderLabels, len(got))
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+
+	"k8s.io/klog/v2"
+)
+
+// RequestTracer pairs a ruleset with a sink, so that several tracers
+// with independent filters and destinations can observe the same
+// stream of requests.
+type RequestTracer struct {
+	// Name identifies the tracer in logs.
+	Name string
+
+	// Rules selects the requests this tracer observes.
+	Rules []Rule
+
+	// Sink receives the markers of matching requests.  A nil Sink
+	// means the package-level sink configured via SetMarkerSink.
+	Sink MarkerSink
+
+	// ObserveRequest, if non-nil, is called once for each matching
+	// request as it enters the filter, with the arrival time and the
+	// request's classification attributes.  Unlike markers, which
+	// carry only timing, this gives the tracer enough to reconstruct
+	// the request later — e.g. to record a workload for replay.
+	ObserveRequest func(arrival time.Time, ri *request.RequestInfo, user user.Info)
+
+	// FirstSeenHeartbeat, if positive, switches the tracer to flow
+	// discovery mode: only the first request of each distinct
+	// (verb, resource, user) tuple emits markers, and an already-seen
+	// tuple emits again at most once per this interval, as a
+	// still-active heartbeat.  This maps the request landscape of a
+	// run without flooding the sink, typically with a catch-all rule,
+	// before precise rules are written.
+	FirstSeenHeartbeat time.Duration
+
+	// FirstSeenLimit bounds the number of tuples remembered in
+	// discovery mode; zero means 1024.  When the bound is reached the
+	// stalest tuple is evicted, so a long-dormant flow may later
+	// re-emit as first seen.
+	FirstSeenLimit int
+
+	// firstSeen records, per tuple, when the tracer last emitted for
+	// it in discovery mode.  Guarded by firstSeenLock because
+	// concurrent requests consult it on the request path.
+	firstSeenLock sync.Mutex
+	firstSeen     map[flowKey]time.Time
+}
+
+// flowKey is the tuple that identifies a distinct flow in discovery
+// mode.
+type flowKey struct {
+	verb, resource, user string
+}
+
+// defaultFirstSeenLimit bounds the discovery seen-set when
+// FirstSeenLimit is zero.
+const defaultFirstSeenLimit = 1024
+
+// shouldEmitFirstSeen tells whether a discovery-mode tracer should
+// emit for the given request at the given instant, recording the
+// emission when it says yes.  The second result distinguishes a
+// first sighting from a heartbeat.
+func (t *RequestTracer) shouldEmitFirstSeen(now time.Time, ri *request.RequestInfo, username string) (emit, first bool) {
+	key := flowKey{verb: ri.Verb, resource: ri.Resource, user: username}
+	t.firstSeenLock.Lock()
+	defer t.firstSeenLock.Unlock()
+	if last, seen := t.firstSeen[key]; seen {
+		if now.Sub(last) < t.FirstSeenHeartbeat {
+			return false, false
+		}
+		t.firstSeen[key] = now
+		return true, false
+	}
+	if t.firstSeen == nil {
+		t.firstSeen = map[flowKey]time.Time{}
+	}
+	limit := t.FirstSeenLimit
+	if limit <= 0 {
+		limit = defaultFirstSeenLimit
+	}
+	if len(t.firstSeen) >= limit {
+		var stalest flowKey
+		var stalestTime time.Time
+		initialized := false
+		for k, ts := range t.firstSeen {
+			if !initialized || ts.Before(stalestTime) {
+				stalest, stalestTime, initialized = k, ts, true
+			}
+		}
+		delete(t.firstSeen, stalest)
+	}
+	t.firstSeen[key] = now
+	return true, true
+}
+
+// tracers holds the registered tracers, consulted in registration
+// order.  The built-in tracer covers the default ruleset and writes
+// to the package-level sink.
+var tracers = []*RequestTracer{{Name: "default", Rules: DefaultRules()}}
+
+// RegisterTracer appends a tracer, to be consulted after the
+// previously registered ones.  Like SetMarkerSink, it must be called
+// before the filter serves traffic.
+func RegisterTracer(t *RequestTracer) {
+	tracers = append(tracers, t)
+}
+
+// UnregisterTracer removes a tracer that RegisterTracer added, so
+// that test harnesses can clean up after themselves.  Like
+// RegisterTracer, it must not race with traffic.
+func UnregisterTracer(t *RequestTracer) {
+	for i, existing := range tracers {
+		if existing == t {
+			tracers = append(tracers[:i:i], tracers[i+1:]...)
+			return
+		}
+	}
+}
+
+// EmitMarker writes m to the matched tracer's sink, recovering any
+// panic so that one misbehaving tracer affects neither the other
+// tracers nor request processing.
+func (mr MatchedRule) EmitMarker(m Marker) {
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Errorf("CONTINUUM tracer %q panicked emitting marker %04d: %v", mr.Tracer.Name, m.ID, r)
+		}
+	}()
+	s := mr.Tracer.Sink
+	if s == nil {
+		s = sink
+	}
+	s.Write(m)
+}
+
+// ObserveRequest invokes the matched tracer's ObserveRequest hook, if
+// any, with the same panic isolation as EmitMarker.
+func (mr MatchedRule) ObserveRequest(arrival time.Time, ri *request.RequestInfo, user user.Info) {
+	if mr.Tracer.ObserveRequest == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			klog.Errorf("CONTINUUM tracer %q panicked observing a request: %v", mr.Tracer.Name, r)
+		}
+	}()
+	mr.Tracer.ObserveRequest(arrival, ri, user)
+}
@@ -0,0 +1,131 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type recordingSink struct {
+	markers []Marker
+}
+
+func (s *recordingSink) Write(m Marker) {
+	s.markers = append(s.markers, m)
+}
+
+type panickingSink struct{}
+
+func (panickingSink) Write(m Marker) {
+	panic("sink failure")
+}
+
+func TestMatchTracersOrderAndPanicIsolation(t *testing.T) {
+	savedTracers := tracers
+	defer func() { tracers = savedTracers }()
+
+	recorder := &recordingSink{}
+	podRules := []Rule{{
+		Name:       "pods-all-verbs",
+		Resources:  []string{"pods"},
+		BaseMarker: 250,
+	}}
+	tracers = []*RequestTracer{
+		{Name: "broken", Rules: podRules, Sink: panickingSink{}},
+		{Name: "recording", Rules: podRules, Sink: recorder},
+	}
+
+	ri := &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods"}
+	matched := MatchTracers(ri, "", "")
+	if len(matched) != 2 {
+		t.Fatalf("expected 2 matched tracers, got %d", len(matched))
+	}
+	if matched[0].Tracer.Name != "broken" || matched[1].Tracer.Name != "recording" {
+		t.Errorf("matched tracers out of registration order: %q, %q", matched[0].Tracer.Name, matched[1].Tracer.Name)
+	}
+	for _, mt := range matched {
+		mt.EmitMarker(Marker{ID: mt.Rule.EnterMarker(), Time: 42})
+	}
+	if len(recorder.markers) != 1 || recorder.markers[0].ID != 250 {
+		t.Errorf("expected the recording tracer to get marker 250 despite the broken one panicking, got %#v", recorder.markers)
+	}
+
+	if got := MatchTracers(&request.RequestInfo{IsResourceRequest: true, Verb: "get", APIVersion: "v1", Resource: "secrets"}, "", ""); got != nil {
+		t.Errorf("expected no tracers to match, got %d", len(got))
+	}
+}
+
+func TestFirstSeenDiscovery(t *testing.T) {
+	tracer := &RequestTracer{
+		Name:               "discovery",
+		Rules:              []Rule{{Name: "all", BaseMarker: MarkerRequestEnter}},
+		Sink:               &recordingSink{},
+		FirstSeenHeartbeat: time.Hour,
+	}
+	RegisterTracer(tracer)
+	defer UnregisterTracer(tracer)
+
+	podCreate := &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods"}
+	matchesDiscovery := func(ri *request.RequestInfo, username string) bool {
+		for _, mt := range MatchTracers(ri, username, username) {
+			if mt.Tracer == tracer {
+				return true
+			}
+		}
+		return false
+	}
+	if !matchesDiscovery(podCreate, "alice") {
+		t.Error("expected the first request of a flow to be matched")
+	}
+	if matchesDiscovery(podCreate, "alice") {
+		t.Error("expected a repeat of a seen flow to be suppressed")
+	}
+	if !matchesDiscovery(podCreate, "bob") {
+		t.Error("expected a different user to count as a new flow")
+	}
+}
+
+func TestFirstSeenHeartbeatAndEviction(t *testing.T) {
+	tracer := &RequestTracer{FirstSeenHeartbeat: time.Minute, FirstSeenLimit: 2}
+	base := time.Now()
+	podCreate := &request.RequestInfo{IsResourceRequest: true, Verb: "create", Resource: "pods"}
+	podList := &request.RequestInfo{IsResourceRequest: true, Verb: "list", Resource: "pods"}
+	leaseUpdate := &request.RequestInfo{IsResourceRequest: true, Verb: "update", Resource: "leases"}
+
+	if emit, first := tracer.shouldEmitFirstSeen(base, podCreate, "alice"); !emit || !first {
+		t.Errorf("first sighting: expected emit and first, got %t, %t", emit, first)
+	}
+	if emit, _ := tracer.shouldEmitFirstSeen(base.Add(30*time.Second), podCreate, "alice"); emit {
+		t.Error("expected a repeat within the heartbeat interval to be suppressed")
+	}
+	if emit, first := tracer.shouldEmitFirstSeen(base.Add(2*time.Minute), podCreate, "alice"); !emit || first {
+		t.Errorf("heartbeat: expected emit without first, got %t, %t", emit, first)
+	}
+
+	// The set is full after a second tuple; a third evicts the
+	// stalest one, which then counts as new again.
+	tracer.shouldEmitFirstSeen(base.Add(3*time.Minute), podList, "alice")
+	tracer.shouldEmitFirstSeen(base.Add(4*time.Minute), leaseUpdate, "alice")
+	if emit, first := tracer.shouldEmitFirstSeen(base.Add(5*time.Minute), podCreate, "alice"); !emit || !first {
+		t.Errorf("after eviction: expected the evicted flow to count as new, got %t, %t", emit, first)
+	}
+}
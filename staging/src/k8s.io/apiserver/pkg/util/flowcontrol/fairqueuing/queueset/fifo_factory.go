@@ -0,0 +1,113 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queueset
+
+import (
+	"context"
+
+	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	"k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/eventclock"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
+	fqrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+)
+
+// fifoQueueSetFactory makes QueueSets that queue requests in plain
+// arrival order rather than fair-queuing them.  It delegates to the
+// ordinary factory after rewriting each QueuingConfig to use a single
+// queue, so flow distinguishers and shuffle sharding play no role
+// while concurrency limits and wait limits are honored exactly as in
+// the fair implementation.
+type fifoQueueSetFactory struct {
+	delegate fq.QueueSetFactory
+}
+
+// NewFIFOQueueSetFactory creates a QueueSetFactory whose QueueSets
+// dispatch requests of each priority level in plain FIFO order,
+// ignoring flow distinguishers.  It is intended for baseline
+// measurements of the overhead and benefit of fair queuing, not for
+// production use.  Select it by setting the QueueSetFactory field of
+// TestableConfig.
+func NewFIFOQueueSetFactory(c eventclock.Interface) fq.QueueSetFactory {
+	return &fifoQueueSetFactory{delegate: NewQueueSetFactory(c)}
+}
+
+// fifoQueuingConfig collapses the given configuration down to a
+// single queue.  The total queuing capacity is preserved by scaling
+// the per-queue length limit up by the desired number of queues.
+// Non-positive DesiredNumQueues values (no queuing, or exempt) pass
+// through unchanged.
+func fifoQueuingConfig(qCfg fq.QueuingConfig) fq.QueuingConfig {
+	if qCfg.DesiredNumQueues > 1 {
+		qCfg.QueueLengthLimit *= qCfg.DesiredNumQueues
+		qCfg.DesiredNumQueues = 1
+		qCfg.HandSize = 1
+	}
+	return qCfg
+}
+
+func (qsf *fifoQueueSetFactory) BeginConstruction(qCfg fq.QueuingConfig, reqsGaugePair metrics.RatioedGaugePair, execSeatsGauge metrics.RatioedGauge, seatDemandIntegrator metrics.Gauge) (fq.QueueSetCompleter, error) {
+	qsc, err := qsf.delegate.BeginConstruction(fifoQueuingConfig(qCfg), reqsGaugePair, execSeatsGauge, seatDemandIntegrator)
+	if err != nil {
+		return nil, err
+	}
+	return &fifoQueueSetCompleter{delegate: qsc}, nil
+}
+
+// fifoQueueSetCompleter wraps the fair completer so that the
+// completed QueueSet also rewrites later configuration changes.  If
+// theSet is non-nil then Complete returns it, preserving the identity
+// contract of QueueSet::BeginConfigChange.
+type fifoQueueSetCompleter struct {
+	delegate fq.QueueSetCompleter
+	theSet   *fifoQueueSet
+}
+
+func (qsc *fifoQueueSetCompleter) Complete(dCfg fq.DispatchingConfig) fq.QueueSet {
+	inner := qsc.delegate.Complete(dCfg)
+	if qsc.theSet != nil {
+		return qsc.theSet
+	}
+	return &fifoQueueSet{delegate: inner}
+}
+
+// fifoQueueSet is a QueueSet that intercepts BeginConfigChange to
+// keep the single-queue rewrite in force across reconfigurations; all
+// other behavior comes from the wrapped fair QueueSet.
+type fifoQueueSet struct {
+	delegate fq.QueueSet
+}
+
+func (qs *fifoQueueSet) BeginConfigChange(qCfg fq.QueuingConfig) (fq.QueueSetCompleter, error) {
+	qsc, err := qs.delegate.BeginConfigChange(fifoQueuingConfig(qCfg))
+	if err != nil {
+		return nil, err
+	}
+	return &fifoQueueSetCompleter{delegate: qsc, theSet: qs}, nil
+}
+
+func (qs *fifoQueueSet) IsIdle() bool {
+	return qs.delegate.IsIdle()
+}
+
+func (qs *fifoQueueSet) StartRequest(ctx context.Context, workEstimate *fqrequest.WorkEstimate, hashValue uint64, flowDistinguisher, fsName string, descr1, descr2 interface{}, queueNoteFn fq.QueueNoteFn) (fq.Request, bool) {
+	return qs.delegate.StartRequest(ctx, workEstimate, hashValue, flowDistinguisher, fsName, descr1, descr2, queueNoteFn)
+}
+
+func (qs *fifoQueueSet) Dump(includeRequestDetails bool) debug.QueueSetDump {
+	return qs.delegate.Dump(includeRequestDetails)
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package queueset
+
+import (
+	"testing"
+	"time"
+
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	testeventclock "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/testing/eventclock"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
+)
+
+// TestFIFOFactoryCollapsesToOneQueue checks that the FIFO factory
+// rewrites a multi-queue configuration down to a single queue with
+// the total queuing capacity preserved, both at construction and
+// across a later configuration change.
+func TestFIFOFactoryCollapsesToOneQueue(t *testing.T) {
+	metrics.Register()
+	now := time.Now()
+	clk, _ := testeventclock.NewFake(now, 0, nil)
+	qsf := NewFIFOQueueSetFactory(clk)
+	qCfg := fq.QueuingConfig{
+		Name:             "TestFIFOFactory",
+		DesiredNumQueues: 9,
+		QueueLengthLimit: 8,
+		HandSize:         3,
+	}
+	qsc, err := qsf.BeginConstruction(qCfg, newGaugePair(clk), newExecSeatsGauge(clk), fq.NewNamedIntegrator(clk, "TestFIFOFactory"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs := qsc.Complete(fq.DispatchingConfig{ConcurrencyLimit: 1, ConcurrencyDenominator: 1})
+	if nq := len(qs.Dump(false).Queues); nq != 1 {
+		t.Errorf("expected 1 queue after construction, got %d", nq)
+	}
+	qsc2, err := qs.BeginConfigChange(qCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	qs2 := qsc2.Complete(fq.DispatchingConfig{ConcurrencyLimit: 1, ConcurrencyDenominator: 1})
+	if qs2 != qs {
+		t.Errorf("BeginConfigChange+Complete returned a different QueueSet")
+	}
+	if nq := len(qs2.Dump(false).Queues); nq != 1 {
+		t.Errorf("expected 1 queue after config change, got %d", nq)
+	}
+	if got := fifoQueuingConfig(qCfg); got.QueueLengthLimit != 72 || got.DesiredNumQueues != 1 || got.HandSize != 1 {
+		t.Errorf("unexpected rewritten config %#+v", got)
+	}
+}
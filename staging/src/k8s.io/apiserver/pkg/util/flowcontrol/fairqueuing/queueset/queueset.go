@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
 	"k8s.io/apiserver/pkg/util/flowcontrol/debug"
 	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
 	"k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/eventclock"
@@ -420,7 +421,6 @@ func (req *request) wait() (bool, bool) {
 		// This can not happen, all possible values are handled above
 		klog.Errorf("QS(%s): Impossible decision (type %T, value %#+v) for request %#+v %#+v!  Treating as cancel", qs.qCfg.Name, decisionAny, decisionAny, req.descr1, req.descr2)
 	}
-	// TODO(aaron-prindle) add metrics for this case
 	klog.V(5).Infof("QS(%s): Ejecting request %#+v %#+v from its queue", qs.qCfg.Name, req.descr1, req.descr2)
 	// remove the request from the queue as its queue wait time has exceeded
 	queue := req.queue
@@ -431,6 +431,8 @@ func (req *request) wait() (bool, bool) {
 		qs.totRequestsRejected++
 		qs.totRequestsCancelled++
 		metrics.AddReject(req.ctx, qs.qCfg.Name, req.fsName, "time-out")
+		metrics.AddCancelledWhileQueued(req.ctx, qs.qCfg.Name, req.fsName)
+		continuum.EmitMarker(continuum.Marker{ID: continuum.MarkerCancelledWhileQueued, Time: continuum.TimestampFrom(qs.clock.Now()), FlowSchema: req.fsName, PriorityLevel: qs.qCfg.Name})
 		metrics.AddRequestsInQueues(req.ctx, qs.qCfg.Name, req.fsName, -1)
 		metrics.AddSeatsInQueues(req.ctx, qs.qCfg.Name, req.fsName, -req.MaxSeats())
 		req.NoteQueued(false)
@@ -551,7 +553,7 @@ func (qs *queueSet) getVirtualTimeRatioLocked() float64 {
 // the queuelengthlimit has been reached
 func (qs *queueSet) shuffleShardAndRejectOrEnqueueLocked(ctx context.Context, workEstimate *fqrequest.WorkEstimate, hashValue uint64, flowDistinguisher, fsName string, descr1, descr2 interface{}, queueNoteFn fq.QueueNoteFn) *request {
 	// Start with the shuffle sharding, to pick a queue.
-	queueIdx := qs.shuffleShardLocked(hashValue, descr1, descr2)
+	queueIdx, hand := qs.shuffleShardLocked(hashValue, descr1, descr2)
 	queue := qs.queues[queueIdx]
 
 	defer qs.boundNextDispatchLocked(queue)
@@ -566,6 +568,7 @@ func (qs *queueSet) shuffleShardAndRejectOrEnqueueLocked(ctx context.Context, wo
 		arrivalTime:       qs.clock.Now(),
 		arrivalR:          qs.currentR,
 		queue:             queue,
+		shuffleShardHand:  hand,
 		descr1:            descr1,
 		descr2:            descr2,
 		queueNoteFn:       queueNoteFn,
@@ -580,7 +583,9 @@ func (qs *queueSet) shuffleShardAndRejectOrEnqueueLocked(ctx context.Context, wo
 
 // shuffleShardLocked uses shuffle sharding to select a queue index
 // using the given hashValue and the shuffle sharding parameters of the queueSet.
-func (qs *queueSet) shuffleShardLocked(hashValue uint64, descr1, descr2 interface{}) int {
+// The second return value is a copy of the dealt hand if continuum
+// verbose tracing is enabled, nil otherwise.
+func (qs *queueSet) shuffleShardLocked(hashValue uint64, descr1, descr2 interface{}) (int, []int) {
 	var backHand [8]int
 	// Deal into a data structure, so that the order of visit below is not necessarily the order of the deal.
 	// This removes bias in the case of flows with overlapping hands.
@@ -608,7 +613,47 @@ func (qs *queueSet) shuffleShardLocked(hashValue uint64, descr1, descr2 interfac
 		chosenQueue := qs.queues[bestQueueIdx]
 		klogV.Infof("QS(%s) at t=%s R=%v: For request %#+v %#+v chose queue %d, with sum: %#v & %d seats in use & nextDispatchR=%v", qs.qCfg.Name, qs.clock.Now().Format(nsTimeFmt), qs.currentR, descr1, descr2, bestQueueIdx, chosenQueue.requestsWaiting.QueueSum(), chosenQueue.seatsInUse, chosenQueue.nextDispatchR)
 	}
-	return bestQueueIdx
+	var handCopy []int
+	if continuum.Verbose() {
+		handCopy = append(handCopy, hand...)
+	}
+	return bestQueueIdx, handCopy
+}
+
+// ShuffleShardDetails reports the hand of queue indices that was
+// dealt when the given request was shuffle-sharded into a queue, and
+// the index of the queue that was chosen from that hand.  The hand is
+// recorded only while continuum verbose tracing is enabled; ok is
+// false if it was not recorded, if the request did not go through a
+// queue, or if the request is not from this package.
+func ShuffleShardDetails(r fq.Request) (hand []int, queueIndex int, ok bool) {
+	req, isReq := r.(*request)
+	if !isReq || req.queue == nil || req.shuffleShardHand == nil {
+		return nil, 0, false
+	}
+	return req.shuffleShardHand, req.queue.index, true
+}
+
+// ChosenQueueIndex reports the index of the queue the given request
+// was assigned to.  Unlike the hand, the index is available whether or
+// not verbose tracing is enabled; ok is false if the request did not
+// go through a queue or is not from this package.
+func ChosenQueueIndex(r fq.Request) (queueIndex int, ok bool) {
+	req, isReq := r.(*request)
+	if !isReq || req.queue == nil {
+		return 0, false
+	}
+	return req.queue.index, true
+}
+
+// RequestWorkEstimate reports the work estimate attached to the given
+// request; ok is false if the request is not from this package.
+func RequestWorkEstimate(r fq.Request) (we fqrequest.WorkEstimate, ok bool) {
+	req, isReq := r.(*request)
+	if !isReq {
+		return fqrequest.WorkEstimate{}, false
+	}
+	return req.workEstimate.WorkEstimate, true
 }
 
 // rejectOrEnqueueToBoundLocked rejects or enqueues the newly arrived
@@ -42,6 +42,12 @@ type request struct {
 	// a queue.
 	queue *queue
 
+	// shuffleShardHand is the hand of queue indices that was dealt
+	// when this request was shuffle-sharded into its queue.  It is
+	// recorded only when continuum verbose tracing is enabled and the
+	// request went through a queue; otherwise it is nil.
+	shuffleShardHand []int
+
 	// estimated amount of work of the request
 	workEstimate completedWorkEstimate
 
@@ -21,8 +21,11 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+
 	epmetrics "k8s.io/apiserver/pkg/endpoints/metrics"
 	apirequest "k8s.io/apiserver/pkg/endpoints/request"
 	compbasemetrics "k8s.io/component-base/metrics"
@@ -39,6 +42,7 @@ const (
 	requestKind         = "request_kind"
 	priorityLevel       = "priority_level"
 	flowSchema          = "flow_schema"
+	className           = "class"
 	phase               = "phase"
 	LabelNamePhase      = "phase"
 	LabelValueWaiting   = "waiting"
@@ -48,8 +52,56 @@ const (
 var (
 	queueLengthBuckets            = []float64{0, 10, 25, 50, 100, 250, 500, 1000}
 	requestDurationSecondsBuckets = []float64{0, 0.005, 0.02, 0.05, 0.1, 0.2, 0.5, 1, 2, 5, 10, 15, 30}
+
+	// highResolutionDurationSecondsBuckets resolves the sub-millisecond
+	// phases that the coarse buckets above lump into their first bucket.
+	highResolutionDurationSecondsBuckets = []float64{1e-6, 2e-6, 5e-6, 1e-5, 2e-5, 5e-5, 1e-4, 2e-4, 5e-4, 1e-3, 2e-3, 5e-3, 1e-2, 2e-2, 5e-2, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+)
+
+// highResolutionTimingEnabled controls whether wait and execution
+// durations are additionally observed in the high-resolution
+// histograms.  The extra histograms cost more to scrape and store, so
+// they are off unless selected through configuration.
+var highResolutionTimingEnabled atomic.Bool
+
+// EnableHighResolutionTiming causes subsequent wait and execution
+// duration observations to also be recorded in the high-resolution
+// (microsecond-bucketed) histogram variants.
+func EnableHighResolutionTiming() {
+	highResolutionTimingEnabled.Store(true)
+}
+
+// priorityLevelClasses maps priority-level name to the user-defined
+// class reported in the "class" label of the ALPHA metric vectors
+// below that carry one.  Levels absent from the mapping report an
+// empty class.
+var (
+	priorityLevelClassesLock sync.RWMutex
+	priorityLevelClasses     map[string]string
 )
 
+// SetPriorityLevelClasses replaces the mapping from priority-level
+// name to class.  The class appears as the "class" label on the
+// metric vectors that carry one, letting queries aggregate related
+// priority levels without enumerating them by name.
+func SetPriorityLevelClasses(classes map[string]string) {
+	copied := make(map[string]string, len(classes))
+	for name, class := range classes {
+		copied[name] = class
+	}
+	priorityLevelClassesLock.Lock()
+	defer priorityLevelClassesLock.Unlock()
+	priorityLevelClasses = copied
+}
+
+// classOf returns the configured class of the given priority level,
+// or the empty string when none is configured.
+func classOf(priorityLevel string) string {
+	priorityLevelClassesLock.RLock()
+	defer priorityLevelClassesLock.RUnlock()
+	return priorityLevelClasses[priorityLevel]
+}
+
 var registerMetrics sync.Once
 
 // Register all metrics.
@@ -98,6 +150,26 @@ var (
 		},
 		[]string{priorityLevel, flowSchema, "reason"},
 	)
+	apiserverFlowSchemaRequestsTotal = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "flow_schema_requests_total",
+			Help:           "Number of requests handled by API Priority and Fairness subsystem, by FlowSchema and outcome",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{flowSchema, "outcome"},
+	)
+	apiserverRequestsCancelledWhileQueued = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "requests_cancelled_while_queued_total",
+			Help:           "Number of requests whose context was cancelled while they waited in an APF queue, so they were ejected without executing",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
 	apiserverDispatchedRequestsTotal = compbasemetrics.NewCounterVec(
 		&compbasemetrics.CounterOpts{
 			Namespace:      namespace,
@@ -108,6 +180,46 @@ var (
 		},
 		[]string{priorityLevel, flowSchema},
 	)
+	apiserverImmediateDispatchesTotal = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "immediate_dispatches_total",
+			Help:           "Number of queued requests whose observed queue wait stayed below the configured epsilon, i.e. that were dispatched effectively immediately",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
+	apiserverRequestDeadlineExceededTotal = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_deadline_exceeded_total",
+			Help:           "Number of requests whose total time in the API Priority and Fairness filter exceeded the configured maximum request duration",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
+	apiserverExecutionPanicsTotal = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "execution_panics_total",
+			Help:           "Number of requests whose execution panicked inside the API Priority and Fairness filter",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
+	apiserverSeatSecondsTotal = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_execution_seat_seconds_total",
+			Help:           "Accumulated products of seats occupied and execution duration of requests executed by API Priority and Fairness subsystem",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, className},
+	)
 	// PriorityLevelExecutionSeatsGaugeVec creates observers of seats occupied throughout execution for priority levels
 	PriorityLevelExecutionSeatsGaugeVec = NewTimingRatioHistogramVec(
 		&compbasemetrics.TimingHistogramOpts{
@@ -220,6 +332,16 @@ var (
 		},
 		[]string{priorityLevel, flowSchema},
 	)
+	apiserverInqueueRequestsHighWatermark = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "inqueue_requests_high_watermark",
+			Help:           "High-water mark of the number of requests pending in queues of each priority level since startup or the last counters reset",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, className},
+	)
 	apiserverRequestQueueLength = compbasemetrics.NewHistogramVec(
 		&compbasemetrics.HistogramOpts{
 			Namespace:      namespace,
@@ -297,6 +419,61 @@ var (
 		},
 		[]string{priorityLevel, flowSchema, "type"},
 	)
+	apiserverRequestWaitingSecondsHighResolution = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_wait_duration_high_resolution_seconds",
+			Help:           "Length of time a request spent waiting in its queue, with microsecond-resolution buckets; populated only when high-resolution timing is enabled",
+			Buckets:        highResolutionDurationSecondsBuckets,
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, "execute", className},
+	)
+	apiserverRequestExecutionSecondsHighResolution = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_execution_high_resolution_seconds",
+			Help:           "Duration of initial stage (for a WATCH) or any (for a non-WATCH) stage of request execution, with microsecond-resolution buckets; populated only when high-resolution timing is enabled",
+			Buckets:        highResolutionDurationSecondsBuckets,
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, "type", className},
+	)
+	apiserverRequestClassificationSeconds = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_classification_duration_seconds",
+			Help:           "Length of time spent classifying a request (FlowSchema matching and flow distinguisher computation) before any queuing",
+			Buckets:        highResolutionDurationSecondsBuckets,
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
+	apiserverClassificationToEnqueueSeconds = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_classification_to_enqueue_seconds",
+			Help:           "Length of time between the end of request classification and the request's placement in a queue; populated only for requests that queue",
+			Buckets:        highResolutionDurationSecondsBuckets,
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
+	apiserverRequestExecutionCPUSeconds = compbasemetrics.NewHistogramVec(
+		&compbasemetrics.HistogramOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "request_execution_cpu_seconds",
+			Help:           "CPU time consumed by the executing thread during request execution; populated only when execution CPU accounting is enabled",
+			Buckets:        highResolutionDurationSecondsBuckets,
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, flowSchema, className},
+	)
 	watchCountSamples = compbasemetrics.NewHistogramVec(
 		&compbasemetrics.HistogramOpts{
 			Namespace:      namespace,
@@ -371,6 +548,46 @@ var (
 		},
 		[]string{priorityLevel},
 	)
+	apiserverRegisteredWatches = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "registered_watches",
+			Help:           "Number of watches currently registered in the watch tracker that feeds work estimation for mutating requests, broken down by API group and resource",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"group", "resource"},
+	)
+	apiserverWatchTrackEvents = compbasemetrics.NewCounterVec(
+		&compbasemetrics.CounterOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "watch_track_events_total",
+			Help:           "Number of watch registrations and unregistrations seen by the watch tracker, broken down by API group, resource, and event",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{"group", "resource", "event"},
+	)
+	apiserverLentSeats = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "lent_seats",
+			Help:           "Number of execution seats each priority level is currently lending out, i.e. its nominal limit minus its current limit when positive",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, className},
+	)
+	apiserverBorrowedSeats = compbasemetrics.NewGaugeVec(
+		&compbasemetrics.GaugeOpts{
+			Namespace:      namespace,
+			Subsystem:      subsystem,
+			Name:           "borrowed_seats",
+			Help:           "Number of execution seats each priority level is currently borrowing, i.e. its current limit minus its nominal limit when positive",
+			StabilityLevel: compbasemetrics.ALPHA,
+		},
+		[]string{priorityLevel, className},
+	)
 	ApiserverSeatDemands = NewTimingRatioHistogramVec(
 		&compbasemetrics.TimingHistogramOpts{
 			Namespace: namespace,
@@ -458,7 +675,13 @@ var (
 
 	metrics = Registerables{
 		apiserverRejectedRequestsTotal,
+		apiserverFlowSchemaRequestsTotal,
+		apiserverRequestsCancelledWhileQueued,
 		apiserverDispatchedRequestsTotal,
+		apiserverImmediateDispatchesTotal,
+		apiserverRequestDeadlineExceededTotal,
+		apiserverExecutionPanicsTotal,
+		apiserverSeatSecondsTotal,
 		apiserverCurrentR,
 		apiserverDispatchR,
 		apiserverLatestS,
@@ -466,6 +689,7 @@ var (
 		apiserverNextDiscountedSBounds,
 		apiserverCurrentInqueueRequests,
 		apiserverCurrentInqueueSeats,
+		apiserverInqueueRequestsHighWatermark,
 		apiserverRequestQueueLength,
 		apiserverRequestConcurrencyLimit,
 		apiserverRequestConcurrencyInUse,
@@ -473,11 +697,20 @@ var (
 		apiserverCurrentExecutingRequests,
 		apiserverRequestWaitingSeconds,
 		apiserverRequestExecutionSeconds,
+		apiserverRequestWaitingSecondsHighResolution,
+		apiserverRequestExecutionSecondsHighResolution,
+		apiserverRequestClassificationSeconds,
+		apiserverClassificationToEnqueueSeconds,
+		apiserverRequestExecutionCPUSeconds,
 		watchCountSamples,
 		apiserverEpochAdvances,
 		apiserverWorkEstimatedSeats,
 		apiserverDispatchWithNoAccommodation,
 		apiserverNominalConcurrencyLimits,
+		apiserverRegisteredWatches,
+		apiserverWatchTrackEvents,
+		apiserverLentSeats,
+		apiserverBorrowedSeats,
 		apiserverMinimumConcurrencyLimits,
 		apiserverMaximumConcurrencyLimits,
 		apiserverSeatDemandHighWatermarks,
@@ -529,6 +762,12 @@ func AddRequestsInQueues(ctx context.Context, priorityLevel, flowSchema string,
 	apiserverCurrentInqueueRequests.WithLabelValues(priorityLevel, flowSchema).Add(float64(delta))
 }
 
+// SetInqueueRequestsHighWatermark sets the gauge of the high-water mark of
+// requests pending in the queues of the specified priorityLevel
+func SetInqueueRequestsHighWatermark(ctx context.Context, priorityLevel string, depth int) {
+	apiserverInqueueRequestsHighWatermark.WithLabelValues(priorityLevel, classOf(priorityLevel)).Set(float64(depth))
+}
+
 // AddSeatsInQueues adds the given delta to the gauge of the # of seats in the queues of the specified flowSchema and priorityLevel
 func AddSeatsInQueues(ctx context.Context, priorityLevel, flowSchema string, delta int) {
 	apiserverCurrentInqueueSeats.WithLabelValues(priorityLevel, flowSchema).Add(float64(delta))
@@ -566,11 +805,60 @@ func AddReject(ctx context.Context, priorityLevel, flowSchema, reason string) {
 	apiserverRejectedRequestsTotal.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, reason).Add(1)
 }
 
+// AddCancelledWhileQueued increments the # of requests ejected from
+// their queue because their context was cancelled before dispatch
+func AddCancelledWhileQueued(ctx context.Context, priorityLevel, flowSchema string) {
+	apiserverRequestsCancelledWhileQueued.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Add(1)
+}
+
 // AddDispatch increments the # of dispatched requests for flow control
 func AddDispatch(ctx context.Context, priorityLevel, flowSchema string) {
 	apiserverDispatchedRequestsTotal.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema).Add(1)
 }
 
+// AddImmediateDispatch increments the # of queued requests whose
+// observed queue wait was below the configured epsilon, so they were
+// dispatched effectively immediately.  Comparing this counter with
+// the smallest wait-histogram bucket separates truly-unqueued
+// requests from barely-queued ones.
+func AddImmediateDispatch(ctx context.Context, priorityLevel, flowSchema string) {
+	apiserverImmediateDispatchesTotal.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Add(1)
+}
+
+// AddRequestDeadlineExceeded increments the # of requests whose total
+// time in the filter exceeded the configured maximum request duration
+func AddRequestDeadlineExceeded(ctx context.Context, priorityLevel, flowSchema string) {
+	apiserverRequestDeadlineExceededTotal.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Add(1)
+}
+
+// AddExecutionPanic increments the # of requests whose execution panicked
+func AddExecutionPanic(ctx context.Context, priorityLevel, flowSchema string) {
+	apiserverExecutionPanicsTotal.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Add(1)
+}
+
+// AddSeatSeconds accumulates the product of the given seat count and
+// execution duration, which captures both wide and long requests in a
+// single measure of resource pressure.
+func AddSeatSeconds(ctx context.Context, priorityLevel string, seats int, executionTime time.Duration) {
+	apiserverSeatSecondsTotal.WithContext(ctx).WithLabelValues(priorityLevel, classOf(priorityLevel)).Add(float64(seats) * executionTime.Seconds())
+}
+
+// Outcome label values for AddFlowSchemaOutcome.  A request is counted
+// as classified once on arrival, as queued if it had to wait, and then
+// as either dispatched or rejected.
+const (
+	OutcomeClassified = "classified"
+	OutcomeQueued     = "queued"
+	OutcomeDispatched = "dispatched"
+	OutcomeRejected   = "rejected"
+)
+
+// AddFlowSchemaOutcome increments the per-FlowSchema counter for the
+// given outcome
+func AddFlowSchemaOutcome(ctx context.Context, flowSchema, outcome string) {
+	apiserverFlowSchemaRequestsTotal.WithContext(ctx).WithLabelValues(flowSchema, outcome).Add(1)
+}
+
 // ObserveQueueLength observes the queue length for flow control
 func ObserveQueueLength(ctx context.Context, priorityLevel, flowSchema string, length int) {
 	apiserverRequestQueueLength.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema).Observe(float64(length))
@@ -579,6 +867,9 @@ func ObserveQueueLength(ctx context.Context, priorityLevel, flowSchema string, l
 // ObserveWaitingDuration observes the queue length for flow control
 func ObserveWaitingDuration(ctx context.Context, priorityLevel, flowSchema, execute string, waitTime time.Duration) {
 	apiserverRequestWaitingSeconds.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, execute).Observe(waitTime.Seconds())
+	if highResolutionTimingEnabled.Load() {
+		apiserverRequestWaitingSecondsHighResolution.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, execute, classOf(priorityLevel)).Observe(waitTime.Seconds())
+	}
 }
 
 // ObserveExecutionDuration observes the execution duration for flow control
@@ -588,6 +879,65 @@ func ObserveExecutionDuration(ctx context.Context, priorityLevel, flowSchema str
 		reqType = requestInfo.Verb
 	}
 	apiserverRequestExecutionSeconds.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, reqType).Observe(executionTime.Seconds())
+	if highResolutionTimingEnabled.Load() {
+		apiserverRequestExecutionSecondsHighResolution.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, reqType, classOf(priorityLevel)).Observe(executionTime.Seconds())
+	}
+}
+
+// observeMaybeExemplar makes one observation on vec, attaching the
+// given trace id as an OpenMetrics exemplar when it is non-empty and
+// the underlying implementation supports exemplars.
+func observeMaybeExemplar(vec *compbasemetrics.HistogramVec, value float64, traceID string, labelValues ...string) {
+	if traceID != "" && vec.IsCreated() {
+		if obs, err := vec.HistogramVec.GetMetricWithLabelValues(labelValues...); err == nil {
+			if exObs, isExObs := obs.(prometheus.ExemplarObserver); isExObs {
+				exObs.ObserveWithExemplar(value, prometheus.Labels{"trace_id": traceID})
+				return
+			}
+		}
+	}
+	vec.WithLabelValues(labelValues...).Observe(value)
+}
+
+// ObserveWaitingDurationWithExemplar is like ObserveWaitingDuration
+// but additionally attaches the given trace id as an exemplar on the
+// observations, so that a slow bucket can be traced back to a
+// specific request.  An empty trace id attaches nothing.
+func ObserveWaitingDurationWithExemplar(ctx context.Context, priorityLevel, flowSchema, execute string, waitTime time.Duration, traceID string) {
+	observeMaybeExemplar(apiserverRequestWaitingSeconds, waitTime.Seconds(), traceID, priorityLevel, flowSchema, execute)
+	if highResolutionTimingEnabled.Load() {
+		observeMaybeExemplar(apiserverRequestWaitingSecondsHighResolution, waitTime.Seconds(), traceID, priorityLevel, flowSchema, execute, classOf(priorityLevel))
+	}
+}
+
+// ObserveExecutionDurationWithExemplar is the exemplar-attaching
+// analog of ObserveExecutionDuration.
+func ObserveExecutionDurationWithExemplar(ctx context.Context, priorityLevel, flowSchema string, executionTime time.Duration, traceID string) {
+	reqType := "regular"
+	if requestInfo, ok := apirequest.RequestInfoFrom(ctx); ok && requestInfo.Verb == "watch" {
+		reqType = requestInfo.Verb
+	}
+	observeMaybeExemplar(apiserverRequestExecutionSeconds, executionTime.Seconds(), traceID, priorityLevel, flowSchema, reqType)
+	if highResolutionTimingEnabled.Load() {
+		observeMaybeExemplar(apiserverRequestExecutionSecondsHighResolution, executionTime.Seconds(), traceID, priorityLevel, flowSchema, reqType, classOf(priorityLevel))
+	}
+}
+
+// ObserveClassificationDuration observes the time spent classifying a request
+func ObserveClassificationDuration(ctx context.Context, priorityLevel, flowSchema string, classificationTime time.Duration) {
+	apiserverRequestClassificationSeconds.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Observe(classificationTime.Seconds())
+}
+
+// ObserveClassificationToEnqueueGap observes the time between the end of a
+// request's classification and its placement in a queue; a non-trivial gap
+// points to contention on the way into the queueset
+func ObserveClassificationToEnqueueGap(ctx context.Context, priorityLevel, flowSchema string, gap time.Duration) {
+	apiserverClassificationToEnqueueSeconds.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Observe(gap.Seconds())
+}
+
+// ObserveExecutionCPU observes the CPU time consumed during request execution
+func ObserveExecutionCPU(ctx context.Context, priorityLevel, flowSchema string, cpuTime time.Duration) {
+	apiserverRequestExecutionCPUSeconds.WithContext(ctx).WithLabelValues(priorityLevel, flowSchema, classOf(priorityLevel)).Observe(cpuTime.Seconds())
 }
 
 // ObserveWatchCount notes a sampling of a watch count
@@ -618,6 +968,31 @@ func SetPriorityLevelConfiguration(priorityLevel string, nominalCL, minCL, maxCL
 	apiserverMaximumConcurrencyLimits.WithLabelValues(priorityLevel).Set(float64(maxCL))
 }
 
+// AddRegisteredWatches adjusts the number of currently registered watches
+// for the given API group and resource by the given (possibly negative) delta.
+func AddRegisteredWatches(group, resource string, delta int) {
+	apiserverRegisteredWatches.WithLabelValues(group, resource).Add(float64(delta))
+}
+
+// AddWatchTrackEvent counts one watch registration or unregistration
+// seen by the watch tracker.
+func AddWatchTrackEvent(group, resource string, registered bool) {
+	event := "unregistered"
+	if registered {
+		event = "registered"
+	}
+	apiserverWatchTrackEvents.WithLabelValues(group, resource, event).Inc()
+}
+
+// SetPriorityLevelSeatBorrowing records how many seats the given priority
+// level is currently lending out and how many it is currently borrowing;
+// at most one of the two is positive.
+func SetPriorityLevelSeatBorrowing(priorityLevel string, lent, borrowed int) {
+	class := classOf(priorityLevel)
+	apiserverLentSeats.WithLabelValues(priorityLevel, class).Set(float64(lent))
+	apiserverBorrowedSeats.WithLabelValues(priorityLevel, class).Set(float64(borrowed))
+}
+
 func NotePriorityLevelConcurrencyAdjustment(priorityLevel string, seatDemandHWM, seatDemandAvg, seatDemandStdev, seatDemandSmoothed, seatDemandTarget float64, currentCL int) {
 	apiserverSeatDemandHighWatermarks.WithLabelValues(priorityLevel).Set(seatDemandHWM)
 	apiserverSeatDemandAverages.WithLabelValues(priorityLevel).Set(seatDemandAvg)
@@ -0,0 +1,86 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+func TestPriorityLevelClasses(t *testing.T) {
+	defer SetPriorityLevelClasses(nil)
+	if got := classOf("workload-low"); got != "" {
+		t.Errorf("expected empty class before configuration, got %q", got)
+	}
+	SetPriorityLevelClasses(map[string]string{
+		"workload-low":    "workload",
+		"workload-high":   "workload",
+		"system-critical": "system",
+	})
+	for plName, want := range map[string]string{
+		"workload-low":    "workload",
+		"workload-high":   "workload",
+		"system-critical": "system",
+		"unmapped":        "",
+	} {
+		if got := classOf(plName); got != want {
+			t.Errorf("classOf(%q) = %q, expected %q", plName, got, want)
+		}
+	}
+
+	Register()
+	Reset()
+	AddRequestDeadlineExceeded(context.Background(), "workload-low", "some-fs")
+	err := GatherAndCompare(`
+# HELP apiserver_flowcontrol_request_deadline_exceeded_total [ALPHA] Number of requests whose total time in the API Priority and Fairness filter exceeded the configured maximum request duration
+# TYPE apiserver_flowcontrol_request_deadline_exceeded_total counter
+apiserver_flowcontrol_request_deadline_exceeded_total{class="workload",flow_schema="some-fs",priority_level="workload-low"} 1
+`, "apiserver_flowcontrol_request_deadline_exceeded_total")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestClassificationToEnqueueGap(t *testing.T) {
+	Register()
+	Reset()
+	ObserveClassificationToEnqueueGap(context.Background(), "workload-low", "some-fs", 3*time.Millisecond)
+	families, err := legacyregistry.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "apiserver_flowcontrol_request_classification_to_enqueue_seconds" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			h := m.GetHistogram()
+			if got := h.GetSampleCount(); got != 1 {
+				t.Errorf("expected one sample, got %d", got)
+			}
+			if got := h.GetSampleSum(); math.Abs(got-0.003) > 1e-9 {
+				t.Errorf("expected a sample sum of 0.003, got %g", got)
+			}
+			return
+		}
+	}
+	t.Error("the classification-to-enqueue histogram was not gathered")
+}
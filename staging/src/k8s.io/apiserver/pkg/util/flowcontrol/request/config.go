@@ -28,6 +28,8 @@ const (
 	objectsPerSeat              = 100.0
 	watchesPerSeat              = 10.0
 	enableMutatingWorkEstimator = true
+	bytesPerSeat                = 100 * 1024.0
+	enableSizeWorkEstimator     = false
 )
 
 var eventAdditionalDuration = 5 * time.Millisecond
@@ -36,6 +38,7 @@ var eventAdditionalDuration = 5 * time.Millisecond
 type WorkEstimatorConfig struct {
 	*ListWorkEstimatorConfig     `json:"listWorkEstimatorConfig,omitempty"`
 	*MutatingWorkEstimatorConfig `json:"mutatingWorkEstimatorConfig,omitempty"`
+	*SizeWorkEstimatorConfig     `json:"sizeWorkEstimatorConfig,omitempty"`
 
 	// MinimumSeats is the minimum number of seats a request must occupy.
 	MinimumSeats uint64 `json:"minimumSeats,omitempty"`
@@ -63,6 +66,18 @@ type MutatingWorkEstimatorConfig struct {
 	WatchesPerSeat          float64         `json:"watchesPerSeat,omitempty"`
 }
 
+// SizeWorkEstimatorConfig holds work estimator parameters related to
+// scaling seats with the size of a mutating request's body.
+type SizeWorkEstimatorConfig struct {
+	// Enabled selects whether the initial seats of mutating requests
+	// are scaled with the request body size; off by default because
+	// the flat estimate is what the algorithm has been tuned with.
+	Enabled bool `json:"enable,omitempty"`
+
+	// BytesPerSeat is the number of body bytes that cost one seat.
+	BytesPerSeat float64 `json:"bytesPerSeat,omitempty"`
+}
+
 // DefaultWorkEstimatorConfig creates a new WorkEstimatorConfig with default values.
 func DefaultWorkEstimatorConfig() *WorkEstimatorConfig {
 	return &WorkEstimatorConfig{
@@ -70,6 +85,7 @@ func DefaultWorkEstimatorConfig() *WorkEstimatorConfig {
 		MaximumSeatsLimit:           maximumSeatsLimit,
 		ListWorkEstimatorConfig:     defaultListWorkEstimatorConfig(),
 		MutatingWorkEstimatorConfig: defaultMutatingWorkEstimatorConfig(),
+		SizeWorkEstimatorConfig:     defaultSizeWorkEstimatorConfig(),
 	}
 }
 
@@ -78,6 +94,14 @@ func defaultListWorkEstimatorConfig() *ListWorkEstimatorConfig {
 	return &ListWorkEstimatorConfig{ObjectsPerSeat: objectsPerSeat}
 }
 
+// defaultSizeWorkEstimatorConfig creates a new SizeWorkEstimatorConfig with default values.
+func defaultSizeWorkEstimatorConfig() *SizeWorkEstimatorConfig {
+	return &SizeWorkEstimatorConfig{
+		Enabled:      enableSizeWorkEstimator,
+		BytesPerSeat: bytesPerSeat,
+	}
+}
+
 // defaultMutatingWorkEstimatorConfig creates a new MutatingWorkEstimatorConfig with default values.
 func defaultMutatingWorkEstimatorConfig() *MutatingWorkEstimatorConfig {
 	return &MutatingWorkEstimatorConfig{
@@ -49,7 +49,7 @@ func (e *mutatingWorkEstimator) estimate(r *http.Request, flowSchemaName, priori
 
 	// TODO(wojtekt): Remove once we tune the algorithm to not fail
 	// scalability tests.
-	if !e.config.Enabled {
+	if !e.config.MutatingWorkEstimatorConfig.Enabled {
 		return WorkEstimate{
 			InitialSeats: minSeats,
 		}
@@ -0,0 +1,61 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package request
+
+import (
+	"math"
+	"net/http"
+)
+
+// newSizeWorkEstimator returns an estimator that scales a mutating
+// request's initial seats with the size of its body, read from the
+// request's content length.  It lets experiments model the admission
+// cost of large create requests more realistically than the flat
+// one-seat estimate does; it is disabled by default.
+func newSizeWorkEstimator(config *WorkEstimatorConfig, maxSeatsFn maxSeatsFunc) WorkEstimatorFunc {
+	estimator := &sizeWorkEstimator{
+		config:     config,
+		maxSeatsFn: maxSeatsFn,
+	}
+	return estimator.estimate
+}
+
+type sizeWorkEstimator struct {
+	config     *WorkEstimatorConfig
+	maxSeatsFn maxSeatsFunc
+}
+
+func (e *sizeWorkEstimator) estimate(r *http.Request, flowSchemaName, priorityLevelName string) WorkEstimate {
+	minSeats := e.config.MinimumSeats
+	if !e.config.SizeWorkEstimatorConfig.Enabled || r.ContentLength <= 0 {
+		// An unknown content length, e.g. a chunked body, gives the
+		// estimator nothing to scale by.
+		return WorkEstimate{InitialSeats: minSeats}
+	}
+	maxSeats := e.maxSeatsFn(priorityLevelName)
+	if maxSeats == 0 || maxSeats > e.config.MaximumSeatsLimit {
+		maxSeats = e.config.MaximumSeatsLimit
+	}
+	seats := uint64(math.Ceil(float64(r.ContentLength) / e.config.BytesPerSeat))
+	if seats < minSeats {
+		seats = minSeats
+	}
+	if seats > maxSeats {
+		seats = maxSeats
+	}
+	return WorkEstimate{InitialSeats: seats}
+}
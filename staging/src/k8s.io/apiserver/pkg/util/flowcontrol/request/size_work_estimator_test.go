@@ -0,0 +1,120 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package request
+
+import (
+	"net/http"
+	"testing"
+
+	apirequest "k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func TestSizeWorkEstimator(t *testing.T) {
+	tests := []struct {
+		name                 string
+		enabled              bool
+		bytesPerSeat         float64
+		contentLength        int64
+		maxSeats             uint64
+		initialSeatsExpected uint64
+	}{
+		{
+			name:                 "disabled yields the flat estimate",
+			enabled:              false,
+			bytesPerSeat:         1024,
+			contentLength:        10 * 1024,
+			maxSeats:             10,
+			initialSeatsExpected: 1,
+		},
+		{
+			name:                 "seats scale with body size",
+			enabled:              true,
+			bytesPerSeat:         1024,
+			contentLength:        3 * 1024,
+			maxSeats:             10,
+			initialSeatsExpected: 3,
+		},
+		{
+			name:                 "partial seats round up",
+			enabled:              true,
+			bytesPerSeat:         1024,
+			contentLength:        2*1024 + 1,
+			maxSeats:             10,
+			initialSeatsExpected: 3,
+		},
+		{
+			name:                 "seats are capped at max seats",
+			enabled:              true,
+			bytesPerSeat:         1024,
+			contentLength:        100 * 1024,
+			maxSeats:             10,
+			initialSeatsExpected: 10,
+		},
+		{
+			name:                 "small body keeps the minimum",
+			enabled:              true,
+			bytesPerSeat:         1024,
+			contentLength:        100,
+			maxSeats:             10,
+			initialSeatsExpected: 1,
+		},
+		{
+			name:                 "unknown content length keeps the minimum",
+			enabled:              true,
+			bytesPerSeat:         1024,
+			contentLength:        -1,
+			maxSeats:             10,
+			initialSeatsExpected: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := DefaultWorkEstimatorConfig()
+			cfg.SizeWorkEstimatorConfig.Enabled = test.enabled
+			cfg.SizeWorkEstimatorConfig.BytesPerSeat = test.bytesPerSeat
+
+			countsFn := func(key string) (int64, error) {
+				return 0, nil
+			}
+			watchCountsFn := func(_ *apirequest.RequestInfo) int {
+				return 0
+			}
+			maxSeatsFn := func(_ string) uint64 {
+				return test.maxSeats
+			}
+			estimator := NewWorkEstimator(countsFn, watchCountsFn, cfg, maxSeatsFn)
+
+			req, err := http.NewRequest("POST", "http://server/apis/batch/v1/namespaces/ns/jobs", nil)
+			if err != nil {
+				t.Fatalf("Failed to create new HTTP request - %v", err)
+			}
+			req.ContentLength = test.contentLength
+			req = req.WithContext(apirequest.WithRequestInfo(req.Context(), &apirequest.RequestInfo{
+				Verb:      "create",
+				APIGroup:  "batch",
+				Resource:  "jobs",
+				Namespace: "ns",
+			}))
+
+			workestimateGot := estimator.EstimateWork(req, "testFS", "testPL")
+			if test.initialSeatsExpected != workestimateGot.InitialSeats {
+				t.Errorf("Expected work estimate to match: %d initial seats, but got: %d", test.initialSeatsExpected, workestimateGot.InitialSeats)
+			}
+		})
+	}
+}
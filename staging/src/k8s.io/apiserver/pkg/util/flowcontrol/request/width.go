@@ -77,6 +77,7 @@ func NewWorkEstimator(objectCountFn objectCountGetterFunc, watchCountFn watchCou
 		maximumSeatsLimit:     config.MaximumSeatsLimit,
 		listWorkEstimator:     newListWorkEstimator(objectCountFn, config, maxSeatsFn),
 		mutatingWorkEstimator: newMutatingWorkEstimator(watchCountFn, config, maxSeatsFn),
+		sizeWorkEstimator:     newSizeWorkEstimator(config, maxSeatsFn),
 	}
 	return estimator.estimate
 }
@@ -99,6 +100,9 @@ type workEstimator struct {
 	listWorkEstimator WorkEstimatorFunc
 	// mutatingWorkEstimator calculates the width of mutating request(s)
 	mutatingWorkEstimator WorkEstimatorFunc
+	// sizeWorkEstimator scales the width of mutating request(s) with
+	// the size of the request body, when enabled
+	sizeWorkEstimator WorkEstimatorFunc
 }
 
 func (e *workEstimator) estimate(r *http.Request, flowSchemaName, priorityLevelName string) WorkEstimate {
@@ -122,7 +126,13 @@ func (e *workEstimator) estimate(r *http.Request, flowSchemaName, priorityLevelN
 			return e.listWorkEstimator.EstimateWork(r, flowSchemaName, priorityLevelName)
 		}
 	case "create", "update", "patch", "delete":
-		return e.mutatingWorkEstimator.EstimateWork(r, flowSchemaName, priorityLevelName)
+		estimate := e.mutatingWorkEstimator.EstimateWork(r, flowSchemaName, priorityLevelName)
+		// The size-based estimate can only raise the initial seats;
+		// the watch-driven final-seat estimate is kept as is.
+		if sizeEstimate := e.sizeWorkEstimator.EstimateWork(r, flowSchemaName, priorityLevelName); sizeEstimate.InitialSeats > estimate.InitialSeats {
+			estimate.InitialSeats = sizeEstimate.InitialSeats
+		}
+		return estimate
 	}
 
 	return WorkEstimate{InitialSeats: e.minimumSeats}
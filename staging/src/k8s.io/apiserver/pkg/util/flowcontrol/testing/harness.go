@@ -0,0 +1,304 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package testing provides a harness that drives a flow-control
+// filter built by NewTestable with a fake clock and a capturing
+// tracer, so that the tracing features can be verified without a real
+// apiserver.
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/server/mux"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	"k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing/testing/eventclock"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+	"k8s.io/client-go/informers"
+	clientsetfake "k8s.io/client-go/kubernetes/fake"
+)
+
+// Config carries the parameters of a Harness.
+type Config struct {
+	// Objects are the FlowSchemas and PriorityLevelConfigurations to
+	// digest.  NewHarness does not return until every FlowSchema among
+	// them has been digested with its priority level in place.
+	Objects []runtime.Object
+
+	// ServerConcurrencyLimit for the filter to enforce; zero means 24.
+	ServerConcurrencyLimit int
+
+	// AbsoluteConcurrency optionally pins named priority levels to
+	// absolute seat counts; see
+	// TestableConfig.AbsoluteConcurrency.
+	AbsoluteConcurrency map[string]int
+
+	// StrictFIFOByFlowSchema optionally serializes execution starts
+	// per FlowSchema; see TestableConfig.StrictFIFOByFlowSchema.
+	StrictFIFOByFlowSchema map[string]bool
+
+	// MaxQueueLength optionally overrides the per-queue length limit
+	// of named priority levels; see TestableConfig.MaxQueueLength.
+	MaxQueueLength map[string]int
+
+	// EnableSyntheticInjection serves the synthetic-request debug
+	// endpoint; see TestableConfig.EnableSyntheticInjection.
+	EnableSyntheticInjection bool
+
+	// ImmediateDispatchEpsilon optionally counts sub-epsilon queue
+	// waits separately; see
+	// TestableConfig.ImmediateDispatchEpsilon.
+	ImmediateDispatchEpsilon time.Duration
+
+	// ConfigWorkerCount is the number of config worker goroutines;
+	// see TestableConfig.ConfigWorkerCount.
+	ConfigWorkerCount int
+
+	// DigestionLagThreshold optionally marks config changes whose
+	// digestion starts late; see
+	// TestableConfig.DigestionLagThreshold.
+	DigestionLagThreshold time.Duration
+
+	// RuntimeStatsAccounting attaches periodically sampled Go runtime
+	// state to lifecycle events; see
+	// TestableConfig.RuntimeStatsAccounting.
+	RuntimeStatsAccounting bool
+
+	// TraceIDFunc, if non-nil, replaces the UUID generator used for
+	// trace ids of traced requests that did not supply one; see
+	// TestableConfig.TraceIDFunc.  A counter makes assertions on
+	// captured markers deterministic.
+	TraceIDFunc func() string
+
+	// ExecutionPprofLabels runs execFn under pprof labels naming the
+	// priority level and flow schema; see
+	// TestableConfig.ExecutionPprofLabels.
+	ExecutionPprofLabels bool
+
+	// MaxRequestDuration optionally bounds each request's total
+	// queue-plus-execution time; see
+	// TestableConfig.MaxRequestDuration.
+	MaxRequestDuration time.Duration
+
+	// PriorityLevelClasses optionally maps priority-level name to the
+	// class reported in the "class" metric label; see
+	// TestableConfig.PriorityLevelClasses.
+	PriorityLevelClasses map[string]string
+
+	// ConfigDebounceWindow coalesces bursts of config changes into a
+	// single digestion; see TestableConfig.ConfigDebounceWindow.
+	ConfigDebounceWindow time.Duration
+
+	// QueueSelectionLogging logs each request's queue assignment and
+	// serves the per-queue request histogram; see
+	// TestableConfig.QueueSelectionLogging.
+	QueueSelectionLogging bool
+
+	// Rules for the harness's capturing tracer; nil means the default
+	// ruleset.
+	Rules []continuum.Rule
+
+	// OnConfigApplied, if non-nil, is invoked after each config
+	// digestion with the resulting seat allocation; see
+	// TestableConfig.OnConfigApplied.
+	OnConfigApplied func(allocation map[string]int)
+}
+
+// Harness wraps a flow-control filter with a fake clock and a tracer
+// whose markers it captures in emission order.
+type Harness struct {
+	// Filter is the filter under test.
+	Filter utilflowcontrol.Interface
+
+	// Clock is the fake clock the filter reads; advance it to shape
+	// the recorded durations and timestamps.
+	Clock *eventclock.Fake
+
+	mux    *mux.PathRecorderMux
+	sink   *capturingSink
+	tracer *continuum.RequestTracer
+	stopCh chan struct{}
+	// runDone is closed when the controller's Run returns; nil if Run
+	// was never started.  Stop waits for it so that its tracer
+	// cleanup does not race with the controller's own.
+	runDone chan struct{}
+}
+
+// capturingSink appends every marker it is given to a slice, under a
+// mutex so that concurrent requests do not race.
+type capturingSink struct {
+	lock    sync.Mutex
+	markers []continuum.Marker
+}
+
+func (s *capturingSink) Write(m continuum.Marker) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.markers = append(s.markers, m)
+}
+
+func (s *capturingSink) snapshot() []continuum.Marker {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]continuum.Marker(nil), s.markers...)
+}
+
+// NewHarness builds a filter from the given config objects, registers
+// a capturing tracer, starts config digestion, and waits for the
+// given FlowSchemas to be digested.  Call Stop when done with it.
+func NewHarness(config Config) (*Harness, error) {
+	metrics.Register()
+	serverConcurrencyLimit := config.ServerConcurrencyLimit
+	if serverConcurrencyLimit == 0 {
+		serverConcurrencyLimit = 24
+	}
+	clientset := clientsetfake.NewSimpleClientset(config.Objects...)
+	informerFactory := informers.NewSharedInformerFactory(clientset, time.Second)
+	clk, _ := eventclock.NewFake(time.Now(), 0, nil)
+	filter := utilflowcontrol.NewTestable(utilflowcontrol.TestableConfig{
+		Name:                     "Harness",
+		Clock:                    clk,
+		AsFieldManager:           utilflowcontrol.ConfigConsumerAsFieldManager,
+		FoundToDangling:          func(found bool) bool { return !found },
+		InformerFactory:          informerFactory,
+		FlowcontrolClient:        clientset.FlowcontrolV1(),
+		ServerConcurrencyLimit:   serverConcurrencyLimit,
+		AbsoluteConcurrency:      config.AbsoluteConcurrency,
+		StrictFIFOByFlowSchema:   config.StrictFIFOByFlowSchema,
+		MaxQueueLength:           config.MaxQueueLength,
+		EnableSyntheticInjection: config.EnableSyntheticInjection,
+		ImmediateDispatchEpsilon: config.ImmediateDispatchEpsilon,
+		ConfigWorkerCount:        config.ConfigWorkerCount,
+		DigestionLagThreshold:    config.DigestionLagThreshold,
+		ConfigDebounceWindow:     config.ConfigDebounceWindow,
+		QueueSelectionLogging:    config.QueueSelectionLogging,
+		RuntimeStatsAccounting:   config.RuntimeStatsAccounting,
+		TraceIDFunc:              config.TraceIDFunc,
+		MaxRequestDuration:       config.MaxRequestDuration,
+		ExecutionPprofLabels:     config.ExecutionPprofLabels,
+		PriorityLevelClasses:     config.PriorityLevelClasses,
+		OnConfigApplied:          config.OnConfigApplied,
+		ReqsGaugeVec:             metrics.PriorityLevelConcurrencyGaugeVec,
+		ExecSeatsGaugeVec:        metrics.PriorityLevelExecutionSeatsGaugeVec,
+		// QueueSetFactory is left nil so the filter builds one on the
+		// same fake clock, keeping queueing and duration measurement
+		// on a single time line.
+	})
+	h := &Harness{
+		Filter: filter,
+		Clock:  clk,
+		mux:    mux.NewPathRecorderMux("flowcontrol-harness"),
+		sink:   &capturingSink{},
+		stopCh: make(chan struct{}),
+	}
+	rules := config.Rules
+	if rules == nil {
+		rules = continuum.DefaultRules()
+	}
+	h.tracer = &continuum.RequestTracer{Name: "harness", Rules: rules, Sink: h.sink}
+	continuum.RegisterTracer(h.tracer)
+	filter.Install(h.mux)
+	informerFactory.Start(h.stopCh)
+	for informerType, synced := range informerFactory.WaitForCacheSync(h.stopCh) {
+		if !synced {
+			h.Stop()
+			return nil, fmt.Errorf("cache sync failed for %v", informerType)
+		}
+	}
+	h.runDone = make(chan struct{})
+	go func() {
+		defer close(h.runDone)
+		filter.Run(h.stopCh)
+	}()
+	if err := h.waitForDigestion(config.Objects); err != nil {
+		h.Stop()
+		return nil, err
+	}
+	return h, nil
+}
+
+// waitForDigestion polls the flowschemas debug endpoint until every
+// given FlowSchema has been digested and references an existing
+// priority level.
+func (h *Harness) waitForDigestion(objects []runtime.Object) error {
+	wanted := map[string]bool{}
+	for _, obj := range objects {
+		if fs, isFS := obj.(*flowcontrolv1.FlowSchema); isFS {
+			wanted[fs.Name] = true
+		}
+	}
+	return wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		recorder := httptest.NewRecorder()
+		h.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/flowcontrol/flowschemas", nil))
+		var entries []struct {
+			Name     string `json:"name"`
+			Dangling bool   `json:"dangling"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+			return false, nil
+		}
+		digested := map[string]bool{}
+		for _, entry := range entries {
+			digested[entry.Name] = !entry.Dangling
+		}
+		for name := range wanted {
+			if !digested[name] {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+}
+
+// Submit drives one request through the filter, invoking execFn if
+// the filter dispatches it, and returns the markers the capturing
+// tracer emitted during the call, in emission order.
+func (h *Harness) Submit(ctx context.Context, rd utilflowcontrol.RequestDigest, workEstimate fcrequest.WorkEstimate, execFn func()) []continuum.Marker {
+	before := len(h.sink.snapshot())
+	h.Filter.Handle(ctx, rd,
+		func(fs *flowcontrolv1.FlowSchema, pl *flowcontrolv1.PriorityLevelConfiguration, flowDistinguisher string) {
+		},
+		func() fcrequest.WorkEstimate { return workEstimate },
+		fq.QueueNoteFn(func(inQueue bool) {}),
+		execFn)
+	return h.sink.snapshot()[before:]
+}
+
+// Markers returns all markers captured so far, in emission order.
+func (h *Harness) Markers() []continuum.Marker {
+	return h.sink.snapshot()
+}
+
+// Stop shuts down config digestion and unregisters the capturing
+// tracer.
+func (h *Harness) Stop() {
+	close(h.stopCh)
+	if h.runDone != nil {
+		<-h.runDone
+	}
+	continuum.UnregisterTracer(h.tracer)
+}
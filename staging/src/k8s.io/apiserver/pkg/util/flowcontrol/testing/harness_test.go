@@ -0,0 +1,1005 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+	"k8s.io/utils/ptr"
+)
+
+func harnessConfigObjects() []runtime.Object {
+	fsObj := &flowcontrolv1.FlowSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "harness-fs"},
+		Spec: flowcontrolv1.FlowSchemaSpec{
+			MatchingPrecedence: 100,
+			PriorityLevelConfiguration: flowcontrolv1.PriorityLevelConfigurationReference{
+				Name: "harness-pl",
+			},
+			Rules: []flowcontrolv1.PolicyRulesWithSubjects{{
+				Subjects: []flowcontrolv1.Subject{{
+					Kind: flowcontrolv1.SubjectKindUser,
+					User: &flowcontrolv1.UserSubject{Name: "harness-user"},
+				}},
+				ResourceRules: []flowcontrolv1.ResourcePolicyRule{{
+					Verbs:        []string{"*"},
+					APIGroups:    []string{"*"},
+					Resources:    []string{"*"},
+					ClusterScope: true,
+					Namespaces:   []string{"*"},
+				}},
+			}},
+		},
+	}
+	plcObj := &flowcontrolv1.PriorityLevelConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "harness-pl"},
+		Spec: flowcontrolv1.PriorityLevelConfigurationSpec{
+			Type: flowcontrolv1.PriorityLevelEnablementLimited,
+			Limited: &flowcontrolv1.LimitedPriorityLevelConfiguration{
+				NominalConcurrencyShares: ptr.To(int32(100)),
+				LendablePercent:          ptr.To(int32(0)),
+				BorrowingLimitPercent:    ptr.To(int32(0)),
+				LimitResponse: flowcontrolv1.LimitResponse{
+					Type: flowcontrolv1.LimitResponseTypeQueue,
+					Queuing: &flowcontrolv1.QueuingConfiguration{
+						Queues:           8,
+						HandSize:         2,
+						QueueLengthLimit: 10,
+					},
+				},
+			},
+		},
+	}
+	return []runtime.Object{fsObj, plcObj}
+}
+
+func TestHarnessCapturesMarkerPair(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{
+			IsResourceRequest: true,
+			Verb:              "create",
+			APIVersion:        "v1",
+			Resource:          "pods",
+			Namespace:         "default",
+		},
+		User: &user.DefaultInfo{Name: "harness-user"},
+	}
+	var executedAt int64
+	markers := h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+		executedAt = continuum.TimestampFrom(h.Clock.Now())
+	})
+	var pair []continuum.Marker
+	for _, m := range markers {
+		if m.ID == continuum.MarkerPodCreateEnter || m.ID == continuum.MarkerPodCreateFinish {
+			pair = append(pair, m)
+		}
+	}
+	if len(pair) != 2 {
+		t.Fatalf("expected an enter/finish marker pair, got %#v", markers)
+	}
+	if pair[0].ID != continuum.MarkerPodCreateEnter || pair[1].ID != continuum.MarkerPodCreateFinish {
+		t.Errorf("wrong marker IDs: %#v", pair)
+	}
+	if pair[0].Time > executedAt || executedAt > pair[1].Time {
+		t.Errorf("markers do not bracket execution: enter=%d executed=%d finish=%d", pair[0].Time, executedAt, pair[1].Time)
+	}
+	if pair[0].FlowSchema != "harness-fs" || pair[0].PriorityLevel != "harness-pl" {
+		t.Errorf("wrong classification on marker: %#v", pair[0])
+	}
+
+	if got := h.Submit(context.Background(), utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "get", APIVersion: "v1", Resource: "secrets"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}, fcrequest.WorkEstimate{InitialSeats: 1}, func() {}); len(got) != 0 {
+		t.Errorf("expected no markers for an untraced request, got %#v", got)
+	}
+}
+
+func TestQueueTransitionMarkers(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects(), ServerConcurrencyLimit: 1})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+	}()
+	// Wait until the second request has visibly joined a queue, then
+	// let the first one finish so the second dispatches.
+	hasMarker := func(id int) bool {
+		for _, m := range h.Markers() {
+			if m.ID == id {
+				return true
+			}
+		}
+		return false
+	}
+	if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return hasMarker(continuum.MarkerRequestEnqueued), nil
+	}); err != nil {
+		t.Fatalf("never saw the enqueued marker: %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if !hasMarker(continuum.MarkerRequestDequeued) {
+		t.Errorf("expected a dequeued marker, got %#v", h.Markers())
+	}
+	for _, m := range h.Markers() {
+		if m.ID == continuum.MarkerRequestEnqueued && m.PriorityLevel != "harness-pl" {
+			t.Errorf("enqueued marker lacks classification: %#v", m)
+		}
+	}
+}
+
+func TestAbsoluteConcurrencyPinsNominalLimit(t *testing.T) {
+	h, err := NewHarness(Config{
+		Objects:                harnessConfigObjects(),
+		ServerConcurrencyLimit: 24,
+		AbsoluteConcurrency:    map[string]int{"harness-pl": 7},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	recorder := httptest.NewRecorder()
+	h.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/flowcontrol/borrowing", nil))
+	var entries []struct {
+		Name      string `json:"name"`
+		NominalCL int    `json:"nominalConcurrencyLimit"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to decode borrowing dump %q: %v", recorder.Body.String(), err)
+	}
+	found := false
+	for _, entry := range entries {
+		if entry.Name == "harness-pl" {
+			found = true
+			if entry.NominalCL != 7 {
+				t.Errorf("expected the pinned priority level to have 7 nominal seats, got %#v", entry)
+			}
+		} else if entry.NominalCL > 24-7 {
+			t.Errorf("unpinned priority level exceeds the remaining concurrency: %#v", entry)
+		}
+	}
+	if !found {
+		t.Errorf("no entry for harness-pl in %#v", entries)
+	}
+}
+
+// fifoConfigObjects builds a FlowSchema that fans requests of one
+// group out over multiple queues by user, so that dispatch order can
+// diverge from arrival order.
+func fifoConfigObjects() []runtime.Object {
+	fsObj := &flowcontrolv1.FlowSchema{
+		ObjectMeta: metav1.ObjectMeta{Name: "fifo-fs"},
+		Spec: flowcontrolv1.FlowSchemaSpec{
+			MatchingPrecedence: 100,
+			PriorityLevelConfiguration: flowcontrolv1.PriorityLevelConfigurationReference{
+				Name: "fifo-pl",
+			},
+			DistinguisherMethod: &flowcontrolv1.FlowDistinguisherMethod{Type: flowcontrolv1.FlowDistinguisherMethodByUserType},
+			Rules: []flowcontrolv1.PolicyRulesWithSubjects{{
+				Subjects: []flowcontrolv1.Subject{{
+					Kind:  flowcontrolv1.SubjectKindGroup,
+					Group: &flowcontrolv1.GroupSubject{Name: "fifo-group"},
+				}},
+				ResourceRules: []flowcontrolv1.ResourcePolicyRule{{
+					Verbs:        []string{"*"},
+					APIGroups:    []string{"*"},
+					Resources:    []string{"*"},
+					ClusterScope: true,
+					Namespaces:   []string{"*"},
+				}},
+			}},
+		},
+	}
+	plcObj := &flowcontrolv1.PriorityLevelConfiguration{
+		ObjectMeta: metav1.ObjectMeta{Name: "fifo-pl"},
+		Spec: flowcontrolv1.PriorityLevelConfigurationSpec{
+			Type: flowcontrolv1.PriorityLevelEnablementLimited,
+			Limited: &flowcontrolv1.LimitedPriorityLevelConfiguration{
+				NominalConcurrencyShares: ptr.To(int32(100)),
+				LendablePercent:          ptr.To(int32(0)),
+				BorrowingLimitPercent:    ptr.To(int32(0)),
+				LimitResponse: flowcontrolv1.LimitResponse{
+					Type: flowcontrolv1.LimitResponseTypeQueue,
+					Queuing: &flowcontrolv1.QueuingConfiguration{
+						Queues:           8,
+						HandSize:         2,
+						QueueLengthLimit: 10,
+					},
+				},
+			},
+		},
+	}
+	return []runtime.Object{fsObj, plcObj}
+}
+
+func TestStrictFIFOExecutionOrder(t *testing.T) {
+	h, err := NewHarness(Config{
+		Objects:                fifoConfigObjects(),
+		ServerConcurrencyLimit: 3,
+		StrictFIFOByFlowSchema: map[string]bool{"fifo-fs": true},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	digest := func(userName string) utilflowcontrol.RequestDigest {
+		return utilflowcontrol.RequestDigest{
+			RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+			User:        &user.DefaultInfo{Name: userName, Groups: []string{"fifo-group"}},
+		}
+	}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	// Fill every seat of the priority level so the followers queue.
+	for i := 0; i < 3; i++ {
+		started := make(chan struct{})
+		wg.Add(1)
+		go func(userName string) {
+			defer wg.Done()
+			h.Submit(context.Background(), digest(userName), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+				close(started)
+				<-release
+			})
+		}(fmt.Sprintf("blocker-%d", i))
+		<-started
+	}
+	countEnqueued := func() int {
+		n := 0
+		for _, m := range h.Markers() {
+			if m.ID == continuum.MarkerRequestEnqueued {
+				n++
+			}
+		}
+		return n
+	}
+	// The followers arrive in a known order; waiting for each one's
+	// enqueued marker before sending the next pins the ticket order.
+	base := countEnqueued()
+	var orderLock sync.Mutex
+	var order []int
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			h.Submit(context.Background(), digest(fmt.Sprintf("follower-%d", i)), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+				orderLock.Lock()
+				defer orderLock.Unlock()
+				order = append(order, i)
+			})
+		}(i)
+		if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			return countEnqueued() >= base+i, nil
+		}); err != nil {
+			t.Fatalf("follower %d never queued: %v", i, err)
+		}
+	}
+	close(release)
+	wg.Wait()
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(order, want) {
+		t.Errorf("expected executions to start in arrival order %v, got %v", want, order)
+	}
+}
+
+func TestSyntheticInjection(t *testing.T) {
+	h, err := NewHarness(Config{
+		Objects:                  harnessConfigObjects(),
+		ServerConcurrencyLimit:   1,
+		EnableSyntheticInjection: true,
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	post := func(target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.mux.ServeHTTP(recorder, httptest.NewRequest("POST", target, nil))
+		return recorder
+	}
+	if got := post("/debug/flowcontrol/inject_synthetic?priorityLevel=harness-pl").Code; got != 400 {
+		t.Errorf("expected 400 without a flowSchema, got %d", got)
+	}
+	if got := post("/debug/flowcontrol/inject_synthetic?priorityLevel=no-such-pl&flowSchema=synthetic-load").Code; got != 404 {
+		t.Errorf("expected 404 for an unknown priority level, got %d", got)
+	}
+	if got := post("/debug/flowcontrol/inject_synthetic?priorityLevel=harness-pl&flowSchema=synthetic-load&hold=500ms").Code; got != 200 {
+		t.Fatalf("expected the injection to be accepted, got %d", got)
+	}
+	// Wait for the synthetic request to occupy the level's only seat.
+	executing := func() int {
+		recorder := httptest.NewRecorder()
+		h.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/api_priority_and_fairness/dump_priority_levels", nil))
+		for _, line := range strings.Split(recorder.Body.String(), "\n") {
+			if strings.HasPrefix(line, "harness-pl") {
+				fields := strings.Split(line, ",")
+				if len(fields) >= 6 {
+					n, _ := strconv.Atoi(strings.TrimSpace(fields[5]))
+					return n
+				}
+			}
+		}
+		return -1
+	}
+	if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		return executing() == 1, nil
+	}); err != nil {
+		t.Fatalf("synthetic request never occupied a seat: %v", err)
+	}
+	// A real request now has to wait for the synthetic one's hold to
+	// expire before it can execute.
+	before := time.Now()
+	executed := false
+	h.Submit(context.Background(), utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}, fcrequest.WorkEstimate{InitialSeats: 1}, func() { executed = true })
+	if !executed {
+		t.Error("expected the real request to execute after the hold expired")
+	}
+	if waited := time.Since(before); waited < 100*time.Millisecond {
+		t.Errorf("expected the real request to wait behind the synthetic one, waited only %v", waited)
+	}
+}
+
+func TestOnConfigAppliedReportsAllocation(t *testing.T) {
+	var lock sync.Mutex
+	var allocations []map[string]int
+	h, err := NewHarness(Config{
+		Objects:                harnessConfigObjects(),
+		ServerConcurrencyLimit: 24,
+		AbsoluteConcurrency:    map[string]int{"harness-pl": 7},
+		OnConfigApplied: func(allocation map[string]int) {
+			lock.Lock()
+			defer lock.Unlock()
+			allocations = append(allocations, allocation)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	if len(allocations) == 0 {
+		t.Fatal("expected at least one config digestion to be observed")
+	}
+	last := allocations[len(allocations)-1]
+	if last["harness-pl"] != 7 {
+		t.Errorf("expected the pinned allocation in the callback, got %#v", last)
+	}
+	total := 0
+	for _, seats := range last {
+		total += seats
+	}
+	if total < 24 {
+		t.Errorf("expected the allocation to cover the server concurrency limit, got %#v", last)
+	}
+}
+
+func TestRunIDTagsMarkers(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	markers := h.Submit(continuum.WithRunID(context.Background(), "run-7"), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+	if len(markers) == 0 {
+		t.Fatal("expected markers for a traced request")
+	}
+	for _, m := range markers {
+		if m.RunID != "run-7" {
+			t.Errorf("expected marker tagged with the run id, got %#v", m)
+		}
+	}
+	for _, m := range h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {}) {
+		if m.RunID != "" {
+			t.Errorf("expected untagged markers without a run id, got %#v", m)
+		}
+	}
+}
+
+func TestExecutionPprofLabels(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects(), ExecutionPprofLabels: true})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	// The goroutine profile at debug level 1 prints the labels of
+	// each goroutine, which is the only way to observe the labels of
+	// the executing goroutine from inside execFn.
+	var profile strings.Builder
+	h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+		if err := pprof.Lookup("goroutine").WriteTo(&profile, 1); err != nil {
+			t.Errorf("writing the goroutine profile failed: %v", err)
+		}
+	})
+	if got := profile.String(); !strings.Contains(got, `"flow_schema":"harness-fs"`) || !strings.Contains(got, `"priority_level":"harness-pl"`) {
+		t.Error("expected the executing goroutine to carry the priority_level and flow_schema pprof labels")
+	}
+}
+
+func TestMaxRequestDurationEmitsDeadlineMarker(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects(), MaxRequestDuration: 50 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	markers := h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+		time.Sleep(120 * time.Millisecond)
+	})
+	var sawDeadline bool
+	for _, m := range markers {
+		if m.ID == continuum.MarkerRequestDeadlineExceeded {
+			sawDeadline = true
+		}
+	}
+	if !sawDeadline {
+		t.Errorf("expected a deadline-exceeded marker for a request outliving the bound, got %#v", markers)
+	}
+	for _, m := range h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {}) {
+		if m.ID == continuum.MarkerRequestDeadlineExceeded {
+			t.Errorf("expected no deadline-exceeded marker for a prompt request, got %#v", m)
+		}
+	}
+}
+
+func TestTraceIDFuncGeneratesIDs(t *testing.T) {
+	var counter int
+	h, err := NewHarness(Config{
+		Objects: harnessConfigObjects(),
+		TraceIDFunc: func() string {
+			counter++
+			return fmt.Sprintf("trace-%d", counter)
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	for want := 1; want <= 2; want++ {
+		markers := h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+		if len(markers) == 0 {
+			t.Fatal("expected markers for a traced request")
+		}
+		for _, m := range markers {
+			if m.TraceID != fmt.Sprintf("trace-%d", want) {
+				t.Errorf("expected generated trace id trace-%d, got %#v", want, m)
+			}
+		}
+	}
+	for _, m := range h.Submit(continuum.WithTraceID(context.Background(), "external"), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {}) {
+		if m.TraceID != "external" {
+			t.Errorf("expected the externally supplied trace id to win, got %#v", m)
+		}
+	}
+}
+
+func TestUnifiedClockAccessor(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Stop()
+	ti, ok := h.Filter.(utilflowcontrol.TestableInterface)
+	if !ok {
+		t.Fatalf("filter of type %T does not provide TestableInterface", h.Filter)
+	}
+	// The filter and the queuing implementation share the harness's
+	// fake clock, so the accessor must return exactly it.
+	if ti.Clock() != h.Clock {
+		t.Errorf("expected the accessor to return the harness clock, got %#v", ti.Clock())
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	srv := httptest.NewServer(h.mux)
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/debug/flowcontrol/events", nil)
+	if err != nil {
+		t.Fatalf("building stream request failed: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("opening the event stream failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected an SSE content type, got %q", ct)
+	}
+
+	type streamEvent struct {
+		Event         string `json:"event"`
+		FlowSchema    string `json:"flowSchema"`
+		PriorityLevel string `json:"priorityLevel"`
+	}
+	eventCh := make(chan streamEvent, 64)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var ev streamEvent
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+				continue
+			}
+			eventCh <- ev
+		}
+	}()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+
+	seen := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for !(seen["enter"] && seen["finish"]) {
+		select {
+		case ev := <-eventCh:
+			if ev.FlowSchema != "harness-fs" || ev.PriorityLevel != "harness-pl" {
+				t.Errorf("wrong classification on event: %#v", ev)
+			}
+			seen[ev.Event] = true
+		case <-timeout:
+			t.Fatalf("timed out waiting for enter and finish events, saw %v", seen)
+		}
+	}
+}
+
+func TestImmediateDispatchCounter(t *testing.T) {
+	h, err := NewHarness(Config{
+		Objects:                  harnessConfigObjects(),
+		ImmediateDispatchEpsilon: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+
+	// The fake clock did not advance, so the observed wait is zero and
+	// falls below the epsilon.
+	expected := `
+# HELP apiserver_flowcontrol_immediate_dispatches_total [ALPHA] Number of queued requests whose observed queue wait stayed below the configured epsilon, i.e. that were dispatched effectively immediately
+# TYPE apiserver_flowcontrol_immediate_dispatches_total counter
+apiserver_flowcontrol_immediate_dispatches_total{class="",flow_schema="harness-fs",priority_level="harness-pl"} 1
+`
+	if err := metrics.GatherAndCompare(expected, "apiserver_flowcontrol_immediate_dispatches_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestRejectAllEndpoint(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		recorder := httptest.NewRecorder()
+		h.mux.ServeHTTP(recorder, httptest.NewRequest(method, target, nil))
+		return recorder
+	}
+	if got := serve("POST", "/debug/flowcontrol/reject_all").Code; got != 400 {
+		t.Errorf("expected 400 without a priorityLevel, got %d", got)
+	}
+	if got := serve("POST", "/debug/flowcontrol/reject_all?priorityLevel=harness-pl").Code; got != 200 {
+		t.Fatalf("expected the toggle to be accepted, got %d", got)
+	}
+	if got := strings.TrimSpace(serve("GET", "/debug/flowcontrol/reject_all").Body.String()); got != `["harness-pl"]` {
+		t.Errorf("expected the flagged level to be listed, got %q", got)
+	}
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	executed := false
+	markers := h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { executed = true })
+	if executed {
+		t.Error("expected the request to be rejected while the flag was set")
+	}
+	var sawForceReject bool
+	for _, m := range markers {
+		if m.ID == continuum.MarkerForceRejected {
+			sawForceReject = true
+			if m.PriorityLevel != "harness-pl" {
+				t.Errorf("expected the marker to name the rejected level, got %#v", m)
+			}
+		}
+	}
+	if !sawForceReject {
+		t.Errorf("expected a force-rejected marker, got %#v", markers)
+	}
+
+	if got := serve("POST", "/debug/flowcontrol/reject_all?priorityLevel=harness-pl&enabled=false").Code; got != 200 {
+		t.Fatalf("expected the clearing toggle to be accepted, got %d", got)
+	}
+	if got := strings.TrimSpace(serve("GET", "/debug/flowcontrol/reject_all").Body.String()); got != `[]` {
+		t.Errorf("expected no flagged levels after clearing, got %q", got)
+	}
+	executed = false
+	for _, m := range h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { executed = true }) {
+		if m.ID == continuum.MarkerForceRejected {
+			t.Errorf("expected no force-rejected marker after clearing, got %#v", m)
+		}
+	}
+	if !executed {
+		t.Error("expected the request to execute after the flag was cleared")
+	}
+}
+
+func TestQueueSelectionHistogram(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects(), QueueSelectionLogging: true})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	const requests = 6
+	for i := 0; i < requests; i++ {
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+	}
+
+	recorder := httptest.NewRecorder()
+	h.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/flowcontrol/queue_selections", nil))
+	var entries []struct {
+		PriorityLevel string `json:"priorityLevel"`
+		Queues        []struct {
+			QueueIndex int `json:"queueIndex"`
+			Requests   int `json:"requests"`
+		} `json:"queues"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+		t.Fatalf("failed to decode the response %q: %v", recorder.Body.String(), err)
+	}
+	if len(entries) != 1 || entries[0].PriorityLevel != "harness-pl" {
+		t.Fatalf("expected one entry for harness-pl, got %#v", entries)
+	}
+	total := 0
+	for _, q := range entries[0].Queues {
+		if q.QueueIndex < 0 || q.QueueIndex >= 8 {
+			t.Errorf("queue index %d out of range for a level with 8 queues", q.QueueIndex)
+		}
+		total += q.Requests
+	}
+	if total != requests {
+		t.Errorf("expected the histogram to cover all %d requests, got %d in %#v", requests, total, entries)
+	}
+	// One flow has one hand, so its requests land on at most HandSize
+	// distinct queues.
+	if got := len(entries[0].Queues); got > 2 {
+		t.Errorf("expected at most 2 distinct queues for a single flow with hand size 2, got %d", got)
+	}
+}
+
+func TestExecutionPanicMarker(t *testing.T) {
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Error("expected the panic to be re-raised")
+			}
+		}()
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { panic("handler crash") })
+	}()
+	var sawPanicMarker bool
+	for _, m := range h.Markers() {
+		if m.ID == continuum.MarkerExecutionPanicked {
+			sawPanicMarker = true
+			if m.PriorityLevel != "harness-pl" || m.FlowSchema != "harness-fs" {
+				t.Errorf("expected the marker to carry the classification, got %#v", m)
+			}
+		}
+	}
+	if !sawPanicMarker {
+		t.Errorf("expected an execution-panicked marker, got %#v", h.Markers())
+	}
+
+	// The panicked request's seats must have been released.
+	executed := false
+	for _, m := range h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { executed = true }) {
+		if m.ID == continuum.MarkerExecutionPanicked {
+			t.Errorf("expected no panic marker for a well-behaved request, got %#v", m)
+		}
+	}
+	if !executed {
+		t.Error("expected a request after the panic to execute")
+	}
+}
+
+func TestMaxQueueLengthOverride(t *testing.T) {
+	// A single queue makes the rejection point exact: one request
+	// executing, one waiting, and the next one over the overridden
+	// length of 1 must bounce.
+	objects := harnessConfigObjects()
+	plc := objects[1].(*flowcontrolv1.PriorityLevelConfiguration)
+	plc.Spec.Limited.LimitResponse.Queuing = &flowcontrolv1.QueuingConfiguration{
+		Queues:           1,
+		HandSize:         1,
+		QueueLengthLimit: 10,
+	}
+	h, err := NewHarness(Config{
+		Objects:                objects,
+		ServerConcurrencyLimit: 1,
+		MaxQueueLength:         map[string]int{"harness-pl": 1},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	rd := utilflowcontrol.RequestDigest{
+		RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+		User:        &user.DefaultInfo{Name: "harness-user"},
+	}
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+			close(started)
+			<-release
+		})
+	}()
+	<-started
+
+	queuedExecuted := false
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { queuedExecuted = true })
+	}()
+	if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+		recorder := httptest.NewRecorder()
+		h.mux.ServeHTTP(recorder, httptest.NewRequest("GET", "/debug/flowcontrol/queue_depths", nil))
+		var entries []struct {
+			PriorityLevel string `json:"priorityLevel"`
+			Waiting       int    `json:"waiting"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &entries); err != nil {
+			return false, nil
+		}
+		for _, entry := range entries {
+			if entry.PriorityLevel == "harness-pl" && entry.Waiting == 1 {
+				return true, nil
+			}
+		}
+		return false, nil
+	}); err != nil {
+		t.Fatalf("the second request never showed up as waiting: %v", err)
+	}
+
+	rejectedExecuted := false
+	h.Submit(context.Background(), rd, fcrequest.WorkEstimate{InitialSeats: 1}, func() { rejectedExecuted = true })
+	if rejectedExecuted {
+		t.Error("expected the request beyond the overridden queue length to be rejected")
+	}
+
+	close(release)
+	wg.Wait()
+	if !queuedExecuted {
+		t.Error("expected the waiting request to execute once the seat freed up")
+	}
+}
+
+func TestStrictFIFOSkipsAbandonedTickets(t *testing.T) {
+	h, err := NewHarness(Config{
+		Objects:                fifoConfigObjects(),
+		ServerConcurrencyLimit: 3,
+		StrictFIFOByFlowSchema: map[string]bool{"fifo-fs": true},
+	})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h.Stop()
+
+	digest := func(userName string) utilflowcontrol.RequestDigest {
+		return utilflowcontrol.RequestDigest{
+			RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+			User:        &user.DefaultInfo{Name: userName, Groups: []string{"fifo-group"}},
+		}
+	}
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	// Fill every seat of the priority level so the followers queue.
+	for i := 0; i < 3; i++ {
+		started := make(chan struct{})
+		wg.Add(1)
+		go func(userName string) {
+			defer wg.Done()
+			h.Submit(context.Background(), digest(userName), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+				close(started)
+				<-release
+			})
+		}(fmt.Sprintf("blocker-%d", i))
+		<-started
+	}
+	countEnqueued := func() int {
+		n := 0
+		for _, m := range h.Markers() {
+			if m.ID == continuum.MarkerRequestEnqueued {
+				n++
+			}
+		}
+		return n
+	}
+	waitEnqueued := func(want int) {
+		t.Helper()
+		if err := wait.PollImmediate(time.Millisecond, wait.ForeverTestTimeout, func() (bool, error) {
+			return countEnqueued() >= want, nil
+		}); err != nil {
+			t.Fatalf("enqueued marker %d never arrived: %v", want, err)
+		}
+	}
+	base := countEnqueued()
+	// The doomed follower takes its ticket between two live ones and
+	// is then cancelled before its turn arrives; the turn must skip
+	// past it rather than wedge the gate.
+	var firstExecuted, lastExecuted bool
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), digest("follower-first"), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+			firstExecuted = true
+		})
+	}()
+	waitEnqueued(base + 1)
+	doomedCtx, cancelDoomed := context.WithCancel(context.Background())
+	doomedDone := make(chan struct{})
+	go func() {
+		defer close(doomedDone)
+		h.Submit(doomedCtx, digest("follower-doomed"), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+			t.Error("the cancelled follower must not execute")
+		})
+	}()
+	waitEnqueued(base + 2)
+	cancelDoomed()
+	<-doomedDone
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		h.Submit(context.Background(), digest("follower-last"), fcrequest.WorkEstimate{InitialSeats: 1}, func() {
+			lastExecuted = true
+		})
+	}()
+	waitEnqueued(base + 3)
+
+	close(release)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		wg.Wait()
+	}()
+	select {
+	case <-finished:
+	case <-time.After(wait.ForeverTestTimeout):
+		t.Fatal("the gate wedged on the abandoned ticket")
+	}
+	if !firstExecuted || !lastExecuted {
+		t.Errorf("expected both surviving followers to execute, got first=%t last=%t", firstExecuted, lastExecuted)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	flowcontrolv1 "k8s.io/api/flowcontrol/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	fq "k8s.io/apiserver/pkg/util/flowcontrol/fairqueuing"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+)
+
+// RecordedRequest is one request captured by a Recorder: its arrival
+// time together with the attributes the filter classifies on, enough
+// to rebuild an equivalent RequestDigest for replay.
+type RecordedRequest struct {
+	Arrival     time.Time           `json:"arrival"`
+	RequestInfo request.RequestInfo `json:"requestInfo"`
+	User        user.DefaultInfo    `json:"user"`
+}
+
+// Recorder is a tracer that appends one JSON-encoded RecordedRequest
+// per matching request to a writer, so that a captured workload can
+// later be driven through Replay.  Register its Tracer with
+// continuum.RegisterTracer to start recording.
+type Recorder struct {
+	// Tracer is the tracer to register; its markers are discarded,
+	// only the request observations are kept.
+	Tracer *continuum.RequestTracer
+
+	lock sync.Mutex
+	enc  *json.Encoder
+	err  error
+}
+
+// discardSink drops markers, so that a recording tracer does not feed
+// the package-level sink.
+type discardSink struct{}
+
+func (discardSink) Write(continuum.Marker) {}
+
+// NewRecorder returns a Recorder whose tracer selects requests with
+// the given rules; nil means every resource request.
+func NewRecorder(w io.Writer, rules []continuum.Rule) *Recorder {
+	if rules == nil {
+		rules = []continuum.Rule{{Name: "record-all"}}
+	}
+	r := &Recorder{enc: json.NewEncoder(w)}
+	r.Tracer = &continuum.RequestTracer{
+		Name:           "recorder",
+		Rules:          rules,
+		Sink:           discardSink{},
+		ObserveRequest: r.observe,
+	}
+	return r
+}
+
+func (r *Recorder) observe(arrival time.Time, ri *request.RequestInfo, u user.Info) {
+	rec := RecordedRequest{Arrival: arrival, RequestInfo: *ri}
+	if u != nil {
+		rec.User = user.DefaultInfo{Name: u.GetName(), UID: u.GetUID(), Groups: u.GetGroups(), Extra: u.GetExtra()}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(rec)
+	}
+}
+
+// Err returns the first write error encountered while recording, if
+// any.
+func (r *Recorder) Err() error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.err
+}
+
+// ReadRecording decodes the requests a Recorder wrote, in recording
+// order.
+func ReadRecording(rd io.Reader) ([]RecordedRequest, error) {
+	dec := json.NewDecoder(rd)
+	var records []RecordedRequest
+	for {
+		var rec RecordedRequest
+		if err := dec.Decode(&rec); err != nil {
+			if errors.Is(err, io.EOF) {
+				return records, nil
+			}
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+}
+
+// Replay drives the recorded requests through the harness's filter at
+// their original inter-arrival timings, advancing the fake clock to
+// each arrival in turn, and returns once all of them have finished.
+// The records must be in arrival order.  Requests are submitted one
+// at a time: the next arrival is not started until the previous
+// request has been enqueued, dispatched, or rejected, so the order in
+// which requests reach the queues matches the recording.  execFn, if
+// non-nil, is invoked for each request the filter dispatches.
+func (h *Harness) Replay(records []RecordedRequest, execFn func(RecordedRequest)) {
+	var wg sync.WaitGroup
+	for i := range records {
+		rec := records[i]
+		if rec.Arrival.After(h.Clock.Now()) {
+			h.Clock.SetTime(rec.Arrival)
+		}
+		settled := make(chan struct{})
+		var settleOnce sync.Once
+		settle := func() { settleOnce.Do(func() { close(settled) }) }
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer settle()
+			rd := utilflowcontrol.RequestDigest{RequestInfo: &rec.RequestInfo, User: &rec.User}
+			h.Filter.Handle(context.Background(), rd,
+				func(fs *flowcontrolv1.FlowSchema, pl *flowcontrolv1.PriorityLevelConfiguration, flowDistinguisher string) {
+				},
+				func() fcrequest.WorkEstimate { return fcrequest.WorkEstimate{InitialSeats: 1} },
+				fq.QueueNoteFn(func(inQueue bool) {
+					if inQueue {
+						settle()
+					}
+				}),
+				func() {
+					settle()
+					if execFn != nil {
+						execFn(rec)
+					}
+				})
+		}()
+		<-settled
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for {
+		select {
+		case <-done:
+			return
+		default:
+			// Fire any due clock events (e.g. queue-wait timeouts) and
+			// let executing goroutines make progress.
+			h.Clock.Run(nil)
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
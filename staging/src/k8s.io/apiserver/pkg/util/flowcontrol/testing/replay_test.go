@@ -0,0 +1,113 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package testing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	utilflowcontrol "k8s.io/apiserver/pkg/util/flowcontrol"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	fcrequest "k8s.io/apiserver/pkg/util/flowcontrol/request"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	recording := &bytes.Buffer{}
+
+	h, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	recorder := NewRecorder(recording, nil)
+	continuum.RegisterTracer(recorder.Tracer)
+	digests := []utilflowcontrol.RequestDigest{
+		{
+			RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIVersion: "v1", Resource: "pods", Namespace: "default"},
+			User:        &user.DefaultInfo{Name: "harness-user"},
+		},
+		{
+			RequestInfo: &request.RequestInfo{IsResourceRequest: true, Verb: "create", APIGroup: "batch", APIVersion: "v1", Resource: "jobs", Namespace: "default"},
+			User:        &user.DefaultInfo{Name: "harness-user", Groups: []string{"harness-group"}},
+		},
+	}
+	h.Submit(context.Background(), digests[0], fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+	h.Clock.SetTime(h.Clock.Now().Add(50 * time.Millisecond))
+	h.Submit(context.Background(), digests[1], fcrequest.WorkEstimate{InitialSeats: 1}, func() {})
+	originalMarkers := h.Markers()
+	continuum.UnregisterTracer(recorder.Tracer)
+	h.Stop()
+	if err := recorder.Err(); err != nil {
+		t.Fatalf("recording failed: %v", err)
+	}
+
+	records, err := ReadRecording(bytes.NewReader(recording.Bytes()))
+	if err != nil {
+		t.Fatalf("ReadRecording failed: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected 2 recorded requests, got %#v", records)
+	}
+	if got, want := records[1].Arrival.Sub(records[0].Arrival), 50*time.Millisecond; got != want {
+		t.Errorf("expected an inter-arrival gap of %v, got %v", want, got)
+	}
+	if records[1].User.Name != "harness-user" || len(records[1].User.Groups) != 1 {
+		t.Errorf("recorded user lost attributes: %#v", records[1].User)
+	}
+
+	h2, err := NewHarness(Config{Objects: harnessConfigObjects()})
+	if err != nil {
+		t.Fatalf("NewHarness failed: %v", err)
+	}
+	defer h2.Stop()
+	var replayed []RecordedRequest
+	h2.Replay(records, func(rec RecordedRequest) {
+		replayed = append(replayed, rec)
+	})
+	if len(replayed) != 2 {
+		t.Fatalf("expected both requests to execute, got %#v", replayed)
+	}
+	replayMarkers := h2.Markers()
+	if len(replayMarkers) != len(originalMarkers) {
+		t.Fatalf("expected %d markers from replay, got %#v", len(originalMarkers), replayMarkers)
+	}
+	// Once requests overlap, goroutine scheduling can reorder marker
+	// emissions between requests, so the replay is compared as a
+	// multiset of (marker, classification) rather than as a sequence.
+	key := func(m continuum.Marker) string {
+		return fmt.Sprintf("%04d/%s/%s", m.ID, m.FlowSchema, m.PriorityLevel)
+	}
+	keys := func(markers []continuum.Marker) []string {
+		out := make([]string, 0, len(markers))
+		for _, m := range markers {
+			out = append(out, key(m))
+		}
+		sort.Strings(out)
+		return out
+	}
+	if got, want := keys(replayMarkers), keys(originalMarkers); !reflect.DeepEqual(got, want) {
+		t.Errorf("replayed markers diverged from the original: got %v, want %v", got, want)
+	}
+}
@@ -25,6 +25,8 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+	"k8s.io/apiserver/pkg/util/flowcontrol/metrics"
 
 	"k8s.io/klog/v2"
 )
@@ -158,7 +160,7 @@ func (w *watchTracker) RegisterWatch(r *http.Request) ForgetWatchFunc {
 
 func (w *watchTracker) updateIndexLocked(identifier *watchIdentifier, index *indexValue, incr int) {
 	if index == nil {
-		w.watchCount[*identifier] += incr
+		w.incrementWatchCountLocked(identifier, incr)
 	} else {
 		// For resources with defined index, for a given watch event we are
 		// only processing the watchers that:
@@ -175,11 +177,27 @@ func (w *watchTracker) updateIndexLocked(identifier *watchIdentifier, index *ind
 		// This seem to be true in almost all production clusters, which makes
 		// it a reasonable first step simplification to unblock progres on it.
 		if index.value == unsetValue || index.value == "" {
-			w.watchCount[*identifier] += incr
+			w.incrementWatchCountLocked(identifier, incr)
 		}
 	}
 }
 
+// incrementWatchCountLocked is the single place where the tracked watch
+// count is mutated, so that the exported gauge, the event counter, and
+// the emitted markers stay consistent with it.
+func (w *watchTracker) incrementWatchCountLocked(identifier *watchIdentifier, incr int) {
+	w.watchCount[*identifier] += incr
+	metrics.AddRegisteredWatches(identifier.apiGroup, identifier.resource, incr)
+	metrics.AddWatchTrackEvent(identifier.apiGroup, identifier.resource, incr > 0)
+	continuum.EmitWatchTrackChange(continuum.WatchTrackChange{
+		Registered:  incr > 0,
+		Time:        continuum.Now(),
+		Group:       identifier.apiGroup,
+		Resource:    identifier.resource,
+		ActiveCount: w.watchCount[*identifier],
+	})
+}
+
 func (w *watchTracker) forgetWatch(identifier *watchIdentifier, index *indexValue) ForgetWatchFunc {
 	return func() {
 		w.lock.Lock()
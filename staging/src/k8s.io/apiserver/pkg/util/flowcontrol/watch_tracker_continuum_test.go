@@ -0,0 +1,59 @@
+//go:build continuum
+
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package flowcontrol
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/apiserver/pkg/util/flowcontrol/continuum"
+)
+
+func TestWatchTrackChangeMarkers(t *testing.T) {
+	capture := &markerCapture{}
+	continuum.SetMarkerSink(capture)
+	tracker := &watchTracker{
+		indexes:    getBuiltinIndexes(),
+		watchCount: make(map[watchIdentifier]int),
+	}
+
+	req := httpRequest("GET", "/api/v1/namespaces/foo/endpoints", "watch=true")
+	requestInfo := &request.RequestInfo{
+		IsResourceRequest: true,
+		Verb:              "watch",
+		APIVersion:        "v1",
+		Resource:          "endpoints",
+		Namespace:         "foo",
+	}
+	r := req.WithContext(request.WithRequestInfo(context.Background(), requestInfo))
+
+	forget := tracker.RegisterWatch(r)
+	if forget == nil {
+		t.Fatal("watch should be registered")
+	}
+	if len(capture.markers) != 1 || capture.markers[0].ID != continuum.MarkerWatchRegistered {
+		t.Fatalf("expected one registration marker, got %v", capture.markers)
+	}
+
+	forget()
+	if len(capture.markers) != 2 || capture.markers[1].ID != continuum.MarkerWatchUnregistered {
+		t.Fatalf("expected an unregistration marker, got %v", capture.markers)
+	}
+}
@@ -0,0 +1,232 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package continuum records CONTINUUM benchmark markers from kubectl
+// and prints them as a single machine-readable JSON summary on exit,
+// complementing the markers the API server emits for API Priority and
+// Fairness. Emission is gated on the KUBECTL_CONTINUUM environment
+// variable so that normal kubectl users never see benchmark output; an
+// environment variable is used rather than a command-line flag because
+// the earliest marker fires before flag parsing has happened.
+package continuum
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/rest"
+)
+
+// EnvVar is the environment variable that enables marker emission when
+// set to "1" (or "true").
+const EnvVar = "KUBECTL_CONTINUUM"
+
+// TraceIDHeader is stamped on every outgoing request so server-side
+// CONTINUUM markers can be correlated with the kubectl invocation that
+// triggered them.
+const TraceIDHeader = "X-Continuum-Trace-Id"
+
+// RunIDEnvVar names the benchmark run this invocation belongs to.
+// When set, its value is stamped on every outgoing request via
+// RunIDHeader, so server-side markers from consecutive runs against
+// one apiserver can be separated afterwards.
+const RunIDEnvVar = "KUBECTL_CONTINUUM_RUN_ID"
+
+// RunIDHeader carries the run id to the server.
+const RunIDHeader = "X-Continuum-Run-Id"
+
+// runID is the run this invocation belongs to; empty when RunIDEnvVar
+// is unset.
+var runID = os.Getenv(RunIDEnvVar)
+
+// RunID returns the run id of this invocation, or the empty string if
+// none was configured.
+func RunID() string {
+	return runID
+}
+
+// traceID identifies this invocation; one process gets one id for all
+// of its requests.
+var traceID = uuid.New().String()
+
+// TraceID returns the per-invocation trace id.
+func TraceID() string {
+	return traceID
+}
+
+// Marker identifiers emitted by kubectl. These occupy the 04xx range;
+// the 02xx range is reserved for server-side markers.
+const (
+	// MarkerInvocationStart fires as soon as main starts, before the
+	// command tree is constructed.
+	MarkerInvocationStart = 400
+	// MarkerCommandConstructed fires once the command tree has been
+	// built, immediately before execution begins.
+	MarkerCommandConstructed = 402
+	// MarkerRequestEnter and MarkerRequestFinish bracket each API
+	// round trip made during command execution.
+	MarkerRequestEnter  = 404
+	MarkerRequestFinish = 405
+	// MarkerInvocationFinish fires after command execution completes.
+	MarkerInvocationFinish = 410
+)
+
+var enabled = func() bool {
+	value := os.Getenv(EnvVar)
+	return value == "1" || value == "true"
+}()
+
+// Enabled reports whether CONTINUUM marker emission is turned on for
+// this process.
+func Enabled() bool {
+	return enabled
+}
+
+// markerLabels maps marker identifiers to the labels recorded in the
+// trace summary.
+var markerLabels = map[int]string{
+	MarkerInvocationStart:    "invocation-start",
+	MarkerCommandConstructed: "command-constructed",
+	MarkerRequestEnter:       "request-enter",
+	MarkerRequestFinish:      "request-finish",
+	MarkerInvocationFinish:   "invocation-finish",
+}
+
+// RecordedMarker is one accumulated marker in the trace summary.
+type RecordedMarker struct {
+	ID    int    `json:"marker"`
+	Label string `json:"label"`
+	Time  int64  `json:"time"`
+}
+
+// Summary is the machine-readable record of one kubectl invocation,
+// printed as a single JSON object on exit so the benchmark harness does
+// not have to reassemble loose marker lines. Timestamps are
+// nanoseconds since the Unix epoch; zero means the corresponding marker
+// never fired.
+type Summary struct {
+	TraceID              string           `json:"traceID"`
+	RunID                string           `json:"runID,omitempty"`
+	StartTime            int64            `json:"startTime"`
+	CommandConstructedAt int64            `json:"commandConstructedTime"`
+	FinishTime           int64            `json:"finishTime"`
+	ExitCode             int              `json:"exitCode"`
+	ElapsedNanos         int64            `json:"elapsedNanos"`
+	Markers              []RecordedMarker `json:"markers"`
+}
+
+var (
+	recordMu sync.Mutex
+	recorded []RecordedMarker
+)
+
+// EmitMarker accumulates a CONTINUUM marker for the trace summary if
+// emission is enabled. Markers are held in memory rather than printed
+// individually; PrintSummary flushes them as one JSON object on exit.
+func EmitMarker(id int) {
+	if !Enabled() {
+		return
+	}
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	recorded = append(recorded, RecordedMarker{ID: id, Label: markerLabels[id], Time: time.Now().UnixNano()})
+}
+
+// buildSummary assembles the summary from the accumulated markers.
+func buildSummary(exitCode int) Summary {
+	recordMu.Lock()
+	defer recordMu.Unlock()
+	summary := Summary{TraceID: traceID, RunID: runID, ExitCode: exitCode, Markers: recorded}
+	for _, m := range recorded {
+		switch m.ID {
+		case MarkerInvocationStart:
+			summary.StartTime = m.Time
+		case MarkerCommandConstructed:
+			summary.CommandConstructedAt = m.Time
+		case MarkerInvocationFinish:
+			summary.FinishTime = m.Time
+		}
+	}
+	if summary.StartTime != 0 && summary.FinishTime != 0 {
+		summary.ElapsedNanos = summary.FinishTime - summary.StartTime
+	}
+	return summary
+}
+
+// PrintSummary prints the accumulated trace as a single JSON object on
+// stdout. It is a no-op unless emission is enabled.
+func PrintSummary(exitCode int) {
+	if !Enabled() {
+		return
+	}
+	out, err := json.Marshal(buildSummary(exitCode))
+	if err != nil {
+		return
+	}
+	fmt.Printf("CONTINUUM %s\n", out)
+}
+
+// AddTransportHooks wraps the client transport so each API round trip
+// is bracketed by MarkerRequestEnter and MarkerRequestFinish. It is a
+// no-op unless emission is enabled.
+func AddTransportHooks(kubeConfigFlags *genericclioptions.ConfigFlags) {
+	if !Enabled() {
+		return
+	}
+	wrapConfigFn := kubeConfigFlags.WrapConfigFn
+	kubeConfigFlags.WrapConfigFn = func(c *rest.Config) *rest.Config {
+		if wrapConfigFn != nil {
+			c = wrapConfigFn(c)
+		}
+		c.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+			return &markerRoundTripper{delegate: rt}
+		})
+		return c
+	}
+}
+
+// markerRoundTripper emits markers around each request it delegates and
+// stamps the per-invocation trace id header on the request.
+type markerRoundTripper struct {
+	delegate http.RoundTripper
+}
+
+func (m *markerRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req.Header.Set(TraceIDHeader, traceID)
+	if runID != "" {
+		req.Header.Set(RunIDHeader, runID)
+	}
+	EmitMarker(MarkerRequestEnter)
+	resp, err := m.delegate.RoundTrip(req)
+	EmitMarker(MarkerRequestFinish)
+	return resp, err
+}
+
+// CancelRequest implements the optional canceler interface so request
+// cancellation still reaches the underlying transport.
+func (m *markerRoundTripper) CancelRequest(req *http.Request) {
+	type canceler interface{ CancelRequest(*http.Request) }
+	if c, ok := m.delegate.(canceler); ok {
+		c.CancelRequest(req)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2026 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package continuum
+
+import (
+	"net/http"
+	"testing"
+)
+
+type headerCapturingTransport struct {
+	got http.Header
+}
+
+func (t *headerCapturingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.got = req.Header
+	return &http.Response{StatusCode: http.StatusOK}, nil
+}
+
+func TestTraceIDHeaderStamped(t *testing.T) {
+	delegate := &headerCapturingTransport{}
+	rt := &markerRoundTripper{delegate: delegate}
+	req, err := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := rt.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := delegate.got.Get(TraceIDHeader); got != TraceID() {
+		t.Errorf("expected trace id %q on request, got %q", TraceID(), got)
+	}
+}
+
+func TestBuildSummary(t *testing.T) {
+	origEnabled, origRecorded := enabled, recorded
+	defer func() { enabled, recorded = origEnabled, origRecorded }()
+	enabled = true
+	recorded = nil
+
+	EmitMarker(MarkerInvocationStart)
+	EmitMarker(MarkerCommandConstructed)
+	EmitMarker(MarkerRequestEnter)
+	EmitMarker(MarkerRequestFinish)
+	EmitMarker(MarkerInvocationFinish)
+
+	summary := buildSummary(1)
+	if summary.ExitCode != 1 {
+		t.Errorf("expected exit code 1, got %d", summary.ExitCode)
+	}
+	if len(summary.Markers) != 5 {
+		t.Fatalf("expected 5 markers, got %d", len(summary.Markers))
+	}
+	if summary.Markers[0].Label != "invocation-start" {
+		t.Errorf("unexpected label %q", summary.Markers[0].Label)
+	}
+	if summary.StartTime == 0 || summary.CommandConstructedAt == 0 || summary.FinishTime == 0 {
+		t.Errorf("expected all summary timestamps to be set, got %+v", summary)
+	}
+	if summary.ElapsedNanos != summary.FinishTime-summary.StartTime {
+		t.Errorf("elapsed %d does not match finish-start %d", summary.ElapsedNanos, summary.FinishTime-summary.StartTime)
+	}
+}
+
+func TestDisabledRecordsNothing(t *testing.T) {
+	origEnabled, origRecorded := enabled, recorded
+	defer func() { enabled, recorded = origEnabled, origRecorded }()
+	enabled = false
+	recorded = nil
+
+	EmitMarker(MarkerInvocationStart)
+	if len(recorded) != 0 {
+		t.Errorf("expected no markers recorded while disabled, got %d", len(recorded))
+	}
+}